@@ -0,0 +1,60 @@
+package debugmonitor
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// csrfCookieName is the name of the cookie that holds the CSRF token.
+	csrfCookieName = "_debugmonitor_csrf"
+	// csrfHeaderName is the request header the dashboard sends the CSRF token in.
+	// HTMX requests send it via hx-headers, and fetch requests read it from the csrf-token meta tag.
+	csrfHeaderName = "X-CSRF-Token"
+	// csrfFormField is the form field name accepted as an alternative to the header.
+	csrfFormField = "_csrf"
+)
+
+// csrfToken returns the CSRF token of the client, issuing a new one in a cookie if the client does not have one.
+func csrfToken(c echo.Context) (string, error) {
+	if cookie, err := c.Cookie(csrfCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	c.SetCookie(&http.Cookie{
+		Name:     csrfCookieName,
+		Value:    token,
+		Path:     c.Request().URL.Path,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token, nil
+}
+
+// validateCSRFToken checks that the request carries the same CSRF token as its cookie
+// (the double-submit cookie pattern).
+func validateCSRFToken(c echo.Context) error {
+	cookie, err := c.Cookie(csrfCookieName)
+	if err != nil || cookie.Value == "" {
+		return echo.NewHTTPError(http.StatusForbidden, "missing CSRF token")
+	}
+
+	token := c.Request().Header.Get(csrfHeaderName)
+	if token == "" {
+		token = c.FormValue(csrfFormField)
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+		return echo.NewHTTPError(http.StatusForbidden, "invalid CSRF token")
+	}
+	return nil
+}
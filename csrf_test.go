@@ -0,0 +1,79 @@
+package debugmonitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// withCSRFToken sets a CSRF token to the request as both the cookie and the header.
+func withCSRFToken(req *http.Request) *http.Request {
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "test-token"})
+	req.Header.Set(csrfHeaderName, "test-token")
+	return req
+}
+
+func TestManager_CSRF(t *testing.T) {
+	m := New()
+	monitor := &Monitor{Name: "test"}
+	m.AddMonitor(monitor)
+	monitor.Add("a")
+
+	e := echo.New()
+	e.Any("/monitor", m.Handler())
+
+	// Rendering the dashboard issues a CSRF token cookie
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=test", nil))
+	var token string
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == csrfCookieName {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		t.Fatal("Expected CSRF token cookie to be issued")
+	}
+
+	// POST without a token is rejected
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/monitor?monitor=test&action=clear", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rec.Code)
+	}
+
+	// POST with a mismatched token is rejected
+	req := httptest.NewRequest(http.MethodPost, "/monitor?monitor=test&action=clear", nil)
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+	req.Header.Set(csrfHeaderName, "wrong")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rec.Code)
+	}
+	if monitor.store.Len() != 1 {
+		t.Errorf("Expected 1 record, got %d", monitor.store.Len())
+	}
+
+	// POST with the issued token is accepted
+	req = httptest.NewRequest(http.MethodPost, "/monitor?monitor=test&action=clear", nil)
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+	req.Header.Set(csrfHeaderName, token)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", rec.Code)
+	}
+	if monitor.store.Len() != 0 {
+		t.Errorf("Expected 0 records, got %d", monitor.store.Len())
+	}
+
+	// The clear action is not allowed via GET
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=test&action=clear", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+}
@@ -80,7 +80,7 @@ func main() {
 	e.HTTPErrorHandler = monitors.HTTPErrorHandlerWrapper(errorRecorder, e.HTTPErrorHandler)
 
 	// Register the monitor handler
-	e.GET("/monitor", m.Handler())
+	e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor", m.Handler())
 
 	// Test endpoints to demonstrate various request types
 	e.GET("/test", func(c echo.Context) error {
@@ -0,0 +1,36 @@
+package debugmonitor
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Dumper receives the values passed to Dump and DumpContext.
+type Dumper func(ctx context.Context, v ...any)
+
+// dumper is the Dumper set by SetDumper. Dumps are discarded while it is nil.
+var dumper atomic.Pointer[Dumper]
+
+// SetDumper sets the function that Dump and DumpContext send values to, such as the recorder returned by
+// monitors.NewDumpsMonitor. Passing nil discards the dumps.
+func SetDumper(d Dumper) {
+	if d == nil {
+		dumper.Store(nil)
+		return
+	}
+	dumper.Store(&d)
+}
+
+// Dump sends the values to the Dumper set by SetDumper, so that debug dumps go to the dashboard
+// instead of stdout. It does nothing if no Dumper is set.
+func Dump(v ...any) {
+	DumpContext(context.Background(), v...)
+}
+
+// DumpContext is like Dump but takes the context the values are dumped in, such as the context of
+// an HTTP request, so that the dump is correlated to the request.
+func DumpContext(ctx context.Context, v ...any) {
+	if d := dumper.Load(); d != nil {
+		(*d)(ctx, v...)
+	}
+}
@@ -0,0 +1,31 @@
+package debugmonitor
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	defer SetDumper(nil)
+
+	// Dumps are discarded while no dumper is set
+	Dump("discarded")
+
+	var got []any
+	var gotID string
+	SetDumper(func(ctx context.Context, v ...any) {
+		gotID = RequestIDFromContext(ctx)
+		got = v
+	})
+	DumpContext(ContextWithRequestID(context.Background(), "req-1"), "a", 1)
+	if gotID != "req-1" || len(got) != 2 || got[0] != "a" || got[1] != 1 {
+		t.Errorf("Unexpected dump: %q %v", gotID, got)
+	}
+
+	SetDumper(nil)
+	got = nil
+	Dump("discarded")
+	if got != nil {
+		t.Errorf("Expected the dump to be discarded, got %v", got)
+	}
+}
@@ -0,0 +1,92 @@
+package debugmonitor
+
+import (
+	"sync"
+)
+
+// MonitorEvent represents a data entry added to one of the monitors of a Manager.
+type MonitorEvent struct {
+	MonitorName string     `json:"monitorName"`
+	Entry       *DataEntry `json:"entry"`
+}
+
+// Subscription represents a subscription to the events of all monitors of a Manager.
+// Use the C channel to receive notifications when new data is added to any monitor.
+// Call Close() when done to clean up resources.
+type Subscription struct {
+	C       <-chan *MonitorEvent // Channel to receive monitor events
+	manager *Manager
+	ch      chan *MonitorEvent
+	closed  bool
+	mu      sync.Mutex
+}
+
+// Close unsubscribes from the Manager and closes the event channel.
+// After calling Close, the C channel will be closed and no more events will be received.
+func (s *Subscription) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+
+	s.manager.unsubscribe(s)
+	close(s.ch)
+}
+
+// Subscribe creates a new subscription to the events of all monitors.
+// The returned Subscription provides a channel that will receive notifications
+// when new data is added to any monitor connected to this Manager.
+// Call Close() on the returned Subscription when done to clean up resources.
+func (m *Manager) Subscribe() *Subscription {
+	ch := make(chan *MonitorEvent, 100) // Buffered to prevent blocking
+	sub := &Subscription{
+		C:       ch,
+		manager: m,
+		ch:      ch,
+	}
+
+	m.subscriptionsMu.Lock()
+	m.subscriptions = append(m.subscriptions, sub)
+	m.subscriptionsMu.Unlock()
+
+	return sub
+}
+
+// unsubscribe removes a Subscription from the active subscriptions.
+func (m *Manager) unsubscribe(sub *Subscription) {
+	m.subscriptionsMu.Lock()
+	defer m.subscriptionsMu.Unlock()
+
+	for i, s := range m.subscriptions {
+		if s == sub {
+			m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
+			break
+		}
+	}
+}
+
+// publish sends an event to all active subscriptions.
+// Non-blocking sends are used to prevent slow consumers from blocking the monitors.
+func (m *Manager) publish(monitorName string, entry *DataEntry) {
+	m.subscriptionsMu.RLock()
+	defer m.subscriptionsMu.RUnlock()
+
+	if len(m.subscriptions) == 0 {
+		return
+	}
+
+	event := &MonitorEvent{
+		MonitorName: monitorName,
+		Entry:       entry,
+	}
+	for _, sub := range m.subscriptions {
+		select {
+		case sub.ch <- event:
+		default:
+			// Channel is full, skip this subscriber to avoid blocking
+		}
+	}
+}
@@ -5,15 +5,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
 // RenderTemplate executes a template with the given data and returns the result as HTML response.
+// If the template is parsed with TemplateFuncs, its messages are translated to the locale of the manager.
 func RenderTemplate(c echo.Context, tmpl *template.Template, data any) error {
+	// The template is cloned to bind the functions of the request, as an executed template cannot be cloned
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	if funcs := translateFuncs(c); funcs != nil {
+		tmpl.Funcs(funcs)
+	}
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return err
@@ -21,7 +32,58 @@ func RenderTemplate(c echo.Context, tmpl *template.Template, data any) error {
 	return c.HTML(http.StatusOK, buf.String())
 }
 
+// ClearedAtHeader is the response header of the data endpoint that carries the time the store was last cleared,
+// in Unix milliseconds, or 0 if it has never been cleared. Polling clients reset their entries when it changes.
+const ClearedAtHeader = "X-Debugmonitor-Cleared-At"
+
+// EntryFilter reports whether an entry should be sent to the client.
+type EntryFilter func(entry *DataEntry) bool
+
+// TextFilterParam is the query parameter of the stream, data and long polling endpoints that restricts the entries
+// to those whose payload, encoded as JSON, contains its value case-insensitively. It is combined with the filter
+// of the monitor, such as the level of the logs or the status of the requests.
+const TextFilterParam = "q"
+
+// ClientParam is the query parameter with which the dashboard identifies each browser tab on the data and
+// long polling endpoints, so that the tabs behind the same address are reported as separate subscribers.
+// Clients without it are identified by their address.
+const ClientParam = "client"
+
+// textFilter returns filter restricted by the TextFilterParam query parameter, or filter as is without it.
+func textFilter(c echo.Context, filter EntryFilter) EntryFilter {
+	q := strings.ToLower(c.QueryParam(TextFilterParam))
+	if q == "" {
+		return filter
+	}
+	return func(entry *DataEntry) bool {
+		if filter != nil && !filter(entry) {
+			return false
+		}
+		return strings.Contains(entry.searchText(), q)
+	}
+}
+
+// HandleSSEStream streams store entries to the client with Server-Sent Events.
+// It accepts a "since" query parameter to send only entries with ID greater than the specified value,
+// and the TextFilterParam query parameter to send only the entries that contain its value.
+// Each event is named after its type and carries a StreamEvent as data: an "add" event for each entry,
+// a "clear" event when the store is cleared so that the client resets its entries, and a "stats" event
+// every 30 seconds, which also keeps the connection alive.
+// If the manager enables batching with WithStreamBatching, new entries are sent in events named "batch".
+// If the manager limits the rate with WithStreamRateLimit, the entries over the limit are skipped and counted
+// in events named "skipped". If the manager enables compression with WithCompression, the stream is compressed
+// with gzip. If the number of streams reaches the limit set with WithMaxStreams, it responds with
+// 503 Service Unavailable and a StreamLimitResponse instead.
 func HandleSSEStream(c echo.Context, store *Store) error {
+	return HandleSSEStreamWithFilter(c, store, nil)
+}
+
+// HandleSSEStreamWithFilter is like HandleSSEStream but sends only the entries that match the filter.
+// The filter is applied in the subscription to the store, so that the entries that do not match are never queued
+// for the client. A nil filter matches all entries.
+func HandleSSEStreamWithFilter(c echo.Context, store *Store, filter EntryFilter) error {
+	filter = textFilter(c, filter)
+
 	// Parse the sinceID parameter
 	sinceID := int64(0)
 	if sinceIDStr := c.QueryParam("since"); sinceIDStr != "" {
@@ -30,31 +92,97 @@ func HandleSSEStream(c echo.Context, store *Store) error {
 		}
 	}
 
+	cfg := streamConfigFromContext(c)
+	monitor := c.QueryParam("monitor")
+
+	// Refuse the stream over the limit, so that the client falls back to polling
+	if !cfg.acquireStream() {
+		c.Response().Header().Set(echo.HeaderRetryAfter, "5")
+		return c.JSON(http.StatusServiceUnavailable, &StreamLimitResponse{
+			Error:    "too many streams",
+			Fallback: "polling",
+		})
+	}
+	defer cfg.releaseStream()
+
 	// Set SSE headers
 	c.Response().Header().Set("Content-Type", "text/event-stream")
 	c.Response().Header().Set("Cache-Control", "no-cache")
 	c.Response().Header().Set("Connection", "keep-alive")
+	if cfg.compress && acceptsGzip(c) {
+		defer compressStream(c)()
+	}
 	c.Response().WriteHeader(http.StatusOK)
 
-	// Subscribe to add events
-	addEvent := store.NewAddEvent()
+	// Subscribe to the add events that match the filter and to clear events
+	addEvent := store.NewAddEventWithFilter(filter)
 	defer addEvent.Close()
+	clearEvent := store.NewClearEvent()
+	defer clearEvent.Close()
+	sub := store.trackStream(TransportSSE, c.RealIP(), addEvent, sinceID)
+	defer store.untrack(sub)
 
 	// Send initial data since the provided ID
 	entries := store.GetSince(sinceID)
 	for _, entry := range entries {
-		if err := sendSSEEvent(c, entry); err != nil {
+		sinceID = entry.Id
+		if filter != nil && !filter(entry) {
+			continue
+		}
+		if err := sendSSEEvent(c, &StreamEvent{Type: StreamEventAdd, Monitor: monitor, Entry: entry}); err != nil {
 			return err
 		}
-		sinceID = entry.Id
 	}
 
+	sub.sent(sinceID)
+
 	// Flush to send initial data
 	if f, ok := c.Response().Writer.(http.Flusher); ok {
 		f.Flush()
 	}
 
-	// Listen for new add events
+	// Entries are batched if the manager enables batching
+	var batch []*DataEntry
+	var batchTimer *time.Timer
+	var batchC <-chan time.Time
+	flushBatch := func() error {
+		if batchTimer != nil {
+			batchTimer.Stop()
+			batchTimer, batchC = nil, nil
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		err := sendSSEEvent(c, &StreamEvent{Type: StreamEventBatch, Monitor: monitor, Entries: batch})
+		last := batch[len(batch)-1].Id
+		batch = batch[:0]
+		if err != nil {
+			return err
+		}
+		if f, ok := c.Response().Writer.(http.Flusher); ok {
+			f.Flush()
+		}
+		sub.sent(last)
+		return nil
+	}
+	defer func() {
+		if batchTimer != nil {
+			batchTimer.Stop()
+		}
+	}()
+
+	// Entries over the rate limit are skipped and summarized periodically
+	var limiter *rateLimiter
+	var skippedC <-chan time.Time
+	if cfg.rateLimit > 0 {
+		limiter = newRateLimiter(cfg.rateLimit)
+		skippedTicker := time.NewTicker(StreamSkippedNoticeInterval)
+		defer skippedTicker.Stop()
+		skippedC = skippedTicker.C
+	}
+	skipped := 0
+
+	// Listen for new add and clear events
 	ctx := c.Request().Context()
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
@@ -69,34 +197,118 @@ func HandleSSEStream(c echo.Context, store *Store) error {
 				// Channel closed
 				return nil
 			}
-			if err := sendSSEEvent(c, entry); err != nil {
+			if limiter != nil && !limiter.allow(time.Now()) {
+				skipped++
+				sub.skipped.Add(1)
+				continue
+			}
+			if cfg.batchInterval > 0 {
+				batch = append(batch, entry)
+				if len(batch) >= cfg.batchSize {
+					if err := flushBatch(); err != nil {
+						return err
+					}
+				} else if batchTimer == nil {
+					// The batch is sent at most batchInterval after its first entry
+					batchTimer = time.NewTimer(cfg.batchInterval)
+					batchC = batchTimer.C
+				}
+				continue
+			}
+			if err := sendSSEEvent(c, &StreamEvent{Type: StreamEventAdd, Monitor: monitor, Entry: entry}); err != nil {
+				return err
+			}
+			if f, ok := c.Response().Writer.(http.Flusher); ok {
+				f.Flush()
+			}
+			sub.sent(entry.Id)
+		case <-skippedC:
+			if skipped == 0 {
+				continue
+			}
+			// The pending entries were added before the skipped ones
+			if err := flushBatch(); err != nil {
+				return err
+			}
+			if err := sendSSEEvent(c, &StreamEvent{Type: StreamEventSkipped, Monitor: monitor, Count: skipped}); err != nil {
+				return err
+			}
+			skipped = 0
+			if f, ok := c.Response().Writer.(http.Flusher); ok {
+				f.Flush()
+			}
+			sub.sent(0)
+		case <-batchC:
+			batchTimer, batchC = nil, nil
+			if err := flushBatch(); err != nil {
+				return err
+			}
+		case _, ok := <-clearEvent.C:
+			if !ok {
+				// Channel closed
+				return nil
+			}
+			// The pending entries are dropped instead of sent, as they were removed by the clear
+			// and the client resets its entries on the clear event anyway
+			batch = batch[:0]
+			if err := flushBatch(); err != nil {
+				return err
+			}
+			clearedAt := clearedAtMillis(store.ClearedAt())
+			if err := sendSSEEvent(c, &StreamEvent{Type: StreamEventClear, Monitor: monitor, ClearedAt: clearedAt}); err != nil {
 				return err
 			}
 			if f, ok := c.Response().Writer.(http.Flusher); ok {
 				f.Flush()
 			}
+			sub.sent(0)
 		case <-ticker.C:
-			// Send a comment as keepalive
-			fmt.Fprintf(c.Response().Writer, ": keepalive\n\n")
+			// Send the stats, which also keep the connection alive
+			stats := &StreamStats{Len: store.Len(), MaxRecords: store.MaxRecords()}
+			if err := sendSSEEvent(c, &StreamEvent{Type: StreamEventStats, Monitor: monitor, Stats: stats}); err != nil {
+				return err
+			}
 			if f, ok := c.Response().Writer.(http.Flusher); ok {
 				f.Flush()
 			}
+			sub.sent(0)
 		}
 	}
 }
 
-func sendSSEEvent(c echo.Context, entry *DataEntry) error {
-	data, err := json.Marshal(entry)
+// sendSSEEvent sends the event named after its type, with the event in the current version of the envelope as data.
+func sendSSEEvent(c echo.Context, event *StreamEvent) error {
+	event.Version = StreamVersion
+	data, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
-	_, err = fmt.Fprintf(c.Response().Writer, "data: %s\n\n", data)
+	_, err = fmt.Fprintf(c.Response().Writer, "event: %s\ndata: %s\n\n", event.Type, data)
 	return err
 }
 
+// clearedAtMillis returns clearedAt in Unix milliseconds, or 0 for the zero time.
+func clearedAtMillis(clearedAt time.Time) int64 {
+	if clearedAt.IsZero() {
+		return 0
+	}
+	return clearedAt.UnixMilli()
+}
+
 // HandleDataJSON returns store entries as JSON for polling mode.
-// It accepts a "since" query parameter to return only entries with ID greater than the specified value.
+// It accepts a "since" query parameter to return only entries with ID greater than the specified value,
+// and the TextFilterParam query parameter to return only the entries that contain its value.
+// The ClearedAtHeader response header tells the client when the store was last cleared.
+// If the manager enables compression with WithCompression, large responses are compressed with gzip.
 func HandleDataJSON(c echo.Context, store *Store) error {
+	return HandleDataJSONWithFilter(c, store, nil)
+}
+
+// HandleDataJSONWithFilter is like HandleDataJSON but returns only the entries that match the filter.
+// A nil filter matches all entries.
+func HandleDataJSONWithFilter(c echo.Context, store *Store, filter EntryFilter) error {
+	filter = textFilter(c, filter)
+
 	// Parse the sinceID parameter
 	sinceID := int64(0)
 	if sinceIDStr := c.QueryParam("since"); sinceIDStr != "" {
@@ -105,6 +317,171 @@ func HandleDataJSON(c echo.Context, store *Store) error {
 		}
 	}
 
-	entries := store.GetSince(sinceID)
-	return c.JSON(http.StatusOK, entries)
+	done := store.trackPoll(TransportPoll, c.QueryParam(ClientParam), c.RealIP())
+	entries := filterEntries(store.GetSince(sinceID), filter)
+	done(cursor(sinceID, entries))
+	c.Response().Header().Set(ClearedAtHeader, strconv.FormatInt(clearedAtMillis(store.ClearedAt()), 10))
+	return writeJSON(c, streamConfigFromContext(c), http.StatusOK, entries)
+}
+
+// LongPollTimeout is the maximum time HandleLongPoll waits for new entries before responding.
+// It is shorter than the idle timeouts of common proxies.
+const LongPollTimeout = 25 * time.Second
+
+// HandleLongPoll returns store entries as JSON like HandleDataJSON, but if there is no entry with ID greater than
+// the "since" query parameter, it waits until a new entry is added, the store is cleared or the timeout expires,
+// and then responds with the entries, which may be empty. The "timeout" query parameter sets the timeout in seconds,
+// up to LongPollTimeout, which is also the default. It is a transport for the environments where plain polling
+// is too slow and Server-Sent Events are not passed through.
+func HandleLongPoll(c echo.Context, store *Store) error {
+	return HandleLongPollWithFilter(c, store, nil)
+}
+
+// HandleLongPollWithFilter is like HandleLongPoll but returns only the entries that match the filter,
+// and waits for an entry that matches it. A nil filter matches all entries.
+func HandleLongPollWithFilter(c echo.Context, store *Store, filter EntryFilter) error {
+	filter = textFilter(c, filter)
+
+	// Parse the sinceID parameter
+	sinceID := int64(0)
+	if sinceIDStr := c.QueryParam("since"); sinceIDStr != "" {
+		if id, err := strconv.ParseInt(sinceIDStr, 10, 64); err == nil {
+			sinceID = id
+		}
+	}
+	timeout := LongPollTimeout
+	if v := c.QueryParam("timeout"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			timeout = min(time.Duration(seconds)*time.Second, LongPollTimeout)
+		}
+	}
+
+	done := store.trackPoll(TransportLongPoll, c.QueryParam(ClientParam), c.RealIP())
+	var entries []*DataEntry
+	defer func() {
+		done(cursor(sinceID, entries))
+	}()
+
+	// Subscribe before reading the store, so that an entry added in between is not missed
+	addEvent := store.NewAddEventWithFilter(filter)
+	defer addEvent.Close()
+	clearEvent := store.NewClearEvent()
+	defer clearEvent.Close()
+
+	entries = filterEntries(store.GetSince(sinceID), filter)
+	if len(entries) == 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		ctx := c.Request().Context()
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				// Client disconnected
+				return nil
+			case <-timer.C:
+				break wait
+			case <-addEvent.C:
+				break wait
+			case <-clearEvent.C:
+				break wait
+			}
+		}
+		// Read the store again to include the entries added together with the one that woke up
+		entries = filterEntries(store.GetSince(sinceID), filter)
+	}
+
+	c.Response().Header().Set(ClearedAtHeader, strconv.FormatInt(clearedAtMillis(store.ClearedAt()), 10))
+	return writeJSON(c, streamConfigFromContext(c), http.StatusOK, entries)
+}
+
+// cursor returns the ID of the last of the entries sent to a client that requested the entries since sinceID.
+func cursor(sinceID int64, entries []*DataEntry) int64 {
+	if len(entries) == 0 {
+		return sinceID
+	}
+	return entries[len(entries)-1].Id
+}
+
+// filterEntries returns the entries that match the filter. A nil filter matches all entries.
+func filterEntries(entries []*DataEntry, filter EntryFilter) []*DataEntry {
+	if filter == nil {
+		return entries
+	}
+	filtered := make([]*DataEntry, 0, len(entries))
+	for _, entry := range entries {
+		if filter(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// HandleDownload streams the store entries that match the filter as a file attachment, so that the captured data
+// can be attached to a bug report. With the "format" query parameter set to "ndjson", each entry is written
+// as a line of JSON to name.ndjson. Otherwise each entry is written as the text returned by text, which should
+// end with a newline, to name.log. The "from" and "to" query parameters (RFC 3339) restrict the entries to those
+// added in the time range. A nil filter matches all entries.
+func HandleDownload(c echo.Context, store *Store, filter EntryFilter, name string, text func(entry *DataEntry) string) error {
+	var from, to time.Time
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid from")
+		}
+		from = t
+	}
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid to")
+		}
+		to = t
+	}
+
+	ndjson := c.QueryParam("format") == "ndjson"
+	res := c.Response()
+	if ndjson {
+		res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name+".ndjson"))
+	} else {
+		res.Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name+".log"))
+	}
+	res.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(res)
+	for _, entry := range store.GetSince(0) {
+		added := ExtractTimestamp(entry.Id)
+		if (!from.IsZero() && added.Before(from)) || (!to.IsZero() && added.After(to)) {
+			continue
+		}
+		if filter != nil && !filter(entry) {
+			continue
+		}
+		if ndjson {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := io.WriteString(res, text(entry)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetEntryFromQuery returns the store entry whose ID is given by the "id" query parameter.
+// It returns an HTTP error if the ID is invalid or the entry is not found.
+func GetEntryFromQuery(c echo.Context, store *Store) (*DataEntry, error) {
+	id, err := strconv.ParseInt(c.QueryParam("id"), 10, 64)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	entry := store.GetById(id)
+	if entry == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "entry not found")
+	}
+	return entry, nil
 }
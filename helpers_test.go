@@ -0,0 +1,528 @@
+package debugmonitor
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandleSSEStream_Clear(t *testing.T) {
+	store := NewStore(10)
+	store.Add("old")
+
+	e := echo.New()
+	e.GET("/stream", func(c echo.Context) error {
+		return HandleSSEStream(c, store)
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for the stream")
+			return ""
+		}
+	}
+
+	if line := next(); line != "event: add" {
+		t.Fatalf("Expected the add event, got %q", line)
+	}
+	if line := next(); !strings.HasPrefix(line, `data: {"v":1,"type":"add",`) || !strings.Contains(line, `"payload":"old"`) {
+		t.Fatalf("Expected the initial entry, got %q", line)
+	}
+	next()
+
+	// The subscription is made before the initial entries are sent, so the clear is not missed
+	store.Clear()
+	if line := next(); line != "event: clear" {
+		t.Fatalf("Expected the clear event, got %q", line)
+	}
+	if line := next(); !strings.HasPrefix(line, `data: {"v":1,"type":"clear","clearedAt":`) {
+		t.Errorf("Expected the time of the clear, got %q", line)
+	}
+}
+
+func TestHandleSSEStream_Batching(t *testing.T) {
+	m := New(WithStreamBatching(50*time.Millisecond, 3))
+	monitor := &Monitor{
+		Name: "test",
+		ActionHandler: func(c echo.Context, store *Store, action string) error {
+			return HandleSSEStream(c, store)
+		},
+	}
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/monitor?monitor=test&action=stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	reader := bufio.NewReader(res.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line == "\n" {
+				return strings.Join(lines, "\n")
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+
+	// Wait for the subscription of the stream
+	for monitor.store.NumSubscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A full batch is sent at once
+	for _, payload := range []string{"a", "b", "c", "d"} {
+		monitor.Add(payload)
+	}
+	event := readEvent()
+	if !strings.HasPrefix(event, "event: batch\ndata: {") || !strings.Contains(event, `"payload":"c"`) || strings.Contains(event, `"payload":"d"`) {
+		t.Errorf("Expected a batch of 3 entries, got %q", event)
+	}
+	// The rest is sent after the interval
+	start := time.Now()
+	event = readEvent()
+	if !strings.Contains(event, `"payload":"d"`) {
+		t.Errorf("Expected a batch of the remaining entry, got %q", event)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected the batch to be sent after the interval, got %v", elapsed)
+	}
+}
+
+func TestHandleSSEStream_ClearPendingBatch(t *testing.T) {
+	m := New(WithStreamBatching(time.Minute, 2))
+	monitor := &Monitor{
+		Name: "test",
+		ActionHandler: func(c echo.Context, store *Store, action string) error {
+			return HandleSSEStream(c, store)
+		},
+	}
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/monitor?monitor=test&action=stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	reader := bufio.NewReader(res.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line == "\n" {
+				return strings.Join(lines, "\n")
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+
+	// Wait for the subscription of the stream
+	for monitor.store.NumSubscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Wait for the entry to be pending in the batch of the stream
+	monitor.Add("a")
+	for len(monitor.store.addEvents[0].ch) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The pending entry is dropped by the clear
+	monitor.store.Clear()
+	if event := readEvent(); !strings.HasPrefix(event, "event: clear\n") {
+		t.Fatalf("Expected a clear event, got %q", event)
+	}
+	monitor.Add("b")
+	monitor.Add("c")
+	event := readEvent()
+	if !strings.HasPrefix(event, "event: batch\n") || !strings.Contains(event, `"payload":"b"`) || strings.Contains(event, `"payload":"a"`) {
+		t.Errorf("Expected a batch of the entries added after the clear, got %q", event)
+	}
+}
+
+func TestHandleSSEStream_RateLimit(t *testing.T) {
+	m := New(WithStreamRateLimit(2))
+	monitor := &Monitor{
+		Name: "test",
+		ActionHandler: func(c echo.Context, store *Store, action string) error {
+			return HandleSSEStream(c, store)
+		},
+	}
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/monitor?monitor=test&action=stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	reader := bufio.NewReader(res.Body)
+
+	// Wait for the subscription of the stream
+	for monitor.store.NumSubscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The entries over the burst are skipped
+	for _, payload := range []string{"a", "b", "c", "d", "e"} {
+		monitor.Add(payload)
+	}
+	var lines []string
+	for len(lines) < 6 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != "\n" {
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+	if !strings.Contains(lines[1], `"payload":"a"`) || !strings.Contains(lines[3], `"payload":"b"`) {
+		t.Errorf("Expected the entries within the burst, got %q", lines)
+	}
+	if lines[4] != "event: skipped" {
+		t.Fatalf("Expected the skipped event, got %q", lines[4])
+	}
+	if !strings.HasSuffix(lines[5], `"count":3}`) {
+		t.Errorf("Expected the number of skipped entries, got %q", lines[5])
+	}
+}
+
+func TestHandleSSEStream_Compression(t *testing.T) {
+	m := New(WithCompression())
+	monitor := &Monitor{
+		Name: "test",
+		ActionHandler: func(c echo.Context, store *Store, action string) error {
+			return HandleSSEStream(c, store)
+		},
+	}
+	m.AddMonitor(monitor)
+	monitor.Add("old")
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	// The transport requests and decompresses gzip transparently
+	res, err := http.Get(server.URL + "/monitor?monitor=test&action=stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if !res.Uncompressed {
+		t.Fatal("Expected the stream to be compressed")
+	}
+	reader := bufio.NewReader(res.Body)
+
+	// Each event is flushed through the compressor
+	reader.ReadString('\n')
+	if line, _ := reader.ReadString('\n'); !strings.Contains(line, `"payload":"old"`) {
+		t.Fatalf("Expected the initial entry, got %q", line)
+	}
+	reader.ReadString('\n')
+	monitor.Add("new")
+	reader.ReadString('\n')
+	if line, _ := reader.ReadString('\n'); !strings.Contains(line, `"payload":"new"`) {
+		t.Errorf("Expected the new entry, got %q", line)
+	}
+}
+
+func TestHandleDataJSON_Compression(t *testing.T) {
+	m := New(WithCompression())
+	monitor := &Monitor{
+		Name: "test",
+		ActionHandler: func(c echo.Context, store *Store, action string) error {
+			return HandleDataJSON(c, store)
+		},
+	}
+	m.AddMonitor(monitor)
+	handler := m.Handler()
+	e := echo.New()
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/monitor?monitor=test&action=data", nil)
+		req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+		rec := httptest.NewRecorder()
+		if err := handler(e.NewContext(req, rec)); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	// Small responses are not compressed
+	monitor.Add("small")
+	if rec := get(); rec.Header().Get(echo.HeaderContentEncoding) != "" {
+		t.Errorf("Expected the small response not to be compressed")
+	}
+
+	monitor.Add(strings.Repeat("large ", CompressionMinSize))
+	rec := get()
+	if rec.Header().Get(echo.HeaderContentEncoding) != "gzip" {
+		t.Fatal("Expected the large response to be compressed")
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []*DataEntry
+	if err := json.NewDecoder(gz).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 entries, got %d", len(entries))
+	}
+}
+
+func TestHandleDataJSON_ClearedAt(t *testing.T) {
+	store := NewStore(10)
+	e := echo.New()
+
+	rec := httptest.NewRecorder()
+	if err := HandleDataJSON(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec), store); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get(ClearedAtHeader); got != "0" {
+		t.Errorf("Expected 0 before the store is cleared, got %q", got)
+	}
+
+	store.Clear()
+	rec = httptest.NewRecorder()
+	if err := HandleDataJSON(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec), store); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get(ClearedAtHeader); got == "0" || got == "" {
+		t.Errorf("Expected the time of the clear, got %q", got)
+	}
+}
+
+func TestHandleSSEStream_MaxStreams(t *testing.T) {
+	m := New(WithMaxStreams(1))
+	monitor := &Monitor{
+		Name: "test",
+		ActionHandler: func(c echo.Context, store *Store, action string) error {
+			return HandleSSEStream(c, store)
+		},
+	}
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/monitor?monitor=test&action=stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the first stream to be allowed, got %d", res.StatusCode)
+	}
+	for monitor.store.NumSubscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The stream over the limit is refused with a fallback to polling
+	refused, err := http.Get(server.URL + "/monitor?monitor=test&action=stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer refused.Body.Close()
+	if refused.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", refused.StatusCode)
+	}
+	var body StreamLimitResponse
+	if err := json.NewDecoder(refused.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Fallback != "polling" {
+		t.Errorf("Expected the polling fallback, got %q", body.Fallback)
+	}
+
+	// The stream is allowed again once the first one is closed
+	res.Body.Close()
+	for monitor.store.NumSubscribers() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for m.stream.streams.Load() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	res, err = http.Get(server.URL + "/monitor?monitor=test&action=stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Expected the stream to be allowed after the release, got %d", res.StatusCode)
+	}
+}
+
+func TestHandleLongPoll(t *testing.T) {
+	store := NewStore(10)
+	e := echo.New()
+	poll := func(query string, filter EntryFilter) []*DataEntry {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/?"+query, nil), rec)
+		if err := HandleLongPollWithFilter(c, store, filter); err != nil {
+			t.Fatal(err)
+		}
+		var entries []*DataEntry
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+
+	// It responds immediately if there are entries since the cursor
+	old := store.AddEntry("old")
+	if entries := poll("since=0", nil); len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+
+	// It responds empty after the timeout
+	if entries := poll("since="+strconv.FormatInt(old.Id, 10)+"&timeout=0", nil); len(entries) != 0 {
+		t.Errorf("Expected no entries after the timeout, got %d", len(entries))
+	}
+
+	// It waits for an entry that matches the filter
+	go func() {
+		for store.NumSubscribers() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		store.Add("skip")
+		store.Add("match")
+	}()
+	entries := poll("since="+strconv.FormatInt(old.Id, 10), func(entry *DataEntry) bool {
+		return entry.Payload != "skip"
+	})
+	if len(entries) != 1 || entries[0].Payload != "match" {
+		t.Errorf("Expected the matching entry, got %v", entries)
+	}
+}
+
+func TestHandleSSEStream_TextFilter(t *testing.T) {
+	store := NewStore(10)
+	store.Add(map[string]any{"message": "old Match"})
+	store.Add(map[string]any{"message": "old"})
+
+	e := echo.New()
+	e.GET("/stream", func(c echo.Context) error {
+		return HandleSSEStream(c, store)
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/stream?q=match")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	reader := bufio.NewReader(res.Body)
+	readData := func() string {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.HasPrefix(line, "data: ") {
+				return line
+			}
+		}
+	}
+
+	if line := readData(); !strings.Contains(line, "old Match") {
+		t.Fatalf("Expected the matching initial entry, got %q", line)
+	}
+	for store.NumSubscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	store.Add(map[string]any{"message": "new"})
+	store.Add(map[string]any{"message": "new match"})
+	if line := readData(); !strings.Contains(line, "new match") {
+		t.Errorf("Expected the matching new entry, got %q", line)
+	}
+}
+
+// countingPayload counts how many times it is encoded as JSON.
+type countingPayload struct {
+	count *atomic.Int32
+}
+
+func (p countingPayload) MarshalJSON() ([]byte, error) {
+	p.count.Add(1)
+	return []byte(`"Match"`), nil
+}
+
+func TestTextFilter_EncodesOnce(t *testing.T) {
+	store := NewStore(10)
+	e := echo.New()
+	var events []*AddEvent
+	for i := 0; i < 3; i++ {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/stream?q=match", nil), httptest.NewRecorder())
+		event := store.NewAddEventWithFilter(textFilter(c, nil))
+		defer event.Close()
+		events = append(events, event)
+	}
+
+	var count atomic.Int32
+	store.Add(countingPayload{count: &count})
+	for _, event := range events {
+		select {
+		case <-event.C:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the matching entry")
+		}
+	}
+	if n := count.Load(); n != 1 {
+		t.Errorf("Expected the payload to be encoded once for all subscribers, got %d", n)
+	}
+}
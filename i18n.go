@@ -0,0 +1,263 @@
+package debugmonitor
+
+import (
+	"html/template"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultLocale is the locale used when no locale is specified.
+const DefaultLocale = "en"
+
+// builtinCatalogs are the translations shipped with Echo Debug Monitor.
+// Messages are keyed by their English text, so the English catalog is empty
+// and untranslated messages fall back to the key itself.
+var builtinCatalogs = map[string]map[string]string{
+	"en": {},
+	"ja": {
+		"Echo Debug Monitor": "Echo デバッグモニター",
+		"Debug Monitor":      "デバッグモニター",
+		"Echo Debug Monitor is working in your application, but no monitors have been installed yet.": "Echo デバッグモニターはアプリケーションで動作していますが、モニターがまだインストールされていません。",
+		"Please read the documentation to set up monitors for your application.":                      "モニターの設定方法はドキュメントを参照してください。",
+		"HTTP":          "HTTP",
+		"Database":      "データベース",
+		"Application":   "アプリケーション",
+		"Requests":      "リクエスト",
+		"Logs":          "ログ",
+		"Errors":        "エラー",
+		"Queries":       "クエリ",
+		"Writer":        "ライター",
+		"Logger Writer": "ロガーライター",
+		// Views of the built-in monitors
+		"(truncated)":      "(切り詰め)",
+		"4xx and 5xx":      "4xx と 5xx",
+		"Accept-Language:": "Accept-Language:",
+		"Accept:":          "Accept:",
+		"All databases":    "すべてのデータベース",
+		"All operations":   "すべての操作",
+		"All origins":      "すべての発生元",
+		"All sources":      "すべてのソース",
+		"All statuses":     "すべてのステータス",
+		"Allocs":           "アロケーション",
+		"Arguments:":       "引数:",
+		"Attachments:":     "添付ファイル:",
+		"Avg":              "平均",
+		"Block":            "ブロック",
+		"Body:":            "本文:",
+		"CPU time":         "CPU 時間",
+		"Change":           "変化",
+		"Clear search":     "検索をクリア",
+		"Close":            "閉じる",
+		"Copy as cURL":     "cURL としてコピー",
+		"Count":            "件数",
+		"Critical":         "重大",
+		"Data:":            "データ:",
+		"Dismiss":          "閉じる",
+		"Download":         "ダウンロード",
+		"Downloaded profiles can be opened with \"go tool pprof\"": "ダウンロードしたプロファイルは \"go tool pprof\" で開けます",
+		"Dump Goroutines":                     "ゴルーチンをダンプ",
+		"Duplicate":                           "重複",
+		"Duration":                            "所要時間",
+		"Error":                               "エラー",
+		"Error Rate":                          "エラー率",
+		"Error rate alert:":                   "エラー率アラート:",
+		"Error:":                              "エラー:",
+		"Failed only":                         "失敗のみ",
+		"Fields:":                             "フィールド:",
+		"Filter keys":                         "キーを絞り込む",
+		"First seen":                          "初回発生",
+		"Form Values":                         "フォーム値",
+		"GC pause":                            "GC 停止時間",
+		"Goroutine":                           "ゴルーチン",
+		"Goroutine dump":                      "ゴルーチンダンプ",
+		"Goroutines":                          "ゴルーチン",
+		"Groups":                              "グループ",
+		"HANDLED":                             "処理済み",
+		"Handled":                             "処理済み",
+		"Handler:":                            "ハンドラー:",
+		"Headers:":                            "ヘッダー:",
+		"Heap":                                "ヒープ",
+		"Heap alloc":                          "ヒープ割り当て",
+		"Heap in use":                         "使用中のヒープ",
+		"Hide resolved":                       "解決済みを隠す",
+		"Info":                                "情報",
+		"Input:":                              "入力:",
+		"Internal error:":                     "内部エラー:",
+		"Last seen":                           "最終発生",
+		"Latency:":                            "レイテンシ:",
+		"Levels:":                             "レベル:",
+		"Load newer matches":                  "新しい一致を読み込む",
+		"Logger level:":                       "ロガーレベル:",
+		"Max":                                 "最大",
+		"Message:":                            "メッセージ:",
+		"Method":                              "メソッド",
+		"Method:":                             "メソッド:",
+		"Minute":                              "分",
+		"Mutex":                               "ミューテックス",
+		"Negotiation:":                        "ネゴシエーション:",
+		"Next GC":                             "次回 GC",
+		"No binding or validation errors yet": "バインドやバリデーションのエラーはまだありません",
+		"No dumps yet":                        "ダンプはまだありません",
+		"No emails sent yet":                  "送信されたメールはまだありません",
+		"No entries were recorded for this request.":   "このリクエストで記録されたエントリはありません。",
+		"No errors have been recorded yet.":            "エラーはまだ記録されていません。",
+		"No errors yet":                                "エラーはまだありません",
+		"No events yet":                                "イベントはまだありません",
+		"No file operations yet":                       "ファイル操作はまだありません",
+		"No logs have been recorded in the last hour.": "直近 1 時間に記録されたログはありません。",
+		"No logs yet":                                  "ログはまだありません",
+		"No matching results":                          "一致する結果はありません",
+		"No metrics yet":                               "メトリクスはまだありません",
+		"No outgoing requests yet":                     "外部へのリクエストはまだありません",
+		"No output yet":                                "出力はまだありません",
+		"No profiles yet":                              "プロファイルはまだありません",
+		"No queries have been recorded yet.":           "クエリはまだ記録されていません。",
+		"No queries yet":                               "クエリはまだありません",
+		"No requests have been recorded yet.":          "リクエストはまだ記録されていません。",
+		"No requests yet":                              "リクエストはまだありません",
+		"No samples yet":                               "サンプルはまだありません",
+		"No snapshots yet":                             "スナップショットはまだありません",
+		"Nothing has happened yet":                     "まだ何も起きていません",
+		"Objects":                                      "オブジェクト",
+		"Open files":                                   "開いているファイル",
+		"Path":                                         "パス",
+		"Path Parameters":                              "パスパラメーター",
+		"Plan":                                         "実行計画",
+		"Process statistics are only available on Linux": "プロセスの統計は Linux でのみ利用できます",
+		"Query Parameters":                "クエリパラメーター",
+		"Query Parameters:":               "クエリパラメーター:",
+		"Query Stats":                     "クエリ統計",
+		"REGRESSION":                      "再発",
+		"RESOLVED":                        "解決済み",
+		"Regex":                           "正規表現",
+		"Remote IP:":                      "リモート IP:",
+		"Replay":                          "再送",
+		"Request Body:":                   "リクエスト本文:",
+		"Request Bytes":                   "リクエストバイト数",
+		"Request Headers":                 "リクエストヘッダー",
+		"Request Headers:":                "リクエストヘッダー:",
+		"Request ID:":                     "リクエスト ID:",
+		"Request Size:":                   "リクエストサイズ:",
+		"Resolve":                         "解決",
+		"Response Body:":                  "レスポンス本文:",
+		"Response Bytes":                  "レスポンスバイト数",
+		"Response Content-Type:":          "レスポンスの Content-Type:",
+		"Response Size:":                  "レスポンスサイズ:",
+		"Route":                           "ルート",
+		"Route Stats":                     "ルート統計",
+		"Route:":                          "ルート:",
+		"Search URL...":                   "URL を検索...",
+		"Search caller or values...":      "呼び出し元や値を検索...",
+		"Search path...":                  "パスを検索...",
+		"Search subject or recipient...":  "件名や宛先を検索...",
+		"Search summary or request ID...": "概要やリクエスト ID を検索...",
+		"Search topic or data...":         "トピックやデータを検索...",
+		"Search type, route or error...":  "種類、ルート、エラーを検索...",
+		"Search...":                       "検索...",
+		"Search... (Enter to search all)": "検索... (Enter ですべてを検索)",
+		"Severity:":                       "重大度:",
+		"Size":                            "サイズ",
+		"Size:":                           "サイズ:",
+		"Slow":                            "低速",
+		"Slow queries only":               "低速なクエリのみ",
+		"Statement":                       "ステートメント",
+		"Status":                          "ステータス",
+		"Status:":                         "ステータス:",
+		"Table":                           "テーブル",
+		"Take Snapshot":                   "スナップショットを取得",
+		"Threads":                         "スレッド",
+		"Throughput":                      "スループット",
+		"Time":                            "時刻",
+		"Timeline":                        "タイムライン",
+		"Timestamp:":                      "タイムスタンプ:",
+		"Timings":                         "タイミング",
+		"Total":                           "合計",
+		"Type":                            "種類",
+		"URI:":                            "URI:",
+		"Unhandled":                       "未処理",
+		"Uploaded Files":                  "アップロードされたファイル",
+		"User Agent:":                     "ユーザーエージェント:",
+		"User:":                           "ユーザー:",
+		"Virtual":                         "仮想メモリ",
+		"Warning":                         "警告",
+		"WebSocket Duration:":             "WebSocket 接続時間:",
+		"WebSocket Traffic:":              "WebSocket 通信量:",
+	},
+}
+
+// TemplateFuncs are the functions available to the views of monitors. Parse the views with them
+// so that they can translate messages with the "t" function, as the views of the manager do:
+//
+//	var viewTemplate = template.Must(template.New("view").Funcs(debugmonitor.TemplateFuncs).Parse(view))
+//
+// RenderTemplate binds "t" to the locale of the manager. The functions return the message as is otherwise.
+var TemplateFuncs = template.FuncMap{
+	"t": func(key string) string { return key },
+}
+
+// managerKey is the key of the echo.Context value that carries the Manager handling the action of a monitor.
+const managerKey = "debugmonitor.manager"
+
+// translateFuncs returns TemplateFuncs bound to the locale of the manager handling the request of c,
+// or nil if the request is not handled by a manager.
+func translateFuncs(c echo.Context) template.FuncMap {
+	m, ok := c.Get(managerKey).(*Manager)
+	if !ok {
+		return nil
+	}
+	return template.FuncMap{"t": m.Translate}
+}
+
+// WithLocale sets the locale of the dashboard, such as "en" or "ja".
+func WithLocale(locale string) Option {
+	return func(m *Manager) {
+		m.locale = locale
+	}
+}
+
+// Locale returns the locale of the dashboard.
+func (m *Manager) Locale() string {
+	return m.locale
+}
+
+// RegisterCatalog registers translations for the locale.
+// Messages are keyed by their English text and are merged into the existing catalog of the locale,
+// so it can be used to add a new language or to override built-in translations.
+func (m *Manager) RegisterCatalog(locale string, messages map[string]string) {
+	m.catalogsMu.Lock()
+	defer m.catalogsMu.Unlock()
+
+	catalog, ok := m.catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		m.catalogs[locale] = catalog
+	}
+	for key, message := range messages {
+		catalog[key] = message
+	}
+}
+
+// Translate returns the message for the key in the dashboard's locale.
+// It returns the key itself if there is no translation.
+func (m *Manager) Translate(key string) string {
+	m.catalogsMu.RLock()
+	defer m.catalogsMu.RUnlock()
+
+	if message, ok := m.catalogs[m.locale][key]; ok {
+		return message
+	}
+	return key
+}
+
+// newCatalogs returns a copy of the built-in catalogs.
+func newCatalogs() map[string]map[string]string {
+	catalogs := make(map[string]map[string]string, len(builtinCatalogs))
+	for locale, messages := range builtinCatalogs {
+		catalog := make(map[string]string, len(messages))
+		for key, message := range messages {
+			catalog[key] = message
+		}
+		catalogs[locale] = catalog
+	}
+	return catalogs
+}
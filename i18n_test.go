@@ -0,0 +1,59 @@
+package debugmonitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestManager_Translate(t *testing.T) {
+	m := New()
+	if m.Locale() != DefaultLocale {
+		t.Errorf("Expected locale %s, got %s", DefaultLocale, m.Locale())
+	}
+	if got := m.Translate("Requests"); got != "Requests" {
+		t.Errorf("Expected untranslated message, got %s", got)
+	}
+
+	m = New(WithLocale("ja"))
+	if got := m.Translate("Requests"); got != "リクエスト" {
+		t.Errorf("Expected Japanese message, got %s", got)
+	}
+	if got := m.Translate("Unknown"); got != "Unknown" {
+		t.Errorf("Expected fallback to the key, got %s", got)
+	}
+
+	// Registered catalogs override built-in translations and add new ones
+	m.RegisterCatalog("ja", map[string]string{"Requests": "HTTPリクエスト", "Custom": "カスタム"})
+	if got := m.Translate("Requests"); got != "HTTPリクエスト" {
+		t.Errorf("Expected overridden message, got %s", got)
+	}
+	if got := m.Translate("Custom"); got != "カスタム" {
+		t.Errorf("Expected registered message, got %s", got)
+	}
+
+	// Catalogs are not shared between managers
+	if got := New(WithLocale("ja")).Translate("Custom"); got != "Custom" {
+		t.Errorf("Expected catalogs not to be shared, got %s", got)
+	}
+}
+
+func TestManager_TranslatedViews(t *testing.T) {
+	m := New(WithLocale("ja"))
+	m.AddMonitor(&Monitor{Name: "requests", DisplayName: "Requests"})
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests", nil))
+	body := rec.Body.String()
+	for _, s := range []string{`<html lang="ja">`, "デバッグモニター", "<title>リクエスト - Echo デバッグモニター</title>"} {
+		if !strings.Contains(body, s) {
+			t.Errorf("Expected view to contain %q", s)
+		}
+	}
+}
@@ -2,70 +2,225 @@ package debugmonitor
 
 import (
 	"bytes"
+	"context"
 	"html/template"
 	"io"
+	"io/fs"
+	"mime"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
+// ProductionSafeMaxRecords is the maximum number of records each monitor keeps
+// when the manager is in production-safe mode.
+const ProductionSafeMaxRecords = 100
+
+// ProductionSafeSampleRate is the fraction of requests the built-in monitors record
+// when the manager is in production-safe mode.
+const ProductionSafeSampleRate = 0.1
+
+// Manager is the single entry point of Echo Debug Monitor.
+// It holds the registered monitors and serves the dashboard through Handler.
 type Manager struct {
-	monitors   []*Monitor
-	monitorMap map[string]*Monitor
-	mutex      sync.RWMutex
+	monitors       []*Monitor
+	monitorMap     map[string]*Monitor
+	mutex          sync.RWMutex
+	productionSafe bool
+	startedAt      time.Time
+
+	subscriptionsMu sync.RWMutex    // protects subscriptions slice
+	subscriptions   []*Subscription // active cross-monitor subscriptions
+
+	devViewsDir  string // directory to load views from at request time in dev mode
+	devAssetsDir string // directory to load assets from at request time in dev mode
+
+	ctx    context.Context    // canceled when the manager is closed
+	cancel context.CancelFunc // cancels ctx
+
+	settingsMu sync.RWMutex               // protects settings map
+	settings   map[string]MonitorSettings // runtime settings of each monitor
+
+	stream streamConfig // configuration of the streams served by the monitors
+
+	locale     string                       // locale of the dashboard
+	catalogsMu sync.RWMutex                 // protects catalogs map
+	catalogs   map[string]map[string]string // translations keyed by locale
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithDevMode enables dev mode. In dev mode, the manager loads its own views, such as the layout
+// and the dashboard, from viewsDir and assets from assetsDir at request time instead of using
+// the embedded files, so changes to them are reflected without rebuilding. An empty directory
+// keeps the embedded files. The views of the monitors are parsed when their package is initialized,
+// so they are not reloaded and changes to them need a rebuild.
+func WithDevMode(viewsDir, assetsDir string) Option {
+	return func(m *Manager) {
+		m.devViewsDir = viewsDir
+		m.devAssetsDir = assetsDir
+	}
 }
 
 // New creates a new Echo Debug Monitor manager instance.
-func New() *Manager {
-	return &Manager{
+func New(opts ...Option) *Manager {
+	m := &Manager{
 		monitors:   []*Monitor{},
 		monitorMap: make(map[string]*Monitor),
+		startedAt:  time.Now(),
+		settings:   make(map[string]MonitorSettings),
+		locale:     DefaultLocale,
+		catalogs:   newCatalogs(),
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
+}
+
+// NewProductionSafe creates a new Echo Debug Monitor manager instance in production-safe mode.
+// In this mode, each monitor keeps at most ProductionSafeMaxRecords records, and the built-in
+// monitors sample requests, aggressively redact captured data and never capture bodies.
+func NewProductionSafe(opts ...Option) *Manager {
+	m := New(opts...)
+	m.productionSafe = true
+	return m
 }
 
+// IsProductionSafe reports whether the manager is in production-safe mode.
+func (m *Manager) IsProductionSafe() bool {
+	return m.productionSafe
+}
+
+// AddMonitor registers a monitor to the manager and initializes its data store.
 func (m *Manager) AddMonitor(monitor *Monitor) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	// Use smaller buffers in production-safe mode
+	if m.productionSafe && (monitor.MaxRecords <= 0 || monitor.MaxRecords > ProductionSafeMaxRecords) {
+		monitor.MaxRecords = ProductionSafeMaxRecords
+	}
+
 	// Initialize the store for this monitor
 	// The store will manage ID generation internally
 	monitor.store = NewStore(monitor.MaxRecords)
+	monitor.manager = m
+	if monitor.MaxRecords <= 0 {
+		// Reflect the default maximum chosen by the store
+		monitor.MaxRecords = monitor.store.MaxRecords()
+	}
+
+	m.settingsMu.Lock()
+	m.settings[monitor.Name] = defaultMonitorSettings(monitor)
+	m.settingsMu.Unlock()
 
 	m.monitorMap[monitor.Name] = monitor
 	m.monitors = append(m.monitors, monitor)
+
+	// Keep monitors sorted by Order. The stable sort preserves the registration order
+	// for monitors that have the same Order.
+	sort.SliceStable(m.monitors, func(i, j int) bool {
+		return m.monitors[i].Order < m.monitors[j].Order
+	})
 }
 
+// Monitors returns all registered monitors sorted by their Order.
 func (m *Manager) Monitors() []*Monitor {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
-	return m.monitors
+	// AddMonitor sorts the monitors in place, so the callers get a copy
+	return slices.Clone(m.monitors)
+}
+
+// MonitorGroup is a named section of monitors in the navigation.
+type MonitorGroup struct {
+	// Name is the group name. It is empty for monitors that do not belong to any group.
+	Name string
+	// Monitors are the monitors in this group sorted by their Order.
+	Monitors []*Monitor
+}
+
+// MonitorGroups returns the registered monitors organized by their Group.
+// Groups are ordered by the position of their first monitor.
+func (m *Manager) MonitorGroups() []*MonitorGroup {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	groups := []*MonitorGroup{}
+	groupMap := make(map[string]*MonitorGroup)
+	for _, monitor := range m.monitors {
+		group, ok := groupMap[monitor.Group]
+		if !ok {
+			group = &MonitorGroup{Name: monitor.Group}
+			groupMap[monitor.Group] = group
+			groups = append(groups, group)
+		}
+		group.Monitors = append(group.Monitors, monitor)
+	}
+	return groups
 }
 
+// Handler returns an echo.HandlerFunc that serves the dashboard and dispatches actions to monitors.
 func (m *Manager) Handler() echo.HandlerFunc {
-	t := template.Must(template.New("T").ParseFS(viewsFS, "*.html"))
+	var views *template.Template
+	if m.devViewsDir == "" {
+		views = template.Must(m.parseViews(viewsFS))
+	}
 
 	return func(c echo.Context) error {
 		if c.Request().Method == http.MethodGet {
 			// Check if a file query parameter is present
 			file := c.QueryParam("file")
 			if file != "" {
-				// Serve the requested file from assetsFS
-				return serveStaticFile(c, file)
+				if monitorName := c.QueryParam("monitor"); monitorName != "" {
+					// Serve the requested file from the monitor's own assets
+					return m.serveMonitorAsset(c, monitorName, file)
+				}
+				// Serve the requested file from the assets
+				return serveStaticFile(c, m.assetsFS(), file)
+			}
+
+			t := views
+			if t == nil {
+				// Reload views from disk on every request in dev mode
+				var err error
+				if t, err = m.parseViews(os.DirFS(m.devViewsDir)); err != nil {
+					return err
+				}
 			}
 
 			monitorName := c.QueryParam("monitor")
 			if monitorName == "" {
-				if len(m.monitors) > 0 {
-					monitor := m.monitors[0]
+				if action := c.QueryParam("action"); action != "" {
+					return m.handleManagerAction(c, action)
+				}
+				if monitors := m.Monitors(); len(monitors) > 0 {
+					monitor := monitors[0]
 					return c.Redirect(http.StatusFound, c.Path()+"?monitor="+url.QueryEscape(monitor.Name))
 				} else {
-					return renderView(t, c, http.StatusOK, "no_monitors.html", nil)
+					token, err := csrfToken(c)
+					if err != nil {
+						return err
+					}
+					return renderView(t, c, http.StatusOK, "no_monitors.html", map[string]any{
+						"Manager":     m,
+						"CSRFToken":   token,
+						"Preferences": loadPreferences(c),
+					})
 				}
 			}
 
-			monitor, ok := m.monitorMap[monitorName]
+			monitor, ok := m.monitorByName(monitorName)
 			if !ok {
 				// monitor not found. Redirect to the Echo Debug monitor top page.
 				return c.Redirect(http.StatusFound, c.Path())
@@ -73,42 +228,144 @@ func (m *Manager) Handler() echo.HandlerFunc {
 
 			action := c.QueryParam("action")
 			if action != "" {
-				if monitor.ActionHandler == nil {
-					return c.JSON(http.StatusInternalServerError, map[string]any{
-						"error": "Monitor " + monitor.Name + " does not have a ActionHandler implementation.",
-					})
-				}
-				// handle monitor action
-				return monitor.ActionHandler(c, monitor.store, action)
+				return m.handleAction(c, monitor, action)
+			}
+
+			token, err := csrfToken(c)
+			if err != nil {
+				return err
 			}
 
 			return renderView(t, c, http.StatusOK, "monitor.html", map[string]any{
-				"Manager": m,
-				"Monitor": monitor,
-				"Title":   monitor.DisplayName + " - Echo Debug Monitor",
+				"Manager":     m,
+				"Monitor":     monitor,
+				"Title":       m.Translate(monitor.DisplayName) + " - " + m.Translate("Echo Debug Monitor"),
+				"CSRFToken":   token,
+				"Preferences": loadPreferences(c),
 			})
 		}
 
+		if c.Request().Method == http.MethodPost {
+			// POST is only used for actions, which must carry a valid CSRF token
+			if err := validateCSRFToken(c); err != nil {
+				return err
+			}
+
+			action := c.QueryParam("action")
+			if action == "" {
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+			monitorName := c.QueryParam("monitor")
+			if monitorName == "" {
+				return m.handleManagerAction(c, action)
+			}
+			monitor, ok := m.monitorByName(monitorName)
+			if !ok {
+				return echo.NewHTTPError(http.StatusNotFound)
+			}
+			return m.handleAction(c, monitor, action)
+		}
+
 		return echo.NewHTTPError(http.StatusMethodNotAllowed)
 	}
 }
 
-// serveStaticFile serves static files (app.js or app.css) from assetsFS
-func serveStaticFile(c echo.Context, filename string) error {
+// handleManagerAction handles an action that is not bound to a specific monitor.
+func (m *Manager) handleManagerAction(c echo.Context, action string) error {
+	switch action {
+	case "status":
+		return c.JSON(http.StatusOK, m.Status())
+	case "subscribers":
+		return c.JSON(http.StatusOK, m.Subscribers())
+	case "preferences":
+		return handlePreferences(c)
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+}
+
+// handleAction dispatches an action to the manager's built-in action handlers or to the monitor's ActionHandler.
+// Monitor ActionHandlers that mutate state should check that the request method is POST.
+func (m *Manager) handleAction(c echo.Context, monitor *Monitor, action string) error {
+	// Pass the stream configuration to HandleSSEStream, and the manager to RenderTemplate, through the context
+	c.Set(streamConfigKey, &m.stream)
+	c.Set(managerKey, m)
+
+	switch action {
+	case "settings":
+		return m.handleSettings(c, monitor)
+	case "clear":
+		if c.Request().Method != http.MethodPost {
+			return echo.NewHTTPError(http.StatusMethodNotAllowed)
+		}
+		monitor.store.Clear()
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	if monitor.ActionHandler == nil {
+		return c.JSON(http.StatusInternalServerError, map[string]any{
+			"error": "Monitor " + monitor.Name + " does not have a ActionHandler implementation.",
+		})
+	}
+	// handle monitor action
+	return monitor.ActionHandler(c, monitor.store, action)
+}
+
+// monitorByName returns the registered monitor with the given name.
+func (m *Manager) monitorByName(name string) (*Monitor, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	monitor, ok := m.monitorMap[name]
+	return monitor, ok
+}
+
+// assetsFS returns the file system to serve assets from.
+// In dev mode, assets are read from disk at request time.
+func (m *Manager) assetsFS() fs.FS {
+	if m.devAssetsDir != "" {
+		return os.DirFS(m.devAssetsDir)
+	}
+	return assetsFS
+}
+
+// serveMonitorAsset serves a file from the assets of the monitor provided by a plugin.
+func (m *Manager) serveMonitorAsset(c echo.Context, monitorName string, filename string) error {
+	monitor, ok := m.monitorByName(monitorName)
+	if !ok || monitor.assets == nil || !fs.ValidPath(filename) {
+		return echo.NewHTTPError(http.StatusNotFound)
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return serveAsset(c, monitor.assets, filename, contentType)
+}
+
+// parseViews parses all views in the given file system.
+// Views can translate messages with the "t" function.
+func (m *Manager) parseViews(fsys fs.FS) (*template.Template, error) {
+	return template.New("T").Funcs(template.FuncMap{
+		"t": m.Translate,
+	}).ParseFS(fsys, "*.html")
+}
+
+// serveStaticFile serves static files (app.js or app.css) from fsys
+func serveStaticFile(c echo.Context, fsys fs.FS, filename string) error {
 	switch filename {
 	case "app.js":
-		return serveAsset(c, "app.js", "application/javascript")
+		return serveAsset(c, fsys, "app.js", "application/javascript")
 	case "tailwindcss.js":
-		return serveAsset(c, "tailwindcss.js", "application/javascript")
+		return serveAsset(c, fsys, "tailwindcss.js", "application/javascript")
 	default:
 		return echo.NewHTTPError(http.StatusNotFound)
 	}
 }
 
 // serveAsset is a helper function that serves a file with the specified content type
-func serveAsset(c echo.Context, filename string, contentType string) error {
-	// Open the file from assetsFS
-	f, err := assetsFS.Open(filename)
+func serveAsset(c echo.Context, fsys fs.FS, filename string, contentType string) error {
+	// Open the file from fsys
+	f, err := fsys.Open(filename)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound)
 	}
@@ -0,0 +1,371 @@
+package debugmonitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestManager_MonitorsOrder(t *testing.T) {
+	m := New()
+	m.AddMonitor(&Monitor{Name: "a", Order: 2})
+	m.AddMonitor(&Monitor{Name: "b", Order: 1})
+	m.AddMonitor(&Monitor{Name: "c", Order: 2})
+	m.AddMonitor(&Monitor{Name: "d"})
+
+	// Monitors should be sorted by Order, keeping registration order for ties
+	expected := []string{"d", "b", "a", "c"}
+	monitors := m.Monitors()
+	if len(monitors) != len(expected) {
+		t.Fatalf("Expected %d monitors, got %d", len(expected), len(monitors))
+	}
+	for i, monitor := range monitors {
+		if monitor.Name != expected[i] {
+			t.Errorf("Expected monitor %s at position %d, got %s", expected[i], i, monitor.Name)
+		}
+	}
+}
+
+func TestManager_MonitorsCopy(t *testing.T) {
+	m := New()
+	m.AddMonitor(&Monitor{Name: "a", Order: 2})
+	m.AddMonitor(&Monitor{Name: "b", Order: 2})
+	m.AddMonitor(&Monitor{Name: "c", Order: 2})
+	monitors := m.Monitors()
+
+	// Adding a monitor that sorts first does not reorder the slice returned before
+	m.AddMonitor(&Monitor{Name: "d", Order: 1})
+	for i, expected := range []string{"a", "b", "c"} {
+		if monitors[i].Name != expected {
+			t.Errorf("Expected monitor %s at position %d, got %s", expected, i, monitors[i].Name)
+		}
+	}
+}
+
+func TestManager_MonitorGroups(t *testing.T) {
+	m := New()
+	m.AddMonitor(&Monitor{Name: "requests", Group: "HTTP"})
+	m.AddMonitor(&Monitor{Name: "queries", Group: "Database"})
+	m.AddMonitor(&Monitor{Name: "logs", Group: "Application"})
+	m.AddMonitor(&Monitor{Name: "errors", Group: "Application"})
+	m.AddMonitor(&Monitor{Name: "custom"})
+
+	groups := m.MonitorGroups()
+	expected := []struct {
+		name     string
+		monitors []string
+	}{
+		{"HTTP", []string{"requests"}},
+		{"Database", []string{"queries"}},
+		{"Application", []string{"logs", "errors"}},
+		{"", []string{"custom"}},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %d", len(expected), len(groups))
+	}
+	for i, group := range groups {
+		if group.Name != expected[i].name {
+			t.Errorf("Expected group %q at position %d, got %q", expected[i].name, i, group.Name)
+		}
+		if len(group.Monitors) != len(expected[i].monitors) {
+			t.Errorf("Expected %d monitors in group %q, got %d", len(expected[i].monitors), group.Name, len(group.Monitors))
+			continue
+		}
+		for j, monitor := range group.Monitors {
+			if monitor.Name != expected[i].monitors[j] {
+				t.Errorf("Expected monitor %s in group %q at position %d, got %s", expected[i].monitors[j], group.Name, j, monitor.Name)
+			}
+		}
+	}
+}
+
+func TestManager_ProductionSafe(t *testing.T) {
+	m := NewProductionSafe()
+	if !m.IsProductionSafe() {
+		t.Errorf("Expected manager to be in production-safe mode")
+	}
+
+	large := &Monitor{Name: "large", MaxRecords: 1000}
+	small := &Monitor{Name: "small", MaxRecords: 10}
+	unset := &Monitor{Name: "unset"}
+	m.AddMonitor(large)
+	m.AddMonitor(small)
+	m.AddMonitor(unset)
+
+	if large.MaxRecords != ProductionSafeMaxRecords {
+		t.Errorf("Expected MaxRecords %d, got %d", ProductionSafeMaxRecords, large.MaxRecords)
+	}
+	if small.MaxRecords != 10 {
+		t.Errorf("Expected MaxRecords 10, got %d", small.MaxRecords)
+	}
+	if unset.MaxRecords != ProductionSafeMaxRecords {
+		t.Errorf("Expected MaxRecords %d, got %d", ProductionSafeMaxRecords, unset.MaxRecords)
+	}
+	if !large.IsProductionSafe() {
+		t.Errorf("Expected monitor to be in production-safe mode")
+	}
+
+	// Monitors that are not connected to a production-safe manager
+	if (&Monitor{}).IsProductionSafe() {
+		t.Errorf("Expected unconnected monitor not to be in production-safe mode")
+	}
+	normal := &Monitor{Name: "normal", MaxRecords: 1000}
+	New().AddMonitor(normal)
+	if normal.IsProductionSafe() || normal.MaxRecords != 1000 {
+		t.Errorf("Expected monitor not to be in production-safe mode with MaxRecords 1000, got %d", normal.MaxRecords)
+	}
+}
+
+func TestManager_Status(t *testing.T) {
+	m := New()
+	monitor := &Monitor{Name: "test", MaxRecords: 10}
+	m.AddMonitor(monitor)
+	monitor.Add("a")
+	monitor.Add("b")
+
+	event := monitor.store.NewAddEvent()
+	defer event.Close()
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+
+	req := httptest.NewRequest(http.MethodGet, "/monitor?action=status", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var status Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if status.NumMonitors != 1 {
+		t.Errorf("Expected 1 monitor, got %d", status.NumMonitors)
+	}
+	if status.TotalEntries != 2 {
+		t.Errorf("Expected 2 entries, got %d", status.TotalEntries)
+	}
+	if status.Subscribers != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", status.Subscribers)
+	}
+	if status.GoVersion == "" {
+		t.Errorf("Expected Go version to be set")
+	}
+	if len(status.Monitors) != 1 || status.Monitors[0].Name != "test" || status.Monitors[0].MaxRecords != 10 {
+		t.Errorf("Unexpected monitor status: %+v", status.Monitors)
+	}
+}
+
+func TestManager_StatusLiveStream(t *testing.T) {
+	m := New()
+	monitor := &Monitor{
+		Name: "test",
+		ActionHandler: func(c echo.Context, store *Store, action string) error {
+			return HandleSSEStream(c, store)
+		},
+	}
+	m.AddMonitor(monitor)
+	monitor.Add("a")
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/monitor?monitor=test&action=stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	// Wait for the initial entry of the stream
+	if _, err := bufio.NewReader(res.Body).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stream subscribes to both add and clear events, but it is a single subscriber
+	status := m.Status()
+	if status.Subscribers != 1 || status.Monitors[0].Subscribers != 1 {
+		t.Errorf("Expected 1 subscriber, got %d (monitor %d)", status.Subscribers, status.Monitors[0].Subscribers)
+	}
+}
+
+func TestManager_Subscribers(t *testing.T) {
+	m := New()
+	monitor := &Monitor{
+		Name: "test",
+		ActionHandler: func(c echo.Context, store *Store, action string) error {
+			if action == "stream" {
+				return HandleSSEStream(c, store)
+			}
+			return HandleDataJSON(c, store)
+		},
+	}
+	m.AddMonitor(monitor)
+	entry := monitor.store.AddEntry("a")
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/monitor?monitor=test&action=stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	// Wait for the initial entry of the stream
+	if _, err := bufio.NewReader(res.Body).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	res, err = http.Get(server.URL + "/monitor?monitor=test&action=data&since=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	res, err = http.Get(server.URL + "/monitor?action=subscribers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var subscribers []*MonitorSubscribers
+	if err := json.NewDecoder(res.Body).Decode(&subscribers); err != nil {
+		t.Fatal(err)
+	}
+	if len(subscribers) != 1 || subscribers[0].SSE != 1 || subscribers[0].Poll != 1 {
+		t.Fatalf("Expected 1 stream and 1 polling client, got %+v", subscribers)
+	}
+	for _, sub := range subscribers[0].Subscribers {
+		if sub.Since != entry.Id {
+			t.Errorf("Expected the %s client to be at the last entry, got %d", sub.Transport, sub.Since)
+		}
+	}
+}
+
+func TestManager_PollingSubscribers(t *testing.T) {
+	m := New()
+	monitor := &Monitor{
+		Name: "test",
+		ActionHandler: func(c echo.Context, store *Store, action string) error {
+			return HandleDataJSON(c, store)
+		},
+	}
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	poll := func(query string) {
+		// All the requests come from the same address, as the tabs of a browser or the clients behind a NAT do
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/monitor?monitor=test&action=data&since=0"+query, nil))
+	}
+	poll("&client=tab1")
+	poll("&client=tab2")
+	poll("&client=tab1")
+	poll("")
+	poll("")
+
+	subscribers := m.Subscribers()[0].Subscribers
+	if len(subscribers) != 3 {
+		t.Fatalf("Expected 2 tabs and 1 client without an ID, got %d", len(subscribers))
+	}
+	for i, expected := range []string{"tab1", "tab2", ""} {
+		if subscribers[i].ClientID != expected || subscribers[i].Transport != TransportPoll {
+			t.Errorf("Expected the polling client %q at position %d, got %+v", expected, i, subscribers[i])
+		}
+	}
+}
+
+func TestManager_Subscribe(t *testing.T) {
+	m := New()
+	requests := &Monitor{Name: "requests"}
+	logs := &Monitor{Name: "logs"}
+	m.AddMonitor(requests)
+	m.AddMonitor(logs)
+
+	sub := m.Subscribe()
+
+	requests.Add("request")
+	logs.Add("log")
+
+	expected := []struct {
+		monitorName string
+		payload     string
+	}{
+		{"requests", "request"},
+		{"logs", "log"},
+	}
+	for _, exp := range expected {
+		select {
+		case event := <-sub.C:
+			if event.MonitorName != exp.monitorName {
+				t.Errorf("Expected monitor %s, got %s", exp.monitorName, event.MonitorName)
+			}
+			if event.Entry.Payload != exp.payload {
+				t.Errorf("Expected payload %s, got %v", exp.payload, event.Entry.Payload)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for event")
+		}
+	}
+
+	sub.Close()
+	requests.Add("after close")
+
+	select {
+	case _, ok := <-sub.C:
+		if ok {
+			t.Error("Expected channel to be closed")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("Expected channel to be closed immediately")
+	}
+
+	// Calling Close again should be safe
+	sub.Close()
+}
+
+func TestManager_DevMode(t *testing.T) {
+	assetsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(assetsDir, "app.js"), []byte("console.log('dev');"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	viewsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(viewsDir, "no_monitors.html"), []byte("dev view"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(WithDevMode(viewsDir, assetsDir))
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?file=app.js", nil))
+	if rec.Body.String() != "console.log('dev');" {
+		t.Errorf("Expected asset from disk, got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor", nil))
+	if rec.Body.String() != "dev view" {
+		t.Errorf("Expected view from disk, got %q", rec.Body.String())
+	}
+
+	// Changes are reflected without recreating the handler
+	if err := os.WriteFile(filepath.Join(viewsDir, "no_monitors.html"), []byte("updated view"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor", nil))
+	if rec.Body.String() != "updated view" {
+		t.Errorf("Expected updated view from disk, got %q", rec.Body.String())
+	}
+}
@@ -2,6 +2,7 @@ package debugmonitor
 
 import (
 	"html/template"
+	"io/fs"
 
 	"github.com/labstack/echo/v4"
 )
@@ -12,10 +13,15 @@ const (
 	IconGlobeAlt          template.HTML = `<svg style="width: 16px; height: 16px;" xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" stroke-width="1.5" stroke="currentColor" class="size-6"><path stroke-linecap="round" stroke-linejoin="round" d="M12 21a9.004 9.004 0 0 0 8.716-6.747M12 21a9.004 9.004 0 0 1-8.716-6.747M12 21c2.485 0 4.5-4.03 4.5-9S14.485 3 12 3m0 18c-2.485 0-4.5-4.03-4.5-9S9.515 3 12 3m0 0a8.997 8.997 0 0 1 7.843 4.582M12 3a8.997 8.997 0 0 0-7.843 4.582m15.686 0A11.953 11.953 0 0 1 12 10.5c-2.998 0-5.74-1.1-7.843-2.918m15.686 0A8.959 8.959 0 0 1 21 12c0 .778-.099 1.533-.284 2.253m0 0A17.919 17.919 0 0 1 12 16.5c-3.162 0-6.133-.815-8.716-2.247m0 0A9.015 9.015 0 0 1 3 12c0-1.605.42-3.113 1.157-4.418" /></svg>`
 	IconPencilSquare      template.HTML = `<svg style="width: 16px; height: 16px;" xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" stroke-width="1.5" stroke="currentColor" class="size-6"><path stroke-linecap="round" stroke-linejoin="round" d="m16.862 4.487 1.687-1.688a1.875 1.875 0 1 1 2.652 2.652L10.582 16.07a4.5 4.5 0 0 1-1.897 1.13L6 18l.8-2.685a4.5 4.5 0 0 1 1.13-1.897l8.932-8.931Zm0 0L19.5 7.125M18 14v4.75A2.25 2.25 0 0 1 15.75 21H5.25A2.25 2.25 0 0 1 3 18.75V8.25A2.25 2.25 0 0 1 5.25 6H10" /></svg>`
 	IconDocumentText      template.HTML = `<svg style="width: 16px; height: 16px;" xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" stroke-width="1.5" stroke="currentColor" class="size-6"><path stroke-linecap="round" stroke-linejoin="round" d="M19.5 14.25v-2.625a3.375 3.375 0 0 0-3.375-3.375h-1.5A1.125 1.125 0 0 1 13.5 7.125v-1.5a3.375 3.375 0 0 0-3.375-3.375H8.25m0 12.75h7.5m-7.5 3H12M10.5 2.25H5.625c-.621 0-1.125.504-1.125 1.125v17.25c0 .621.504 1.125 1.125 1.125h12.75c.621 0 1.125-.504 1.125-1.125V11.25a9 9 0 0 0-9-9Z" /></svg>`
+	IconArrowsRightLeft   template.HTML = `<svg style="width: 16px; height: 16px;" xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" stroke-width="1.5" stroke="currentColor" class="size-6"><path stroke-linecap="round" stroke-linejoin="round" d="M7.5 21 3 16.5m0 0L7.5 12M3 16.5h13.5m0-13.5L21 7.5m0 0L16.5 12M21 7.5H7.5" /></svg>`
+	IconCpuChip           template.HTML = `<svg style="width: 16px; height: 16px;" xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" stroke-width="1.5" stroke="currentColor" class="size-6"><path stroke-linecap="round" stroke-linejoin="round" d="M8.25 3v1.5M4.5 8.25H3m18 0h-1.5M4.5 12H3m18 0h-1.5m-15 3.75H3m18 0h-1.5M8.25 19.5V21M12 3v1.5m0 15V21m3.75-18v1.5m0 15V21m-9-1.5h10.5a2.25 2.25 0 0 0 2.25-2.25V6.75a2.25 2.25 0 0 0-2.25-2.25H6.75A2.25 2.25 0 0 0 4.5 6.75v10.5a2.25 2.25 0 0 0 2.25 2.25Zm.75-12h9v9h-9v-9Z" /></svg>`
 )
 
+// MonitorActionHandler handles an action requested to a monitor.
+// It is the only way a monitor serves its views and data.
 type MonitorActionHandler func(c echo.Context, store *Store, action string) error
 
+// Monitor is a unit that captures a specific kind of data and displays it in the dashboard.
 type Monitor struct {
 	// Name is the name of this monitor.
 	// It must be unique among all monitors.
@@ -27,19 +33,65 @@ type Monitor struct {
 	// Icon is an HTML element string representing the icon for this monitor.
 	// Typically, it is an SVG string.
 	Icon template.HTML
-	//
+	// Group is the name of the section this monitor is listed under in the navigation.
+	// Monitors with an empty Group are listed without a section heading.
+	Group string
+	// Order controls the position of this monitor in the navigation.
+	// Monitors with a lower Order come first. Monitors with the same Order keep their registration order.
+	Order int
+	// ActionHandler handles the actions requested to this monitor, such as rendering its view
+	// and serving its data.
 	ActionHandler MonitorActionHandler
 
 	// store is the in-memory data store for records.
 	store *Store
+	// manager is the Manager this monitor is connected to.
+	manager *Manager
+	// assets is the file system of static assets provided by a MonitorPlugin.
+	assets fs.FS
 }
 
+// IsProductionSafe reports whether this monitor is connected to a Manager in production-safe mode.
+// Monitors should use it to decide whether to sample, redact or skip sensitive data.
+func (m *Monitor) IsProductionSafe() bool {
+	return m.manager != nil && m.manager.IsProductionSafe()
+}
+
+// Manager returns the Manager this monitor is connected to, or nil if it is not connected yet.
+func (m *Monitor) Manager() *Manager {
+	return m.manager
+}
+
+// Add adds a payload to the monitor's data store.
+// The payload is dropped if the monitor is disabled or not sampled at the sample rate of its settings.
 func (m *Monitor) Add(payload any) {
+	m.add(payload, false)
+}
+
+// TryAdd is like Add but reports whether the payload was stored.
+func (m *Monitor) TryAdd(payload any) bool {
+	return m.add(payload, false)
+}
+
+// AddUnsampled is like Add but the payload bypasses the sample rate of the monitor's settings,
+// so that payloads that must not be missed, such as errors, are recorded while the monitor is enabled.
+func (m *Monitor) AddUnsampled(payload any) {
+	m.add(payload, true)
+}
+
+func (m *Monitor) add(payload any, unsampled bool) bool {
 	if m.store == nil {
 		// noop if the store is not initialized
 		// It means the monitor is not connected to a Manager
-		return
+		return false
+	}
+
+	if !m.shouldCapture(unsampled) {
+		// The monitor is disabled or the payload is not sampled
+		return false
 	}
 
-	m.store.Add(payload)
+	entry := m.store.AddEntry(payload)
+	m.manager.publish(m.Name, entry)
+	return true
 }
@@ -0,0 +1,142 @@
+// Package aferomonitor records the operations on an afero file system in the files monitor of echo-debugmonitor.
+// It is a separate module so that the monitors package does not depend on afero.
+package aferomonitor
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+	"github.com/spf13/afero"
+)
+
+// Fs is an afero.Fs that records the opening, reading, writing and stat of files on the wrapped file system
+// with the FileRecorder returned by monitors.NewFilesMonitor. The other operations are passed through.
+//
+//	filesMonitor, recorder := monitors.NewFilesMonitor(monitors.FilesMonitorConfig{})
+//	appFs := aferomonitor.NewFs(afero.NewOsFs(), recorder)
+type Fs struct {
+	afero.Fs
+	recorder monitors.FileRecorder
+}
+
+// NewFs creates an Fs that wraps fs and records its operations with recorder.
+func NewFs(fs afero.Fs, recorder monitors.FileRecorder) *Fs {
+	return &Fs{Fs: fs, recorder: recorder}
+}
+
+// Name implements afero.Fs.
+func (m *Fs) Name() string {
+	return "aferomonitor(" + m.Fs.Name() + ")"
+}
+
+// Open implements afero.Fs.
+func (m *Fs) Open(name string) (afero.File, error) {
+	return m.open(name, false, func() (afero.File, error) { return m.Fs.Open(name) })
+}
+
+// Create implements afero.Fs.
+func (m *Fs) Create(name string) (afero.File, error) {
+	return m.open(name, true, func() (afero.File, error) { return m.Fs.Create(name) })
+}
+
+// OpenFile implements afero.Fs.
+func (m *Fs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	return m.open(name, writable, func() (afero.File, error) { return m.Fs.OpenFile(name, flag, perm) })
+}
+
+// Stat implements afero.Fs.
+func (m *Fs) Stat(name string) (os.FileInfo, error) {
+	start := time.Now()
+	info, err := m.Fs.Stat(name)
+	m.recorder(monitors.FileOpStat, name, 0, time.Since(start), err)
+	return info, err
+}
+
+// open records the opening of a file and wraps it to record its reads and writes when it is closed.
+func (m *Fs) open(name string, writable bool, open func() (afero.File, error)) (afero.File, error) {
+	start := time.Now()
+	f, err := open()
+	m.recorder(monitors.FileOpOpen, name, 0, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	return &file{File: f, path: name, recorder: m.recorder, opened: start, writable: writable}, nil
+}
+
+// file counts the bytes read from and written to a file opened through Fs and records them when it is closed.
+// A file opened for reading is recorded as a read, a file opened for writing as a write, and a file opened
+// for both as a read if it was read from and a write if it was written to.
+type file struct {
+	afero.File
+	path     string
+	recorder monitors.FileRecorder
+	opened   time.Time
+	writable bool
+	read     int64
+	written  int64
+	readErr  error
+	writeErr error
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.countRead(n, err)
+	return n, err
+}
+
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+	f.countRead(n, err)
+	return n, err
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	f.countWrite(n, err)
+	return n, err
+}
+
+func (f *file) WriteAt(p []byte, off int64) (int, error) {
+	n, err := f.File.WriteAt(p, off)
+	f.countWrite(n, err)
+	return n, err
+}
+
+func (f *file) WriteString(s string) (int, error) {
+	n, err := f.File.WriteString(s)
+	f.countWrite(n, err)
+	return n, err
+}
+
+func (f *file) countRead(n int, err error) {
+	f.read += int64(n)
+	if err != nil && err != io.EOF && f.readErr == nil {
+		f.readErr = err
+	}
+}
+
+func (f *file) countWrite(n int, err error) {
+	f.written += int64(n)
+	if err != nil && f.writeErr == nil {
+		f.writeErr = err
+	}
+}
+
+func (f *file) Close() error {
+	err := f.File.Close()
+	duration := time.Since(f.opened)
+	if !f.writable || f.read > 0 {
+		f.recorder(monitors.FileOpRead, f.path, f.read, duration, f.readErr)
+	}
+	if f.writable && (f.written > 0 || f.read == 0) {
+		recorded := f.writeErr
+		if recorded == nil {
+			recorded = err
+		}
+		f.recorder(monitors.FileOpWrite, f.path, f.written, duration, recorded)
+	}
+	return err
+}
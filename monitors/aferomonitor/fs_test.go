@@ -0,0 +1,64 @@
+package aferomonitor
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+	"github.com/spf13/afero"
+)
+
+func TestFs(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, recorder := monitors.NewFilesMonitor(monitors.FilesMonitorConfig{SkipOpen: true})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	next := func() *monitors.FilePayload {
+		select {
+		case ev := <-sub.C:
+			return ev.Entry.Payload.(*monitors.FilePayload)
+		case <-time.After(time.Second):
+			t.Fatal("Expected a file operation to be recorded")
+			return nil
+		}
+	}
+
+	appFs := NewFs(afero.NewMemMapFs(), recorder)
+
+	if err := afero.WriteFile(appFs, "/data/out.txt", []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if p := next(); p.Op != monitors.FileOpWrite || p.Path != "/data/out.txt" || p.Size != 11 {
+		t.Errorf("Unexpected payload: %+v", p)
+	}
+
+	f, err := appFs.Open("/data/out.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(f); err != nil || string(data) != "hello world" {
+		t.Fatalf("Unexpected read: %q, %v", data, err)
+	}
+	_ = f.Close()
+	if p := next(); p.Op != monitors.FileOpRead || p.Size != 11 || p.Error != "" {
+		t.Errorf("Unexpected payload: %+v", p)
+	}
+
+	if _, err := appFs.Stat("/data/out.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if p := next(); p.Op != monitors.FileOpStat {
+		t.Errorf("Unexpected payload: %+v", p)
+	}
+
+	if _, err := appFs.Open("/missing.txt"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if p := next(); p.Op != monitors.FileOpOpen || p.Error == "" {
+		t.Errorf("Expected the failed open, got %+v", p)
+	}
+}
@@ -0,0 +1,24 @@
+module github.com/kohkimakimoto/echo-debugmonitor/monitors/aferomonitor
+
+go 1.24.0
+
+replace github.com/kohkimakimoto/echo-debugmonitor => ../..
+
+require (
+	github.com/kohkimakimoto/echo-debugmonitor v0.0.0-00010101000000-000000000000
+	github.com/spf13/afero v1.15.0
+)
+
+require (
+	github.com/labstack/echo/v4 v4.13.4 // indirect
+	github.com/labstack/gommon v0.4.2 // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/net v0.43.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
+	golang.org/x/time v0.11.0 // indirect
+)
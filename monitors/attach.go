@@ -0,0 +1,70 @@
+package monitors
+
+import (
+	"net/http"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// AttachConfig defines the config for Attach.
+type AttachConfig struct {
+	// Path is the path the monitor dashboard is mounted on.
+	// Optional. Default: "/monitor"
+	Path string
+	// ProductionSafe creates the manager in production-safe mode.
+	ProductionSafe bool
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+}
+
+// Attach creates a new manager with the default requests, logs and errors monitors and wires them into e.
+// It applies the requests middleware (skipping the dashboard itself), replaces e.Logger with the wrapped logger,
+// binds c.Logger() to each request, wraps e.HTTPErrorHandler to record errors, recovers from panics in the handlers
+// and mounts the dashboard handler on config.Path.
+// The returned manager can be used to add more monitors.
+func Attach(e *echo.Echo, config AttachConfig) *debugmonitor.Manager {
+	// Defaults
+	if config.Path == "" {
+		config.Path = "/monitor"
+	}
+
+	var m *debugmonitor.Manager
+	if config.ProductionSafe {
+		m = debugmonitor.NewProductionSafe()
+	} else {
+		m = debugmonitor.New()
+	}
+
+	// requests monitor
+	requestsMonitor, requestsMonitorMiddleware := NewRequestsMonitor(&RequestsMonitorConfig{
+		Skipper: func(c echo.Context) bool {
+			// Skip monitoring requests to the dashboard itself
+			return c.Path() == config.Path
+		},
+		UsePolling: config.UsePolling,
+	})
+	e.Use(requestsMonitorMiddleware)
+	m.AddMonitor(requestsMonitor)
+
+	// logs monitor
+	logsMonitor, wrappedLogger := NewLogsMonitor(LogsMonitorConfig{
+		Logger:     e.Logger,
+		UsePolling: config.UsePolling,
+	})
+	e.Logger = wrappedLogger
+	e.Use(ContextLogger())
+	m.AddMonitor(logsMonitor)
+
+	// errors monitor
+	errorsMonitor, errorRecorder := NewErrorsMonitor(ErrorsMonitorConfig{
+		UsePolling: config.UsePolling,
+	})
+	e.HTTPErrorHandler = HTTPErrorHandlerWrapper(errorRecorder, e.HTTPErrorHandler)
+	e.Use(PanicRecoveryMiddleware(errorRecorder))
+	m.AddMonitor(errorsMonitor)
+
+	e.Match([]string{http.MethodGet, http.MethodPost}, config.Path, m.Handler())
+
+	return m
+}
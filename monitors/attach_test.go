@@ -0,0 +1,42 @@
+package monitors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAttach(t *testing.T) {
+	e := echo.New()
+	m := Attach(e, AttachConfig{})
+
+	monitors := m.Monitors()
+	if len(monitors) != 3 {
+		t.Fatalf("Expected 3 monitors, got %d", len(monitors))
+	}
+
+	e.GET("/fail", func(c echo.Context) error {
+		return errors.New("failure")
+	})
+
+	for _, path := range []string{"/fail", "/monitor?action=status"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	status := m.Status()
+	entries := map[string]int{}
+	for _, ms := range status.Monitors {
+		entries[ms.Name] = ms.Entries
+	}
+	// Only the /fail request is recorded, the dashboard itself is skipped
+	if entries["requests"] != 1 {
+		t.Errorf("Expected 1 request entry, got %d", entries["requests"])
+	}
+	if entries["errors"] != 1 {
+		t.Errorf("Expected 1 error entry, got %d", entries["errors"])
+	}
+}
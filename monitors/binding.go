@@ -0,0 +1,267 @@
+package monitors
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// BindingErrorPayload represents the data structure for binding and validation error monitoring
+type BindingErrorPayload struct {
+	RequestID string          `json:"requestId,omitempty"` // empty for validation errors, which have no request
+	Method    string          `json:"method,omitempty"`
+	URI       string          `json:"uri,omitempty"`
+	Route     string          `json:"route,omitempty"`
+	Kind      string          `json:"kind"`   // BindingErrorKindBind or BindingErrorKindValidate
+	Target    string          `json:"target"` // Go type of the value bound or validated
+	Error     string          `json:"error"`
+	Fields    []*FieldFailure `json:"fields,omitempty"`
+	// Input is the request body that failed to bind, or the JSON encoding of the value that failed validation,
+	// with the values of RedactBodyFields redacted. It is never captured in production-safe mode.
+	Input          string              `json:"input,omitempty"`
+	InputTruncated bool                `json:"inputTruncated,omitempty"`
+	QueryParams    map[string][]string `json:"queryParams,omitempty"`
+	Timestamp      time.Time           `json:"timestamp"`
+}
+
+// FieldFailure is the failure of a field of a binding or validation error.
+type FieldFailure struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`          // such as the validation tag "required" or "expected=int"
+	Param  string `json:"param,omitempty"` // parameter of the validation tag, such as "3" of "min=3"
+}
+
+// Kinds of binding errors.
+const (
+	BindingErrorKindBind     = "bind"
+	BindingErrorKindValidate = "validate"
+)
+
+// TimelineRequestID implements debugmonitor.TimelinePayload.
+func (p *BindingErrorPayload) TimelineRequestID() string { return p.RequestID }
+
+// TimelineTime implements debugmonitor.TimelinePayload.
+func (p *BindingErrorPayload) TimelineTime() time.Time { return p.Timestamp }
+
+// TimelineSummary implements debugmonitor.TimelinePayload.
+func (p *BindingErrorPayload) TimelineSummary() string {
+	return fmt.Sprintf("%s %s failed: %s", p.Kind, p.Target, p.Error)
+}
+
+// BindingRecorder records binding and validation failures to the bindings monitor.
+type BindingRecorder struct {
+	monitor *debugmonitor.Monitor
+	config  *BindingMonitorConfig
+}
+
+// Binder returns an echo.Binder that binds with binder and records its failures with the request body.
+// Set it as the Binder of the Echo instance: e.Binder = recorder.Binder(e.Binder).
+// If binder is nil, echo.DefaultBinder is used.
+func (r *BindingRecorder) Binder(binder echo.Binder) echo.Binder {
+	if binder == nil {
+		binder = &echo.DefaultBinder{}
+	}
+	return &monitoredBinder{binder: binder, recorder: r}
+}
+
+// Validator returns an echo.Validator that validates with validator and records its failures with the value.
+// Set it as the Validator of the Echo instance: e.Validator = recorder.Validator(e.Validator).
+// The field failures of github.com/go-playground/validator are recorded.
+func (r *BindingRecorder) Validator(validator echo.Validator) echo.Validator {
+	return &monitoredValidator{validator: validator, recorder: r}
+}
+
+// monitoredBinder records the failures of the wrapped binder.
+type monitoredBinder struct {
+	binder   echo.Binder
+	recorder *BindingRecorder
+}
+
+func (b *monitoredBinder) Bind(i interface{}, c echo.Context) error {
+	req := c.Request()
+	var body *bodyBuffer
+	if !b.recorder.monitor.IsProductionSafe() && req.Body != nil && req.Body != http.NoBody {
+		body = &bodyBuffer{limit: b.recorder.config.MaxInputSize}
+		req.Body = newBodyCaptureReader(req.Body, body)
+	}
+
+	err := b.binder.Bind(i, c)
+	if err == nil {
+		return nil
+	}
+
+	payload := b.recorder.newPayload(BindingErrorKindBind, i, err)
+	payload.RequestID = debugmonitor.RequestIDFromContext(req.Context())
+	payload.Method = req.Method
+	payload.URI = req.RequestURI
+	payload.Route = c.Path()
+	payload.QueryParams = redactValues(c.QueryParams(), b.recorder.config.RedactBodyFields, b.recorder.monitor.IsProductionSafe())
+	if body != nil {
+		payload.Input = redactBody(body.buf.String(), req.Header.Get(echo.HeaderContentType), b.recorder.config.RedactBodyFields)
+		payload.InputTruncated = body.truncated
+	}
+	b.recorder.monitor.Add(payload)
+	return err
+}
+
+// monitoredValidator records the failures of the wrapped validator.
+type monitoredValidator struct {
+	validator echo.Validator
+	recorder  *BindingRecorder
+}
+
+func (v *monitoredValidator) Validate(i interface{}) error {
+	if v.validator == nil {
+		// The same error as echo.Context.Validate without a validator
+		return echo.ErrValidatorNotRegistered
+	}
+	err := v.validator.Validate(i)
+	if err == nil {
+		return nil
+	}
+
+	payload := v.recorder.newPayload(BindingErrorKindValidate, i, err)
+	if !v.recorder.monitor.IsProductionSafe() {
+		if b, jsonErr := json.Marshal(i); jsonErr == nil {
+			// The value is redacted before it is truncated, since a truncated JSON cannot be parsed
+			input := redactBody(string(b), echo.MIMEApplicationJSON, v.recorder.config.RedactBodyFields)
+			if len(input) > v.recorder.config.MaxInputSize {
+				input = input[:v.recorder.config.MaxInputSize]
+				payload.InputTruncated = true
+			}
+			payload.Input = input
+		}
+	}
+	v.recorder.monitor.Add(payload)
+	return err
+}
+
+// newPayload returns the payload of a failure to bind or validate i.
+func (r *BindingRecorder) newPayload(kind string, i any, err error) *BindingErrorPayload {
+	return &BindingErrorPayload{
+		Kind:      kind,
+		Target:    fmt.Sprintf("%T", i),
+		Error:     bindingErrorMessage(err),
+		Fields:    fieldFailures(err),
+		Timestamp: time.Now(),
+	}
+}
+
+// bindingErrorMessage returns the message of err, preferring the message of an echo.HTTPError
+// over its "code=400, message=..." form.
+func bindingErrorMessage(err error) string {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return fmt.Sprintf("%v", he.Message)
+	}
+	return err.Error()
+}
+
+// validationFieldError is the interface of the field errors of github.com/go-playground/validator.
+type validationFieldError interface {
+	Namespace() string
+	Tag() string
+	Param() string
+}
+
+// fieldFailures extracts the failures of the fields from a binding or validation error:
+// echo.BindingError, the JSON decoding errors returned by echo.DefaultBinder, and the slice of field errors
+// returned by github.com/go-playground/validator, which is detected by its methods so that it is not a dependency.
+func fieldFailures(err error) []*FieldFailure {
+	var be *echo.BindingError
+	if errors.As(err, &be) {
+		return []*FieldFailure{{Field: be.Field, Reason: bindingErrorMessage(be.HTTPError)}}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []*FieldFailure{{Field: typeErr.Field, Reason: fmt.Sprintf("expected=%v, got=%v", typeErr.Type, typeErr.Value)}}
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return []*FieldFailure{{Field: "offset " + strconv.FormatInt(syntaxErr.Offset, 10), Reason: syntaxErr.Error()}}
+	}
+
+	v := reflect.ValueOf(err)
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+	var failures []*FieldFailure
+	for i := 0; i < v.Len(); i++ {
+		fe, ok := v.Index(i).Interface().(validationFieldError)
+		if !ok {
+			return nil
+		}
+		failures = append(failures, &FieldFailure{Field: fe.Namespace(), Reason: fe.Tag(), Param: fe.Param()})
+	}
+	return failures
+}
+
+// BindingMonitorConfig defines the config for Bindings monitor.
+type BindingMonitorConfig struct {
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+	// MaxInputSize is the maximum number of bytes of the input to capture.
+	// Optional. Default: DefaultMaxBodySize
+	MaxInputSize int
+	// RedactBodyFields are the names of JSON and form fields whose values are redacted in the captured input
+	// and query parameters.
+	RedactBodyFields []string
+}
+
+//go:embed binding.html
+var bindingView string
+
+// bindingViewTemplate is the parsed template for the bindings view
+var bindingViewTemplate = template.Must(template.New("bindingView").Funcs(debugmonitor.TemplateFuncs).Parse(bindingView))
+
+// NewBindingMonitor creates a new monitor for the binding and validation failures and returns
+// the monitor along with the recorder that wraps the binder and the validator of Echo.
+// The offending input is captured only for the failures, so malformed client payloads can be diagnosed
+// without enabling body capture for all requests.
+func NewBindingMonitor(config BindingMonitorConfig) (*debugmonitor.Monitor, *BindingRecorder) {
+	if config.MaxInputSize <= 0 {
+		config.MaxInputSize = DefaultMaxBodySize
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "bindings",
+		DisplayName: "Bind Errors",
+		MaxRecords:  1000,
+		Icon:        debugmonitor.IconExclamationCircle,
+		Group:       "HTTP",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, bindingViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStream(c, store)
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSON(c, store)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPoll(c, store)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	return m, &BindingRecorder{monitor: m, config: &config}
+}
@@ -0,0 +1,146 @@
+package monitors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// testFieldError mimics a field error of github.com/go-playground/validator.
+type testFieldError struct{ namespace, tag, param string }
+
+func (e testFieldError) Namespace() string { return e.namespace }
+func (e testFieldError) Tag() string       { return e.tag }
+func (e testFieldError) Param() string     { return e.param }
+func (e testFieldError) Error() string     { return e.namespace + " failed on " + e.tag }
+
+// testValidationErrors mimics validator.ValidationErrors.
+type testValidationErrors []testFieldError
+
+func (e testValidationErrors) Error() string { return "validation failed" }
+
+type testValidator struct{}
+
+func (testValidator) Validate(i any) error {
+	if u, ok := i.(*testSignup); ok && len(u.Name) < 3 {
+		return testValidationErrors{{namespace: "testSignup.Name", tag: "min", param: "3"}}
+	}
+	return nil
+}
+
+type testSignup struct {
+	Name     string `json:"name" query:"name"`
+	Age      int    `json:"age" query:"age"`
+	Password string `json:"password"`
+}
+
+func TestBindingMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, recorder := NewBindingMonitor(BindingMonitorConfig{RedactBodyFields: []string{"password"}})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Binder = recorder.Binder(e.Binder)
+	e.Validator = recorder.Validator(testValidator{})
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			c.SetRequest(req.WithContext(debugmonitor.ContextWithRequestID(req.Context(), "req-1")))
+			return next(c)
+		}
+	})
+	e.Any("/signup", func(c echo.Context) error {
+		u := new(testSignup)
+		if err := c.Bind(u); err != nil {
+			return err
+		}
+		if err := c.Validate(u); err != nil {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	// A body that does not match the type of a field
+	req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"name":"alice","age":"ten","password":"s3cret"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected the binding error to be returned, got %d", rec.Code)
+	}
+	payload := (<-sub.C).Entry.Payload.(*BindingErrorPayload)
+	if payload.Kind != BindingErrorKindBind || payload.RequestID != "req-1" || payload.Route != "/signup" || payload.Target != "*monitors.testSignup" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if len(payload.Fields) != 1 || payload.Fields[0].Field != "age" || !strings.Contains(payload.Fields[0].Reason, "expected=int") {
+		t.Errorf("Expected the field of the type mismatch, got %+v", payload.Fields)
+	}
+	if !strings.Contains(payload.Input, `"age":"ten"`) || strings.Contains(payload.Input, "s3cret") {
+		t.Errorf("Expected the redacted input, got %q", payload.Input)
+	}
+
+	// A query parameter that does not match the type of a field
+	req = httptest.NewRequest(http.MethodGet, "/signup?name=alice&age=ten&password=s3cret", nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	payload = (<-sub.C).Entry.Payload.(*BindingErrorPayload)
+	if payload.Method != http.MethodGet || payload.Error == "" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if payload.QueryParams["password"][0] != redacted || payload.QueryParams["age"][0] != "ten" {
+		t.Errorf("Expected the redacted query parameters, got %v", payload.QueryParams)
+	}
+
+	// A value that fails validation
+	req = httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"name":"al","age":10,"password":"s3cret"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected the validation error to be returned, got %d", rec.Code)
+	}
+	payload = (<-sub.C).Entry.Payload.(*BindingErrorPayload)
+	if payload.Kind != BindingErrorKindValidate || payload.Error != "validation failed" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if len(payload.Fields) != 1 || *payload.Fields[0] != (FieldFailure{Field: "testSignup.Name", Reason: "min", Param: "3"}) {
+		t.Errorf("Expected the field errors of the validator, got %+v", payload.Fields)
+	}
+	if !strings.Contains(payload.Input, `"name":"al"`) || strings.Contains(payload.Input, "s3cret") {
+		t.Errorf("Expected the redacted value, got %q", payload.Input)
+	}
+
+	// Validation errors do not belong to the request, since the validator has no access to it
+	if got := m.Timeline("req-1"); len(got) != 2 {
+		t.Errorf("Expected the binding errors in the timeline of the request, got %+v", got)
+	}
+}
+
+func TestBindingMonitorProductionSafe(t *testing.T) {
+	m := debugmonitor.NewProductionSafe()
+	monitor, recorder := NewBindingMonitor(BindingMonitorConfig{})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/?token=abc", strings.NewReader(`{"name":`)), httptest.NewRecorder())
+	c.Request().Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if err := recorder.Binder(nil).Bind(new(testSignup), c); err == nil {
+		t.Fatal("Expected a binding error")
+	}
+	payload := (<-sub.C).Entry.Payload.(*BindingErrorPayload)
+	if payload.Input != "" || payload.QueryParams["token"][0] != redacted {
+		t.Errorf("Expected no input in production-safe mode, got %+v", payload)
+	}
+
+	if err := recorder.Validator(nil).Validate(new(testSignup)); !errors.Is(err, echo.ErrValidatorNotRegistered) {
+		t.Errorf("Expected the error of a missing validator, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package monitors
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultMaxBodySize is the default maximum number of bytes of a body to capture.
+const DefaultMaxBodySize = 64 * 1024
+
+// DefaultBodyContentTypes are the default content types of bodies to capture.
+var DefaultBodyContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"application/xml",
+	"text/",
+}
+
+// bodyBuffer is a size-limited buffer for capturing a body.
+type bodyBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write writes p to the buffer up to the limit. It never fails so that it can be used with io.TeeReader.
+func (b *bodyBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.buf.Len()
+	if len(p) > remaining {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// bodyCaptureReader wraps a request body and captures what the handler reads from it.
+type bodyCaptureReader struct {
+	io.ReadCloser
+	reader io.Reader
+}
+
+func newBodyCaptureReader(body io.ReadCloser, buf *bodyBuffer) *bodyCaptureReader {
+	return &bodyCaptureReader{
+		ReadCloser: body,
+		reader:     io.TeeReader(body, buf),
+	}
+}
+
+func (r *bodyCaptureReader) Read(p []byte) (int, error) {
+	return r.reader.Read(p)
+}
+
+// bodyCountingReader wraps a request body and counts the bytes the handler reads from it.
+type bodyCountingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *bodyCountingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// matchContentType reports whether the content type matches one of the allowed content types.
+// Allowed content types ending with "/" match any subtype.
+func matchContentType(contentType string, allowed []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if strings.HasSuffix(a, "/") {
+			if strings.HasPrefix(mediaType, a) {
+				return true
+			}
+		} else if mediaType == a {
+			return true
+		}
+	}
+	return false
+}
+
+// redactBody replaces the values of the given fields in a JSON or form body with the redacted placeholder.
+// Field names are matched case-insensitively. Bodies that cannot be parsed, such as bodies cut at the maximum
+// body size, are redacted by matching the fields with patterns instead, so that their values do not leak.
+func redactBody(body string, contentType string, fields []string) string {
+	if len(fields) == 0 || body == "" {
+		return body
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v any
+		if err := json.Unmarshal([]byte(body), &v); err != nil {
+			return redactFieldPatterns(body, fields)
+		}
+		redactJSON(v, fields)
+		b, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return string(b)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(body)
+		if err != nil {
+			return redactFieldPatterns(body, fields)
+		}
+		for key := range values {
+			if containsFold(fields, key) {
+				values[key] = []string{redacted}
+			}
+		}
+		return values.Encode()
+	default:
+		return body
+	}
+}
+
+// redactFieldPatterns replaces the values of the given fields in a body that cannot be parsed, both as JSON members
+// ("field": value) and as form parameters (field=value). A value cut at the end of the body is redacted as well.
+func redactFieldPatterns(body string, fields []string) string {
+	for _, field := range fields {
+		name := regexp.QuoteMeta(field)
+		jsonPattern := regexp.MustCompile(`(?i)("` + name + `"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+		body = jsonPattern.ReplaceAllString(body, `${1}"`+redacted+`"`)
+		formPattern := regexp.MustCompile(`(?i)((?:^|&)` + name + `=)[^&]*`)
+		body = formPattern.ReplaceAllString(body, `${1}`+url.QueryEscape(redacted))
+	}
+	return body
+}
+
+// redactJSON recursively redacts the values of the given fields in a decoded JSON value.
+func redactJSON(v any, fields []string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if containsFold(fields, key) {
+				v[key] = redacted
+			} else {
+				redactJSON(value, fields)
+			}
+		}
+	case []any:
+		for _, value := range v {
+			redactJSON(value, fields)
+		}
+	}
+}
+
+// containsFold reports whether the list contains s, compared case-insensitively.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBody decodes a body captured with the given Content-Encoding up to limit bytes.
+// It returns the decoded body, whether it was cut at limit or is incomplete, and false if
+// the encoding is not supported. Only gzip and deflate can be decoded with the standard library,
+// so br is not supported, and bodies compressed with it are not recorded rather than recorded as binary.
+// The decompressed stream is read only up to limit, so that a small body that expands to a huge one
+// does not take the time and memory of decompressing it.
+func decodeBody(body []byte, encoding string, limit int) (string, bool, bool) {
+	var r io.Reader
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return string(body), false, true
+	case "gzip", "x-gzip":
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return "", false, false
+		}
+		r = gr
+	case "deflate":
+		r = flate.NewReader(bytes.NewReader(body))
+	default:
+		return "", false, false
+	}
+
+	decoded := &bodyBuffer{limit: limit}
+	// One byte more than the limit is read to tell whether the body is cut at the limit
+	_, err := io.Copy(decoded, io.LimitReader(r, int64(limit)+1))
+	// An incomplete body, such as one whose compressed form was truncated, is kept as far as it was decoded
+	return decoded.buf.String(), decoded.truncated || err != nil, true
+}
+
+// responseCaptureWriter wraps a response writer and captures the body written by the handler.
+// Whether to capture is decided on the first write based on the response content type.
+type responseCaptureWriter struct {
+	http.ResponseWriter
+	buf          *bodyBuffer
+	contentTypes []string
+	decided      bool
+	capture      bool
+}
+
+func newResponseCaptureWriter(w http.ResponseWriter, buf *bodyBuffer, contentTypes []string) *responseCaptureWriter {
+	return &responseCaptureWriter{
+		ResponseWriter: w,
+		buf:            buf,
+		contentTypes:   contentTypes,
+	}
+}
+
+func (w *responseCaptureWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.capture = matchContentType(w.Header().Get(echo.HeaderContentType), w.contentTypes)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	if w.capture && n > 0 {
+		w.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// Flush implements http.Flusher so that streaming responses keep working.
+func (w *responseCaptureWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original response writer for http.ResponseController.
+func (w *responseCaptureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
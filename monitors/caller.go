@@ -0,0 +1,31 @@
+package monitors
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// callerSkipping returns the file:line of the first frame on the stack of its caller whose function does not
+// start with one of skipPrefixes. It returns an empty string if there is none.
+func callerSkipping(skipPrefixes []string) string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		skip := false
+		for _, prefix := range skipPrefixes {
+			if strings.HasPrefix(frame.Function, prefix) {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package monitors
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// ConfigPayload represents a snapshot of the environment and the configuration the process runs with
+type ConfigPayload struct {
+	Hostname  string        `json:"hostname"`
+	PID       int           `json:"pid"`
+	GoVersion string        `json:"goVersion"`
+	Env       []ConfigValue `json:"env"`
+	Config    []ConfigValue `json:"config,omitempty"` // flattened application config
+	Error     string        `json:"error,omitempty"`  // set if the application config cannot be encoded
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// ConfigValue is a key and value of an environment variable or of the application config.
+// Keys of the application config are the paths of the fields in its JSON encoding, such as "db.password".
+type ConfigValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// DefaultSecretPatterns are the substrings of keys whose values are redacted by default.
+var DefaultSecretPatterns = []string{"password", "passwd", "secret", "token", "key", "credential", "auth", "private", "dsn"}
+
+// ConfigMonitorConfig defines the config for Config monitor.
+type ConfigMonitorConfig struct {
+	// Config is the configuration of the application, typically a pointer to a struct.
+	// It is encoded as JSON and flattened into keys each time a snapshot is taken.
+	// Optional.
+	Config any
+	// SecretPatterns are the substrings of keys whose values are redacted, compared case-insensitively.
+	// Optional. Default: DefaultSecretPatterns
+	SecretPatterns []string
+	// IgnoreEnv disables capturing the environment variables.
+	IgnoreEnv bool
+}
+
+//go:embed config.html
+var configView string
+
+// configViewTemplate is the parsed template for the config view
+var configViewTemplate = template.Must(template.New("configView").Funcs(debugmonitor.TemplateFuncs).Parse(configView))
+
+// ConfigMonitor is a plugin that displays the environment variables and the configuration of the application.
+// It takes a snapshot when it is added with debugmonitor.Manager.AddPlugin, and another one each time
+// the snapshot action is requested from the dashboard. In production-safe mode, all environment variable
+// values are redacted.
+type ConfigMonitor struct {
+	monitor *debugmonitor.Monitor
+	config  ConfigMonitorConfig
+}
+
+// NewConfigMonitor creates a new monitor for the environment and the configuration of the application.
+func NewConfigMonitor(config ConfigMonitorConfig) *ConfigMonitor {
+	if config.SecretPatterns == nil {
+		config.SecretPatterns = DefaultSecretPatterns
+	}
+
+	cm := &ConfigMonitor{config: config}
+	cm.monitor = &debugmonitor.Monitor{
+		Name:        "config",
+		DisplayName: "Config",
+		MaxRecords:  20,
+		Icon:        debugmonitor.IconDocumentText,
+		Group:       "Runtime",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, configViewTemplate, nil)
+			case "data":
+				return debugmonitor.HandleDataJSON(c, store)
+			case "snapshot":
+				if c.Request().Method != http.MethodPost {
+					return echo.NewHTTPError(http.StatusMethodNotAllowed)
+				}
+				cm.Snapshot()
+				return c.NoContent(http.StatusNoContent)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+	return cm
+}
+
+// Monitor implements debugmonitor.MonitorPlugin.
+func (cm *ConfigMonitor) Monitor() *debugmonitor.Monitor {
+	return cm.monitor
+}
+
+// Assets implements debugmonitor.MonitorPlugin.
+func (cm *ConfigMonitor) Assets() fs.FS {
+	return nil
+}
+
+// Run implements debugmonitor.MonitorPluginRunner. It takes the initial snapshot.
+func (cm *ConfigMonitor) Run(ctx context.Context) {
+	cm.Snapshot()
+}
+
+// Snapshot records the current environment and configuration.
+func (cm *ConfigMonitor) Snapshot() {
+	payload := &ConfigPayload{
+		PID:       os.Getpid(),
+		GoVersion: runtime.Version(),
+		Timestamp: time.Now(),
+	}
+	payload.Hostname, _ = os.Hostname()
+
+	if !cm.config.IgnoreEnv {
+		productionSafe := cm.monitor.IsProductionSafe()
+		for _, kv := range os.Environ() {
+			key, value, _ := strings.Cut(kv, "=")
+			payload.Env = append(payload.Env, cm.value(key, value, productionSafe))
+		}
+		sort.Slice(payload.Env, func(i, j int) bool { return payload.Env[i].Key < payload.Env[j].Key })
+	}
+
+	if cm.config.Config != nil {
+		b, err := json.Marshal(cm.config.Config)
+		if err != nil {
+			payload.Error = err.Error()
+		} else {
+			var v any
+			if err := json.Unmarshal(b, &v); err != nil {
+				payload.Error = err.Error()
+			} else {
+				cm.flatten("", v, &payload.Config)
+			}
+		}
+	}
+
+	cm.monitor.Add(payload)
+}
+
+// flatten appends the leaves of a decoded JSON value to values, keyed by their dotted paths.
+// Array elements are keyed by their indexes.
+func (cm *ConfigMonitor) flatten(prefix string, v any, values *[]ConfigValue) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if cm.isSecret(key) {
+				// Redact the whole subtree of a secret key
+				*values = append(*values, ConfigValue{Key: join(key), Value: redacted, Redacted: true})
+				continue
+			}
+			cm.flatten(join(key), v[key], values)
+		}
+	case []any:
+		for i, value := range v {
+			cm.flatten(join(strconv.Itoa(i)), value, values)
+		}
+	case string:
+		*values = append(*values, ConfigValue{Key: prefix, Value: v})
+	case nil:
+		*values = append(*values, ConfigValue{Key: prefix, Value: "null"})
+	default:
+		b, _ := json.Marshal(v)
+		*values = append(*values, ConfigValue{Key: prefix, Value: string(b)})
+	}
+}
+
+// value returns the ConfigValue of an environment variable, redacted if its key is a secret or all is true.
+func (cm *ConfigMonitor) value(key, value string, all bool) ConfigValue {
+	if all || cm.isSecret(key) {
+		return ConfigValue{Key: key, Value: redacted, Redacted: true}
+	}
+	return ConfigValue{Key: key, Value: value}
+}
+
+// isSecret reports whether the key contains one of the secret patterns.
+func (cm *ConfigMonitor) isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range cm.config.SecretPatterns {
+		if strings.Contains(key, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
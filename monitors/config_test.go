@@ -0,0 +1,74 @@
+package monitors
+
+import (
+	"testing"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+func TestConfigMonitor(t *testing.T) {
+	t.Setenv("DEBUGMONITOR_TEST_NAME", "app")
+	t.Setenv("DEBUGMONITOR_TEST_API_KEY", "key-1234")
+
+	type dbConfig struct {
+		Host     string `json:"host"`
+		Password string `json:"password"`
+	}
+	appConfig := &struct {
+		Port    int        `json:"port"`
+		DB      dbConfig   `json:"db"`
+		Servers []string   `json:"servers"`
+		Tokens  []dbConfig `json:"tokens"`
+	}{
+		Port:    8080,
+		DB:      dbConfig{Host: "localhost", Password: "p@ss"},
+		Servers: []string{"a", "b"},
+	}
+
+	m := debugmonitor.New()
+	defer m.Close()
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	m.AddPlugin(NewConfigMonitor(ConfigMonitorConfig{Config: appConfig}))
+
+	payload := (<-sub.C).Entry.Payload.(*ConfigPayload)
+	values := func(list []ConfigValue) map[string]string {
+		result := map[string]string{}
+		for _, v := range list {
+			result[v.Key] = v.Value
+		}
+		return result
+	}
+
+	env := values(payload.Env)
+	if env["DEBUGMONITOR_TEST_NAME"] != "app" || env["DEBUGMONITOR_TEST_API_KEY"] != redacted {
+		t.Errorf("Unexpected environment: %v", env)
+	}
+
+	config := values(payload.Config)
+	expected := map[string]string{
+		"port":        "8080",
+		"db.host":     "localhost",
+		"db.password": redacted,
+		"servers.0":   "a",
+		"servers.1":   "b",
+		"tokens":      redacted,
+	}
+	for key, value := range expected {
+		if config[key] != value {
+			t.Errorf("Expected %s to be %q, got %q", key, value, config[key])
+		}
+	}
+
+	ps := debugmonitor.NewProductionSafe()
+	defer ps.Close()
+	psSub := ps.Subscribe()
+	defer psSub.Close()
+
+	ps.AddPlugin(NewConfigMonitor(ConfigMonitorConfig{}))
+	payload = (<-psSub.C).Entry.Payload.(*ConfigPayload)
+	if env := values(payload.Env); env["DEBUGMONITOR_TEST_NAME"] != redacted {
+		t.Errorf("Expected all environment variables to be redacted in production-safe mode, got %q", env["DEBUGMONITOR_TEST_NAME"])
+	}
+}
@@ -0,0 +1,295 @@
+package monitors
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// DumpPayload represents the data structure for variable dump monitoring
+type DumpPayload struct {
+	RequestID string       `json:"requestId,omitempty"` // ID of the request the values were dumped in
+	Values    []*DumpValue `json:"values"`
+	Caller    string       `json:"caller,omitempty"` // file:line of the application code that dumped the values
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// DumpValue is a value passed to debugmonitor.Dump.
+type DumpValue struct {
+	Type      string `json:"type"`
+	Value     string `json:"value,omitempty"` // pretty-printed value, not recorded in production-safe mode
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// TimelineRequestID implements debugmonitor.TimelinePayload.
+func (p *DumpPayload) TimelineRequestID() string { return p.RequestID }
+
+// TimelineTime implements debugmonitor.TimelinePayload.
+func (p *DumpPayload) TimelineTime() time.Time { return p.Timestamp }
+
+// TimelineSummary implements debugmonitor.TimelinePayload.
+func (p *DumpPayload) TimelineSummary() string {
+	types := make([]string, len(p.Values))
+	for i, v := range p.Values {
+		types[i] = v.Type
+	}
+	return "dump " + strings.Join(types, ", ")
+}
+
+// DumpsMonitorConfig defines the config for Dumps monitor.
+type DumpsMonitorConfig struct {
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+	// MaxValueSize is the maximum number of bytes of each pretty-printed value to capture.
+	// Optional. Default: DefaultMaxBodySize
+	MaxValueSize int
+}
+
+//go:embed dumps.html
+var dumpsView string
+
+// dumpsViewTemplate is the parsed template for the dumps view
+var dumpsViewTemplate = template.Must(template.New("dumpsView").Funcs(debugmonitor.TemplateFuncs).Parse(dumpsView))
+
+// NewDumpsMonitor creates a new monitor for variable dumps and returns the monitor along with
+// the dumper to pass to debugmonitor.SetDumper, so that debugmonitor.Dump sends the values to the monitor.
+// In production-safe mode, only the types of the values are recorded.
+func NewDumpsMonitor(config DumpsMonitorConfig) (*debugmonitor.Monitor, debugmonitor.Dumper) {
+	if config.MaxValueSize <= 0 {
+		config.MaxValueSize = DefaultMaxBodySize
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "dumps",
+		DisplayName: "Dumps",
+		MaxRecords:  1000,
+		Icon:        debugmonitor.IconDocumentText,
+		Group:       "Application",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, dumpsViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStream(c, store)
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSON(c, store)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPoll(c, store)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	dumper := func(ctx context.Context, v ...any) {
+		payload := &DumpPayload{
+			RequestID: debugmonitor.RequestIDFromContext(ctx),
+			Values:    make([]*DumpValue, len(v)),
+			Caller:    dumpCaller(),
+			Timestamp: time.Now(),
+		}
+		for i, value := range v {
+			// The values are formatted when they are dumped, so that later changes to them are not reflected
+			dv := &DumpValue{Type: fmt.Sprintf("%T", value)}
+			if value == nil {
+				dv.Type = "nil"
+			}
+			if !m.IsProductionSafe() {
+				dv.Value = formatDump(value)
+				if len(dv.Value) > config.MaxValueSize {
+					dv.Value = dv.Value[:config.MaxValueSize]
+					dv.Truncated = true
+				}
+			}
+			payload.Values[i] = dv
+		}
+		m.Add(payload)
+	}
+
+	return m, dumper
+}
+
+// dumpCallerSkipPrefixes are the prefixes of the functions that are skipped to find the code that dumped the values:
+// the runtime, debugmonitor.Dump and the dumper of this package.
+var dumpCallerSkipPrefixes = []string{
+	"runtime.",
+	"github.com/kohkimakimoto/echo-debugmonitor.Dump",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.NewDumpsMonitor.",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.dumpCaller",
+}
+
+// dumpCaller returns the file:line of the first frame on the stack that is not in the runtime,
+// debugmonitor.Dump or the dumper. It returns an empty string if there is none.
+func dumpCaller() string {
+	return callerSkipping(dumpCallerSkipPrefixes)
+}
+
+// maxDumpDepth is the maximum depth of nested values that formatDump prints.
+const maxDumpDepth = 10
+
+// formatDump pretty-prints v with its types and the unexported fields of structs, one field or element per line.
+// Map keys are sorted, and pointers that refer back to a value being printed are not followed.
+func formatDump(v any) string {
+	p := &dumpPrinter{visited: make(map[uintptr]bool)}
+	p.print(reflect.ValueOf(v), 0)
+	return p.buf.String()
+}
+
+// dumpPrinter is the state of formatDump.
+type dumpPrinter struct {
+	buf     strings.Builder
+	visited map[uintptr]bool // pointers and maps being printed
+}
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	timeType  = reflect.TypeOf(time.Time{})
+)
+
+func (p *dumpPrinter) print(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		p.buf.WriteString("nil")
+		return
+	}
+	if depth > maxDumpDepth {
+		p.buf.WriteString("...")
+		return
+	}
+
+	if v.Type() == timeType && v.CanInterface() {
+		p.buf.WriteString(v.Interface().(time.Time).String())
+		return
+	}
+	if v.Kind() != reflect.Interface && v.Type().Implements(errorType) && v.CanInterface() && !(v.Kind() == reflect.Pointer && v.IsNil()) {
+		fmt.Fprintf(&p.buf, "%s(%q)", v.Type(), v.Interface().(error).Error())
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		p.print(v.Elem(), depth)
+	case reflect.Pointer:
+		if v.IsNil() {
+			fmt.Fprintf(&p.buf, "(%s)(nil)", v.Type())
+			return
+		}
+		if p.visited[v.Pointer()] {
+			fmt.Fprintf(&p.buf, "&<cycle %s>", v.Elem().Type())
+			return
+		}
+		p.visited[v.Pointer()] = true
+		defer delete(p.visited, v.Pointer())
+		p.buf.WriteString("&")
+		p.print(v.Elem(), depth)
+	case reflect.Struct:
+		p.buf.WriteString(v.Type().String())
+		if v.NumField() == 0 {
+			p.buf.WriteString("{}")
+			return
+		}
+		p.buf.WriteString("{\n")
+		for i := 0; i < v.NumField(); i++ {
+			p.indent(depth + 1)
+			p.buf.WriteString(v.Type().Field(i).Name)
+			p.buf.WriteString(": ")
+			p.print(v.Field(i), depth+1)
+			p.buf.WriteString(",\n")
+		}
+		p.indent(depth)
+		p.buf.WriteString("}")
+	case reflect.Map:
+		if v.IsNil() {
+			fmt.Fprintf(&p.buf, "%s(nil)", v.Type())
+			return
+		}
+		if p.visited[v.Pointer()] {
+			fmt.Fprintf(&p.buf, "<cycle %s>", v.Type())
+			return
+		}
+		p.visited[v.Pointer()] = true
+		defer delete(p.visited, v.Pointer())
+		p.buf.WriteString(v.Type().String())
+		if v.Len() == 0 {
+			p.buf.WriteString("{}")
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		p.buf.WriteString("{\n")
+		for _, key := range keys {
+			p.indent(depth + 1)
+			p.print(key, depth+1)
+			p.buf.WriteString(": ")
+			p.print(v.MapIndex(key), depth+1)
+			p.buf.WriteString(",\n")
+		}
+		p.indent(depth)
+		p.buf.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			fmt.Fprintf(&p.buf, "%s(nil)", v.Type())
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			fmt.Fprintf(&p.buf, "%s(%q)", v.Type(), v.Bytes())
+			return
+		}
+		p.buf.WriteString(v.Type().String())
+		if v.Len() == 0 {
+			p.buf.WriteString("{}")
+			return
+		}
+		p.buf.WriteString("{\n")
+		for i := 0; i < v.Len(); i++ {
+			p.indent(depth + 1)
+			p.print(v.Index(i), depth+1)
+			p.buf.WriteString(",\n")
+		}
+		p.indent(depth)
+		p.buf.WriteString("}")
+	case reflect.String:
+		p.buf.WriteString(strconv.Quote(v.String()))
+	case reflect.Bool:
+		p.buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p.buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		p.buf.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		fmt.Fprintf(&p.buf, "%v", v.Complex())
+	default:
+		// Channels, functions and unsafe pointers
+		if v.IsNil() {
+			fmt.Fprintf(&p.buf, "%s(nil)", v.Type())
+		} else {
+			fmt.Fprintf(&p.buf, "%s(%#x)", v.Type(), v.Pointer())
+		}
+	}
+}
+
+// indent writes the indentation of the given depth.
+func (p *dumpPrinter) indent(depth int) {
+	p.buf.WriteString(strings.Repeat("  ", depth))
+}
@@ -0,0 +1,87 @@
+package monitors
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+func TestDumpsMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, dumper := NewDumpsMonitor(DumpsMonitorConfig{})
+	m.AddMonitor(monitor)
+	debugmonitor.SetDumper(dumper)
+	defer debugmonitor.SetDumper(nil)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	type user struct {
+		Name  string
+		tags  []string
+		Admin *bool
+	}
+	u := &user{Name: "alice", tags: []string{"a"}}
+	debugmonitor.DumpContext(debugmonitor.ContextWithRequestID(context.Background(), "req-1"), u, nil)
+	// Changes after the dump are not reflected
+	u.Name = "bob"
+
+	payload := (<-sub.C).Entry.Payload.(*DumpPayload)
+	if payload.RequestID != "req-1" || len(payload.Values) != 2 {
+		t.Fatalf("Unexpected payload: %+v", payload)
+	}
+	if !strings.Contains(payload.Caller, "dumps_test.go") {
+		t.Errorf("Expected the caller to be the test, got %q", payload.Caller)
+	}
+	want := "&monitors.user{\n  Name: \"alice\",\n  tags: []string{\n    \"a\",\n  },\n  Admin: (*bool)(nil),\n}"
+	if payload.Values[0].Type != "*monitors.user" || payload.Values[0].Value != want {
+		t.Errorf("Unexpected value: %s %s", payload.Values[0].Type, payload.Values[0].Value)
+	}
+	if payload.Values[1].Type != "nil" || payload.Values[1].Value != "nil" {
+		t.Errorf("Unexpected value: %+v", payload.Values[1])
+	}
+	if got := m.Timeline("req-1"); len(got) != 1 || got[0].Summary != "dump *monitors.user, nil" {
+		t.Errorf("Expected the dump in the timeline of the request, got %+v", got)
+	}
+}
+
+func TestFormatDump(t *testing.T) {
+	type node struct {
+		Next *node
+	}
+	cyclic := &node{}
+	cyclic.Next = cyclic
+
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{map[string]int{"b": 2, "a": 1}, "map[string]int{\n  \"a\": 1,\n  \"b\": 2,\n}"},
+		{[]byte("hi"), `[]uint8("hi")`},
+		{errors.New("boom"), `*errors.errorString("boom")`},
+		{cyclic, "&monitors.node{\n  Next: &<cycle monitors.node>,\n}"},
+		{[]int(nil), "[]int(nil)"},
+		{3.5, "3.5"},
+	}
+	for _, tt := range tests {
+		if got := formatDump(tt.value); got != tt.want {
+			t.Errorf("formatDump(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDumpsMonitorProductionSafe(t *testing.T) {
+	m := debugmonitor.NewProductionSafe()
+	monitor, dumper := NewDumpsMonitor(DumpsMonitorConfig{})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	dumper(context.Background(), map[string]string{"password": "s3cret"})
+	payload := (<-sub.C).Entry.Payload.(*DumpPayload)
+	if payload.Values[0].Type != "map[string]string" || payload.Values[0].Value != "" {
+		t.Errorf("Expected only the type in production-safe mode, got %+v", payload.Values[0])
+	}
+}
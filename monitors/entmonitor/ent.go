@@ -0,0 +1,20 @@
+// Package entmonitor records the queries run through ent in the queries monitor of echo-debugmonitor.
+// It is a separate module so that the monitors package does not depend on ent.
+package entmonitor
+
+import (
+	entsql "entgo.io/ent/dialect/sql"
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+)
+
+// NewDriver creates a queries monitor for the database of config, as monitors.NewQueriesMonitor does,
+// and returns the monitor along with an ent SQL driver of the dialect, such as dialect.Postgres,
+// on the monitored connection. Pass the driver to the client generated by ent:
+//
+//	queriesMonitor, drv := entmonitor.NewDriver(monitors.QueriesMonitorConfig{Driver: &pq.Driver{}, DSN: dsn}, dialect.Postgres)
+//	client := ent.NewClient(ent.Driver(drv))
+func NewDriver(config monitors.QueriesMonitorConfig, dialect string) (*debugmonitor.Monitor, *entsql.Driver) {
+	monitor, db := monitors.NewQueriesMonitor(config)
+	return monitor, entsql.OpenDB(dialect, db)
+}
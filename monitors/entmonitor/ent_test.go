@@ -0,0 +1,45 @@
+package entmonitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+	"modernc.org/sqlite"
+)
+
+func TestNewDriver(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, drv := NewDriver(monitors.QueriesMonitorConfig{Driver: &sqlite.Driver{}, DSN: ":memory:"}, dialect.SQLite)
+	defer drv.Close()
+	m.AddMonitor(monitor)
+	drv.DB().SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if err := drv.Exec(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)", []any{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	query, args := entsql.Dialect(dialect.SQLite).Select("name").From(entsql.Table("users")).Where(entsql.EQ("id", 1)).Query()
+	var rows entsql.Rows
+	if err := drv.Query(ctx, query, args, &rows); err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+	select {
+	case event := <-sub.C:
+		payload := event.Entry.Payload.(*monitors.QueryPayload)
+		if payload.Query != query || payload.Operation != "Query" {
+			t.Errorf("Unexpected payload: %+v", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the query to be recorded")
+	}
+}
@@ -2,6 +2,7 @@ package monitors
 
 import (
 	_ "embed"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -13,18 +14,62 @@ import (
 
 // ErrorPayload represents the data structure for error monitoring
 type ErrorPayload struct {
-	Error      string    `json:"error"`
-	Type       string    `json:"type"`
-	Message    string    `json:"message"`
-	StackTrace string    `json:"stackTrace"`
-	Timestamp  time.Time `json:"timestamp"`
+	Error       string          `json:"error"`
+	Type        string          `json:"type"`
+	Message     string          `json:"message"`
+	StackTrace  string          `json:"stackTrace"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Frames      []StackFrame    `json:"frames,omitempty"`    // structured frames of the stack trace, if available
+	RequestID   string          `json:"requestId,omitempty"` // ID of the request the error occurred in
+	Method      string          `json:"method,omitempty"`    // HTTP method of the request the error occurred in
+	URI         string          `json:"uri,omitempty"`       // request URI of the request the error occurred in
+	Route       string          `json:"route,omitempty"`     // route path of the handler, such as "/users/:id"
+	Status      int             `json:"status,omitempty"`    // HTTP status the error is responded with
+	Fingerprint string          `json:"fingerprint"`         // identifies the errors with the same type, normalized message and top frames
+	Severity    string          `json:"severity"`            // info, warning or critical, classified by the severity rules
+	Regression  bool            `json:"regression"`          // the group of the error recurs after it was marked resolved
+	Alert       *ErrorRateAlert `json:"alert,omitempty"`     // set if the entry is an error rate alert rather than an error
+	Internal    *InternalError  `json:"internal,omitempty"`  // internal error of an *echo.HTTPError, if any
+	Origin      string          `json:"origin"`              // OriginUnhandled or OriginHandled
 }
 
+// Origins of errors.
+const (
+	// OriginUnhandled is the origin of the errors that escaped to the client: the ones that reached
+	// the HTTP error handler and the panics recovered by PanicRecoveryMiddleware.
+	OriginUnhandled = "unhandled"
+	// OriginHandled is the origin of the errors recorded manually with the ErrorRecorder.
+	OriginHandled = "handled"
+)
+
+// InternalError represents the internal error of an *echo.HTTPError, which is not exposed to the client.
+type InternalError struct {
+	Type       string       `json:"type"`
+	Message    string       `json:"message"`
+	StackTrace string       `json:"stackTrace"`
+	Frames     []StackFrame `json:"frames,omitempty"`
+}
+
+// TimelineRequestID implements debugmonitor.TimelinePayload.
+func (p *ErrorPayload) TimelineRequestID() string { return p.RequestID }
+
+// TimelineTime implements debugmonitor.TimelinePayload.
+func (p *ErrorPayload) TimelineTime() time.Time { return p.Timestamp }
+
+// TimelineSummary implements debugmonitor.TimelinePayload.
+func (p *ErrorPayload) TimelineSummary() string { return p.Type + ": " + p.Message }
+
 //go:embed errors.html
 var errorsView string
 
 // errorsViewTemplate is the parsed template for the errors view
-var errorsViewTemplate = template.Must(template.New("errorsView").Parse(errorsView))
+var errorsViewTemplate = template.Must(template.New("errorsView").Funcs(debugmonitor.TemplateFuncs).Parse(errorsView))
+
+//go:embed errors_groups.html
+var errorGroupsView string
+
+// errorGroupsViewTemplate is the parsed template for the per-fingerprint groups view
+var errorGroupsViewTemplate = template.Must(template.New("errorGroupsView").Funcs(debugmonitor.TemplateFuncs).Parse(errorGroupsView))
 
 // ErrorRecorder is a function type for recording errors
 type ErrorRecorder func(err error)
@@ -33,28 +78,92 @@ type ErrorRecorder func(err error)
 type ErrorsMonitorConfig struct {
 	// UsePolling enables polling mode instead of SSE for real-time updates.
 	UsePolling bool
+	// Deduplicate records only the first occurrence of each group of errors in the monitor.
+	// The later occurrences are only counted in the groups, so that a flood of identical errors
+	// does not fill the buffer.
+	Deduplicate bool
+	// SeverityRules classifies the errors into severities. The first matching rule wins and errors that match
+	// no rule are critical. If it is nil, DefaultSeverityRules is used.
+	SeverityRules []SeverityRule
+	// OnError is called with each recorded error, including the ones not added to the monitor
+	// because of Deduplicate. Use NewWebhookNotifier to post the errors to a webhook such as Slack.
+	// It is called synchronously, so it must not block.
+	OnError func(payload *ErrorPayload)
+	// AlertThreshold is the number of errors per minute above which an alert entry is added to the monitor,
+	// so that a sudden breakage stands out. Zero disables the alerts.
+	AlertThreshold int
+	// OnAlert is called with each alert. It is called synchronously, so it must not block.
+	OnAlert func(alert *ErrorRateAlert)
+	// Exporters forward each recorded error, with its stack trace and request context, to external error
+	// trackers such as Sentry (NewSentryExporter) or an OpenTelemetry collector (NewOTLPExporter).
+	// The errors are exported in the background, including the ones not added to the monitor because of Deduplicate.
+	Exporters []ErrorExporter
+	// OnExportFailure is called with the error if exporting an error fails.
+	OnExportFailure func(err error)
 }
 
 // NewErrorsMonitor creates a new monitor for errors and returns
 // the monitor along with an error recording function
 func NewErrorsMonitor(config ErrorsMonitorConfig) (*debugmonitor.Monitor, ErrorRecorder) {
-	m := &debugmonitor.Monitor{
+	if config.SeverityRules == nil {
+		config.SeverityRules = DefaultSeverityRules
+	}
+
+	// groups holds the per-fingerprint aggregates of the errors
+	groups := newErrorGroups()
+	// rate counts the errors per minute for the alerts
+	rate := newErrorRate(config.AlertThreshold)
+	// exports forwards the errors to the exporters
+	exports := newErrorExports(config.Exporters, config.OnExportFailure)
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
 		Name:        "errors",
 		DisplayName: "Errors",
 		MaxRecords:  1000,
 		Icon:        debugmonitor.IconExclamationCircle,
+		Group:       "Application",
 		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
 			switch action {
 			case "render":
 				return debugmonitor.RenderTemplate(c, errorsViewTemplate, map[string]any{
-					"UsePolling": config.UsePolling,
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
 				})
 			case "stream":
 				// SSE endpoint for real-time updates
-				return debugmonitor.HandleSSEStream(c, store)
+				return debugmonitor.HandleSSEStreamWithFilter(c, store, errorsFilter(c))
 			case "data":
 				// JSON endpoint for polling mode
-				return debugmonitor.HandleDataJSON(c, store)
+				return debugmonitor.HandleDataJSONWithFilter(c, store, errorsFilter(c))
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPollWithFilter(c, store, errorsFilter(c))
+			case "groups":
+				// Per-fingerprint occurrence counts, the most recently seen first, as JSON with format=json
+				// or as an HTML fragment. POST resets the groups.
+				if c.Request().Method == http.MethodPost {
+					groups.reset()
+					return c.NoContent(http.StatusNoContent)
+				}
+				snapshot := groups.snapshot()
+				if c.QueryParam("format") == "json" {
+					return c.JSON(http.StatusOK, snapshot)
+				}
+				return debugmonitor.RenderTemplate(c, errorGroupsViewTemplate, map[string]any{
+					"Groups": snapshot,
+				})
+			case "resolve":
+				// Marks the group of the "fingerprint" parameter resolved. If the errors of the group recur,
+				// they are flagged as regressions.
+				if c.Request().Method != http.MethodPost {
+					return echo.NewHTTPError(http.StatusMethodNotAllowed)
+				}
+				if !groups.resolve(c.QueryParam("fingerprint"), time.Now()) {
+					return echo.NewHTTPError(http.StatusNotFound, "error group not found")
+				}
+				return c.NoContent(http.StatusNoContent)
 			default:
 				return echo.NewHTTPError(http.StatusBadRequest)
 			}
@@ -67,6 +176,12 @@ func NewErrorsMonitor(config ErrorsMonitorConfig) (*debugmonitor.Monitor, ErrorR
 			return
 		}
 
+		// Unwrap the request context attached by RecordWithContext
+		var re *requestError
+		if v, ok := err.(*requestError); ok {
+			err, re = v.err, v
+		}
+
 		// Get error type
 		errorType := fmt.Sprintf("%T", err)
 
@@ -74,32 +189,184 @@ func NewErrorsMonitor(config ErrorsMonitorConfig) (*debugmonitor.Monitor, ErrorR
 		errorMessage := err.Error()
 
 		// Extract stack trace from the error
-		stackTrace := extractStackTrace(err)
+		stackTrace, frames := errorStack(err)
+
+		payload := &ErrorPayload{
+			Error:       errorMessage,
+			Type:        errorType,
+			Message:     errorMessage,
+			StackTrace:  stackTrace,
+			Timestamp:   time.Now(),
+			Frames:      frames,
+			Fingerprint: errorFingerprint(errorType, errorMessage, stackTrace),
+		}
+
+		// Record the HTTP-facing message and the internal error of an HTTPError separately.
+		// The internal error is where the error originated, so it identifies the group of the error.
+		var he *echo.HTTPError
+		if errors.As(err, &he) && he.Internal != nil {
+			internalStackTrace, internalFrames := errorStack(he.Internal)
+			payload.Message = fmt.Sprintf("%v", he.Message)
+			payload.StackTrace, payload.Frames = "", nil
+			payload.Internal = &InternalError{
+				Type:       fmt.Sprintf("%T", he.Internal),
+				Message:    he.Internal.Error(),
+				StackTrace: internalStackTrace,
+				Frames:     internalFrames,
+			}
+			payload.Fingerprint = errorFingerprint(payload.Internal.Type, payload.Internal.Message, internalStackTrace)
+		}
+		if re != nil {
+			payload.RequestID = re.requestID
+			payload.Method = re.method
+			payload.URI = re.uri
+			if m.IsProductionSafe() {
+				// The URI goes to the exporters and OnError, so its query values are redacted as in the requests monitor
+				payload.URI = redactURI(re.uri)
+			}
+			payload.Route = re.route
+			payload.Status = re.status
+		}
+		payload.Origin = OriginHandled
+		if re != nil && re.unhandled {
+			payload.Origin = OriginUnhandled
+		}
+		payload.Severity = classifySeverity(config.SeverityRules, err, payload.Status)
+
+		// Count the error in its group
+		first, regression := groups.record(payload)
+		payload.Regression = regression
+
+		if config.OnError != nil {
+			config.OnError(payload)
+		}
+		exports.export(payload)
+
+		// A regression is always added so that the recurred group reappears in the monitor
+		if first || regression || !config.Deduplicate {
+			// Add error to monitor
+			m.Add(payload)
+		}
 
-		// Add error to monitor
-		m.Add(&ErrorPayload{
-			Error:      errorMessage,
-			Type:       errorType,
-			Message:    errorMessage,
-			StackTrace: stackTrace,
-			Timestamp:  time.Now(),
-		})
+		if config.AlertThreshold > 0 {
+			if alert := rate.record(payload.Timestamp); alert != nil {
+				m.Add(newErrorRateAlertPayload(alert, payload.Timestamp))
+				if config.OnAlert != nil {
+					config.OnAlert(alert)
+				}
+			}
+		}
 	}
 
 	return m, recorder
 }
 
+// errorsFilter returns the filter of the errors given by the query parameters: "severity" keeps the errors
+// of the severity or more severe ones, and "origin" keeps the errors of the origin. Alerts are not filtered
+// by origin. It returns nil if no parameter is given.
+func errorsFilter(c echo.Context) debugmonitor.EntryFilter {
+	severity := c.QueryParam("severity")
+	origin := c.QueryParam("origin")
+	if severity == "" && origin == "" {
+		return nil
+	}
+	minRank := severityRanks[severity]
+	return func(entry *debugmonitor.DataEntry) bool {
+		payload, ok := entry.Payload.(*ErrorPayload)
+		if !ok {
+			return false
+		}
+		if severity != "" && severityRanks[payload.Severity] < minRank {
+			return false
+		}
+		if origin != "" && payload.Alert == nil && payload.Origin != origin {
+			return false
+		}
+		return true
+	}
+}
+
 // HTTPErrorHandlerWrapper returns an echo.HTTPErrorHandler that records errors
 // and then delegates to the provided handler
 func HTTPErrorHandlerWrapper(recorder ErrorRecorder, handler echo.HTTPErrorHandler) echo.HTTPErrorHandler {
 	return func(err error, c echo.Context) {
-		// Record the error
-		recorder(err)
+		// Record the error along with the request it occurred in,
+		// unless it is a panic already recorded by PanicRecoveryMiddleware
+		if !isRecordedPanic(err) {
+			recorder.recordUnhandled(c, err)
+		}
 		// Delegate to the original handler
 		handler(err, c)
 	}
 }
 
+// RecordWithContext records an error along with the request of c it occurred in: its method, URI, route,
+// the status the error is responded with and its ID. Use it to record errors that handlers handle themselves
+// instead of returning them to the HTTP error handler.
+func (r ErrorRecorder) RecordWithContext(c echo.Context, err error) {
+	if err == nil {
+		return
+	}
+	r(newRequestError(c, err))
+}
+
+// recordUnhandled records an error that escaped to the client along with the request of c it occurred in.
+func (r ErrorRecorder) recordUnhandled(c echo.Context, err error) {
+	re := newRequestError(c, err)
+	re.unhandled = true
+	r(re)
+}
+
+// requestError carries the request an error occurred in to the ErrorRecorder.
+type requestError struct {
+	err       error
+	requestID string
+	method    string
+	uri       string
+	route     string
+	status    int
+	unhandled bool
+}
+
+// newRequestError attaches the request of c to err. The status is the code of an echo.HTTPError,
+// the status already sent if the response is committed, or 500.
+func newRequestError(c echo.Context, err error) *requestError {
+	status := http.StatusInternalServerError
+	var he *echo.HTTPError
+	if c.Response().Committed {
+		status = c.Response().Status
+	} else if errors.As(err, &he) {
+		status = he.Code
+	}
+	requestID := debugmonitor.RequestIDFromContext(c.Request().Context())
+	if requestID == "" {
+		requestID = c.Response().Header().Get(echo.HeaderXRequestID)
+	}
+	return &requestError{
+		err:       err,
+		requestID: requestID,
+		method:    c.Request().Method,
+		uri:       c.Request().RequestURI,
+		route:     c.Path(),
+		status:    status,
+	}
+}
+
+func (e *requestError) Error() string { return e.err.Error() }
+
+func (e *requestError) Unwrap() error { return e.err }
+
+// errorStack returns the stack trace of an error as text along with its structured frames, if available.
+// The text is built from the frames if the error provides no text.
+func errorStack(err error) (string, []StackFrame) {
+	frames := extractStackFrames(err)
+	stackTrace := extractStackTrace(err)
+	if stackTrace == "" && frames != nil {
+		stackTrace = formatStackFrames(frames)
+	}
+	return stackTrace, frames
+}
+
 // extractStackTrace attempts to extract stack trace information from an error
 // It supports:
 // 1. Errors formatted with %+v that include stack traces (e.g., errors wrapped with pkg/errors)
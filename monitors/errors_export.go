@@ -0,0 +1,331 @@
+package monitors
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorExporter forwards the recorded errors to an external error tracker.
+type ErrorExporter interface {
+	// Export sends an error to the tracker. It is called in the background.
+	Export(payload *ErrorPayload) error
+}
+
+// maxConcurrentExports is the maximum number of exports in flight. The errors recorded while
+// all slots are busy are not exported, so that a flood of errors does not pile up goroutines.
+const maxConcurrentExports = 8
+
+// errorExports runs the exports of the errors in the background.
+type errorExports struct {
+	exporters []ErrorExporter
+	slots     chan struct{}
+	onFailure func(err error)
+}
+
+func newErrorExports(exporters []ErrorExporter, onFailure func(err error)) *errorExports {
+	return &errorExports{
+		exporters: exporters,
+		slots:     make(chan struct{}, maxConcurrentExports),
+		onFailure: onFailure,
+	}
+}
+
+// export sends the error to all exporters in the background.
+func (x *errorExports) export(payload *ErrorPayload) {
+	for _, exporter := range x.exporters {
+		select {
+		case x.slots <- struct{}{}:
+		default:
+			x.fail(fmt.Errorf("dropped an error to export: too many exports in flight"))
+			continue
+		}
+		go func(exporter ErrorExporter) {
+			defer func() { <-x.slots }()
+			if err := exporter.Export(payload); err != nil {
+				x.fail(err)
+			}
+		}(exporter)
+	}
+}
+
+func (x *errorExports) fail(err error) {
+	if x.onFailure != nil {
+		x.onFailure(err)
+	}
+}
+
+// postJSON posts a body to url and fails if the response is not successful.
+func postJSON(client *http.Client, url, contentType string, body []byte, header http.Header) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// SentryExporterConfig defines the config for the Sentry exporter.
+type SentryExporterConfig struct {
+	// DSN is the Data Source Name of the Sentry project, such as "https://<key>@o0.ingest.sentry.io/<project>".
+	DSN string
+	// Environment is the environment the errors are reported in, such as "staging". Optional.
+	Environment string
+	// Release is the version of the application. Optional.
+	Release string
+	// Client is the HTTP client to send the events with. Default is a client with a 10 seconds timeout.
+	Client *http.Client
+}
+
+// SentryExporter forwards the errors to Sentry as events.
+type SentryExporter struct {
+	config      SentryExporterConfig
+	envelopeURL string
+	auth        string
+}
+
+// NewSentryExporter creates an exporter that forwards the errors to the Sentry project of the DSN.
+func NewSentryExporter(config SentryExporterConfig) (*SentryExporter, error) {
+	dsn, err := url.Parse(config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	key := dsn.User.Username()
+	slash := strings.LastIndex(dsn.Path, "/")
+	if key == "" || slash < 0 || dsn.Path[slash+1:] == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: %q", config.DSN)
+	}
+	project := dsn.Path[slash+1:]
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &SentryExporter{
+		config:      config,
+		envelopeURL: fmt.Sprintf("%s://%s%s/api/%s/envelope/", dsn.Scheme, dsn.Host, dsn.Path[:slash], project),
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=echo-debugmonitor, sentry_key=%s", key),
+	}, nil
+}
+
+// sentryLevels maps the severities to the levels of Sentry.
+var sentryLevels = map[string]string{
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityCritical: "error",
+}
+
+// Export implements ErrorExporter.
+func (x *SentryExporter) Export(payload *ErrorPayload) error {
+	eventID := newEventID()
+	event := x.event(payload, eventID)
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	if err := enc.Encode(map[string]string{"event_id": eventID, "dsn": x.config.DSN}); err != nil {
+		return err
+	}
+	if err := enc.Encode(map[string]string{"type": "event"}); err != nil {
+		return err
+	}
+	if err := enc.Encode(event); err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set("X-Sentry-Auth", x.auth)
+	return postJSON(x.config.Client, x.envelopeURL, "application/x-sentry-envelope", body.Bytes(), header)
+}
+
+// event builds the Sentry event of an error.
+func (x *SentryExporter) event(payload *ErrorPayload, eventID string) map[string]any {
+	// Sentry lists the exceptions from the cause to the outermost one
+	var exceptions []map[string]any
+	if payload.Internal != nil {
+		exceptions = append(exceptions, sentryException(payload.Internal.Type, payload.Internal.Message, payload.Internal.Frames))
+	}
+	exceptions = append(exceptions, sentryException(payload.Type, payload.Message, payload.Frames))
+
+	event := map[string]any{
+		"event_id":    eventID,
+		"timestamp":   payload.Timestamp.UTC().Format(time.RFC3339Nano),
+		"level":       sentryLevels[payload.Severity],
+		"platform":    "go",
+		"logger":      "echo-debugmonitor",
+		"fingerprint": []string{payload.Fingerprint},
+		"exception":   map[string]any{"values": exceptions},
+	}
+	if x.config.Environment != "" {
+		event["environment"] = x.config.Environment
+	}
+	if x.config.Release != "" {
+		event["release"] = x.config.Release
+	}
+	if payload.Method != "" {
+		event["request"] = map[string]string{"method": payload.Method, "url": payload.URI}
+		event["transaction"] = payload.Method + " " + payload.Route
+	}
+	tags := map[string]string{}
+	if payload.RequestID != "" {
+		tags["request_id"] = payload.RequestID
+	}
+	if payload.Status != 0 {
+		tags["status_code"] = strconv.Itoa(payload.Status)
+	}
+	if len(tags) > 0 {
+		event["tags"] = tags
+	}
+	if len(payload.Frames) == 0 && payload.StackTrace != "" {
+		event["extra"] = map[string]string{"stack_trace": payload.StackTrace}
+	}
+	return event
+}
+
+func sentryException(errorType, message string, frames []StackFrame) map[string]any {
+	exception := map[string]any{"type": errorType, "value": message}
+	if len(frames) > 0 {
+		// Sentry lists the frames from the oldest call to the most recent one
+		sentryFrames := make([]map[string]any, 0, len(frames))
+		for i := len(frames) - 1; i >= 0; i-- {
+			sentryFrames = append(sentryFrames, map[string]any{
+				"function": frames[i].Function,
+				"filename": frames[i].File,
+				"lineno":   frames[i].Line,
+			})
+		}
+		exception["stacktrace"] = map[string]any{"frames": sentryFrames}
+	}
+	return exception
+}
+
+// newEventID returns a random ID of a Sentry event.
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// OTLPExporterConfig defines the config for the OTLP exporter.
+type OTLPExporterConfig struct {
+	// Endpoint is the base URL of the OTLP/HTTP receiver, such as "http://localhost:4318".
+	// The errors are posted to Endpoint + "/v1/logs".
+	Endpoint string
+	// Headers are added to the requests, such as the API key of the backend. Optional.
+	Headers map[string]string
+	// ServiceName is the service.name resource attribute. Default is "echo".
+	ServiceName string
+	// Client is the HTTP client to send the logs with. Default is a client with a 10 seconds timeout.
+	Client *http.Client
+}
+
+// OTLPExporter forwards the errors to an OpenTelemetry collector as log records, with the exception
+// and HTTP attributes of the semantic conventions, encoded as OTLP/HTTP JSON.
+type OTLPExporter struct {
+	config OTLPExporterConfig
+}
+
+// NewOTLPExporter creates an exporter that forwards the errors to an OTLP/HTTP endpoint.
+func NewOTLPExporter(config OTLPExporterConfig) *OTLPExporter {
+	if config.ServiceName == "" {
+		config.ServiceName = "echo"
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OTLPExporter{config: config}
+}
+
+// otlpSeverities maps the severities to the severity numbers and texts of OpenTelemetry.
+var otlpSeverities = map[string]struct {
+	number int
+	text   string
+}{
+	SeverityInfo:     {9, "INFO"},
+	SeverityWarning:  {13, "WARN"},
+	SeverityCritical: {17, "ERROR"},
+}
+
+// Export implements ErrorExporter.
+func (x *OTLPExporter) Export(payload *ErrorPayload) error {
+	attributes := []map[string]any{
+		otlpAttribute("exception.type", payload.Type),
+		otlpAttribute("exception.message", payload.Message),
+	}
+	stackTrace := payload.StackTrace
+	if payload.Internal != nil {
+		attributes = append(attributes,
+			otlpAttribute("exception.internal.type", payload.Internal.Type),
+			otlpAttribute("exception.internal.message", payload.Internal.Message),
+		)
+		stackTrace = payload.Internal.StackTrace
+	}
+	if stackTrace != "" {
+		attributes = append(attributes, otlpAttribute("exception.stacktrace", stackTrace))
+	}
+	if payload.Method != "" {
+		attributes = append(attributes,
+			otlpAttribute("http.request.method", payload.Method),
+			otlpAttribute("url.path", payload.URI),
+			otlpAttribute("http.route", payload.Route),
+			otlpAttribute("http.response.status_code", payload.Status),
+		)
+	}
+	if payload.RequestID != "" {
+		attributes = append(attributes, otlpAttribute("http.request.id", payload.RequestID))
+	}
+	attributes = append(attributes, otlpAttribute("error.fingerprint", payload.Fingerprint))
+
+	severity := otlpSeverities[payload.Severity]
+	record := map[string]any{
+		"timeUnixNano":   strconv.FormatInt(payload.Timestamp.UnixNano(), 10),
+		"severityNumber": severity.number,
+		"severityText":   severity.text,
+		"body":           map[string]any{"stringValue": payload.Error},
+		"attributes":     attributes,
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceLogs": []map[string]any{{
+			"resource": map[string]any{
+				"attributes": []map[string]any{otlpAttribute("service.name", x.config.ServiceName)},
+			},
+			"scopeLogs": []map[string]any{{
+				"scope":      map[string]any{"name": "github.com/kohkimakimoto/echo-debugmonitor/monitors"},
+				"logRecords": []map[string]any{record},
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	for key, value := range x.config.Headers {
+		header.Set(key, value)
+	}
+	return postJSON(x.config.Client, strings.TrimSuffix(x.config.Endpoint, "/")+"/v1/logs", "application/json", body, header)
+}
+
+// otlpAttribute returns an attribute of OTLP JSON. Integers are encoded as strings as the protocol requires.
+func otlpAttribute(key string, value any) map[string]any {
+	switch v := value.(type) {
+	case int:
+		return map[string]any{"key": key, "value": map[string]any{"intValue": strconv.Itoa(v)}}
+	default:
+		return map[string]any{"key": key, "value": map[string]any{"stringValue": fmt.Sprint(v)}}
+	}
+}
@@ -0,0 +1,161 @@
+package monitors
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrorGroup represents the aggregated occurrences of the errors that share a fingerprint.
+type ErrorGroup struct {
+	Fingerprint string    `json:"fingerprint"`
+	Type        string    `json:"type"`
+	Message     string    `json:"message"` // normalized message
+	Count       int       `json:"count"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+	Resolved    bool      `json:"resolved"`   // marked resolved and not seen since
+	ResolvedAt  time.Time `json:"resolvedAt"` // when the group was last marked resolved
+	Regression  bool      `json:"regression"` // seen again after it was marked resolved
+}
+
+// errorGroups is a sub-store of the errors monitor that maintains per-fingerprint aggregates.
+type errorGroups struct {
+	mu     sync.Mutex
+	groups map[string]*ErrorGroup
+}
+
+func newErrorGroups() *errorGroups {
+	return &errorGroups{
+		groups: make(map[string]*ErrorGroup),
+	}
+}
+
+// record adds an error to the aggregates of its fingerprint. It reports whether it is the first
+// occurrence of the fingerprint, and whether the fingerprint recurs after it was marked resolved.
+func (g *errorGroups) record(payload *ErrorPayload) (first, regression bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	group, ok := g.groups[payload.Fingerprint]
+	if !ok {
+		group = &ErrorGroup{
+			Fingerprint: payload.Fingerprint,
+			Type:        payload.Type,
+			Message:     normalizeErrorMessage(payload.Message),
+			FirstSeen:   payload.Timestamp,
+		}
+		g.groups[payload.Fingerprint] = group
+	}
+	group.Count++
+	group.LastSeen = payload.Timestamp
+	if group.Resolved {
+		group.Resolved = false
+		group.Regression = true
+		regression = true
+	}
+	return !ok, regression
+}
+
+// resolve marks the group of a fingerprint resolved. It reports whether the group exists.
+func (g *errorGroups) resolve(fingerprint string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	group, ok := g.groups[fingerprint]
+	if !ok {
+		return false
+	}
+	group.Resolved = true
+	group.ResolvedAt = now
+	return true
+}
+
+// snapshot returns the aggregates of all fingerprints, the most recently seen first.
+func (g *errorGroups) snapshot() []*ErrorGroup {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := make([]*ErrorGroup, 0, len(g.groups))
+	for _, group := range g.groups {
+		copied := *group
+		result = append(result, &copied)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].LastSeen.Equal(result[j].LastSeen) {
+			return result[i].LastSeen.After(result[j].LastSeen)
+		}
+		return result[i].Fingerprint < result[j].Fingerprint
+	})
+	return result
+}
+
+// reset removes all aggregates.
+func (g *errorGroups) reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.groups = make(map[string]*ErrorGroup)
+}
+
+// errorFingerprintFrames is the number of the top frames of the stack trace that are part of the fingerprint.
+const errorFingerprintFrames = 3
+
+var (
+	// errorMessageVariablePattern matches the parts of error messages that vary between occurrences:
+	// quoted strings, UUIDs, hexadecimal and decimal numbers.
+	errorMessageVariablePattern = regexp.MustCompile(`"[^"]*"|'[^']*'|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|0x[0-9a-fA-F]+|\d+(\.\d+)?`)
+	// stackFramePositionPattern matches the file and line of a frame in a stack trace.
+	stackFramePositionPattern = regexp.MustCompile(`[^\s]+\.go:\d+`)
+	// panicMiddlewareFile is the file of PanicRecoveryMiddleware as it appears in stack traces.
+	panicMiddlewareFile = func() string {
+		_, file, _, _ := runtime.Caller(0)
+		return path.Join(path.Dir(file), "errors_panic.go")
+	}()
+)
+
+// normalizeErrorMessage replaces the parts of an error message that vary between occurrences with "?",
+// such as `user 42 not found` with `user ? not found`.
+func normalizeErrorMessage(message string) string {
+	return errorMessageVariablePattern.ReplaceAllString(message, "?")
+}
+
+// errorFingerprint returns the fingerprint of an error, built from its type, its normalized message
+// and the file and line of the top application frames of its stack trace.
+func errorFingerprint(errorType, message, stackTrace string) string {
+	h := sha1.New()
+	h.Write([]byte(errorType))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizeErrorMessage(message)))
+	frames := 0
+	for _, frame := range stackFramePositionPattern.FindAllString(stackTrace, -1) {
+		if frames == errorFingerprintFrames {
+			break
+		}
+		if !isApplicationFrame(frame) {
+			continue
+		}
+		h.Write([]byte{0})
+		h.Write([]byte(frame))
+		frames++
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// isApplicationFrame reports whether the position of a frame is in the application, rather than in the Go runtime,
+// such as runtime/debug.Stack and runtime.gopanic, or in PanicRecoveryMiddleware, which are at the top
+// of the stacks of all the recovered panics.
+func isApplicationFrame(position string) bool {
+	file, _, _ := strings.Cut(position, ".go:")
+	file += ".go"
+	if strings.HasPrefix(file, "runtime/") || strings.Contains(file, "/src/runtime/") {
+		return false
+	}
+	return file != panicMiddlewareFile
+}
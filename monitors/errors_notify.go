@@ -0,0 +1,137 @@
+package monitors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookNotifierConfig defines the config for the webhook notifier of errors.
+type WebhookNotifierConfig struct {
+	// URL is the URL the notifications are posted to, such as a Slack incoming webhook URL.
+	URL string
+	// MinSeverity is the least severity of the errors to notify. Default is SeverityCritical.
+	MinSeverity string
+	// Throttle is the minimum interval between the notifications of the errors with the same fingerprint.
+	// The errors in the interval are counted and reported with the next notification. Default is 1 minute.
+	Throttle time.Duration
+	// Body returns the JSON body of the notification of an error. suppressed is the number of the errors
+	// with the same fingerprint that were not notified because of the throttling.
+	// Default is a Slack message: {"text": "..."}.
+	Body func(payload *ErrorPayload, suppressed int) any
+	// Client is the HTTP client to post the notifications with. Default is a client with a 10 seconds timeout.
+	Client *http.Client
+	// OnFailure is called with the error if posting a notification fails.
+	OnFailure func(err error)
+}
+
+// webhookNotifier posts the errors to a webhook, at most once per Throttle for each fingerprint.
+type webhookNotifier struct {
+	config WebhookNotifierConfig
+
+	mu         sync.Mutex
+	lastSent   map[string]time.Time
+	suppressed map[string]int
+}
+
+// NewWebhookNotifier returns an OnError hook that posts the errors to a webhook, such as a Slack incoming webhook.
+// Notifications are sent in the background, so the hook does not block the request the error occurred in.
+func NewWebhookNotifier(config WebhookNotifierConfig) func(payload *ErrorPayload) {
+	if config.MinSeverity == "" {
+		config.MinSeverity = SeverityCritical
+	}
+	if config.Throttle == 0 {
+		config.Throttle = time.Minute
+	}
+	if config.Body == nil {
+		config.Body = slackMessage
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	n := &webhookNotifier{
+		config:     config,
+		lastSent:   make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+	return n.notify
+}
+
+func (n *webhookNotifier) notify(payload *ErrorPayload) {
+	if severityRanks[payload.Severity] < severityRanks[n.config.MinSeverity] {
+		return
+	}
+	suppressed, ok := n.take(payload.Fingerprint, payload.Timestamp)
+	if !ok {
+		return
+	}
+	body := n.config.Body(payload, suppressed)
+	go n.post(body)
+}
+
+// take reports whether an error with the fingerprint may be notified at now, along with the number of
+// the errors with the fingerprint suppressed since the last notification. If it may not, the error is
+// counted as suppressed.
+func (n *webhookNotifier) take(fingerprint string, now time.Time) (int, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if last, ok := n.lastSent[fingerprint]; ok && now.Sub(last) < n.config.Throttle {
+		n.suppressed[fingerprint]++
+		return 0, false
+	}
+	suppressed := n.suppressed[fingerprint]
+	delete(n.suppressed, fingerprint)
+	n.lastSent[fingerprint] = now
+
+	// Forget the fingerprints that are no longer throttled so that the maps do not grow without bound
+	for fp, last := range n.lastSent {
+		if now.Sub(last) >= n.config.Throttle && n.suppressed[fp] == 0 && fp != fingerprint {
+			delete(n.lastSent, fp)
+		}
+	}
+	return suppressed, true
+}
+
+func (n *webhookNotifier) post(body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		n.fail(err)
+		return
+	}
+	resp, err := n.config.Client.Post(n.config.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		n.fail(err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		n.fail(fmt.Errorf("webhook responded with status %d", resp.StatusCode))
+	}
+}
+
+func (n *webhookNotifier) fail(err error) {
+	if n.config.OnFailure != nil {
+		n.config.OnFailure(err)
+	}
+}
+
+// slackMessage is the default body of the webhook notifications, which is a Slack message.
+func slackMessage(payload *ErrorPayload, suppressed int) any {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*[%s] %s*: %s", payload.Severity, payload.Type, payload.Message)
+	if payload.Method != "" {
+		fmt.Fprintf(&b, "\n%d %s %s", payload.Status, payload.Method, payload.URI)
+	}
+	if payload.RequestID != "" {
+		fmt.Fprintf(&b, "\nrequest %s", payload.RequestID)
+	}
+	if suppressed > 0 {
+		fmt.Fprintf(&b, "\n(%d more since the last notification)", suppressed)
+	}
+	return map[string]string{"text": b.String()}
+}
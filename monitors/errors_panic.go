@@ -0,0 +1,69 @@
+package monitors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PanicError is the error recorded for a panic recovered by PanicRecoveryMiddleware.
+type PanicError struct {
+	// Value is the value the handler panicked with.
+	Value any
+	// Stack is the stack of the goroutine that panicked.
+	Stack string
+
+	recorded bool // whether the panic has been recorded by the middleware
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the value the handler panicked with if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StackTrace returns the stack of the goroutine that panicked.
+func (e *PanicError) StackTrace() string {
+	return e.Stack
+}
+
+// PanicRecoveryMiddleware returns a middleware that recovers from panics in the handlers, records them
+// with the stack of the goroutine and the request in the errors monitor, and responds with 500.
+// Use it instead of echo's Recover middleware, after the requests monitor middleware so that the panic
+// is recorded with the request. The 500 error is returned to the error handler with the panic as its
+// internal error, and is not recorded again by HTTPErrorHandlerWrapper.
+// Like echo's Recover middleware, it does not recover from http.ErrAbortHandler.
+func PanicRecoveryMiddleware(recorder ErrorRecorder) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (returnErr error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				pe := &PanicError{Value: r, Stack: string(debug.Stack())}
+				recorder.recordUnhandled(c, pe)
+				pe.recorded = true
+				returnErr = echo.NewHTTPError(http.StatusInternalServerError).SetInternal(pe)
+			}()
+			return next(c)
+		}
+	}
+}
+
+// isRecordedPanic reports whether err is a panic already recorded by PanicRecoveryMiddleware.
+func isRecordedPanic(err error) bool {
+	var pe *PanicError
+	return errors.As(err, &pe) && pe.recorded
+}
@@ -0,0 +1,67 @@
+package monitors
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errorRateWindow is the window the error rate is measured in.
+const errorRateWindow = time.Minute
+
+// ErrorRateAlert represents an alert raised when the number of errors in the last minute exceeds the threshold.
+type ErrorRateAlert struct {
+	Count     int `json:"count"`     // number of errors in the last minute
+	Threshold int `json:"threshold"` // configured AlertThreshold
+}
+
+// errorRate counts the errors in a sliding window and raises an alert when the count exceeds the threshold.
+// Once raised, no alert is raised again until the count falls back to the threshold.
+type errorRate struct {
+	mu        sync.Mutex
+	threshold int
+	times     []time.Time
+	alerting  bool
+}
+
+func newErrorRate(threshold int) *errorRate {
+	return &errorRate{threshold: threshold}
+}
+
+// record counts an error that occurred at now. It returns an alert if the count exceeds the threshold
+// for the first time since it was last at or below the threshold, otherwise nil.
+func (r *errorRate) record(now time.Time) *ErrorRateAlert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.times = append(r.times, now)
+	expired := 0
+	for expired < len(r.times) && now.Sub(r.times[expired]) >= errorRateWindow {
+		expired++
+	}
+	r.times = r.times[expired:]
+
+	count := len(r.times)
+	if count <= r.threshold {
+		r.alerting = false
+		return nil
+	}
+	if r.alerting {
+		return nil
+	}
+	r.alerting = true
+	return &ErrorRateAlert{Count: count, Threshold: r.threshold}
+}
+
+// newErrorRateAlertPayload returns the entry of the errors monitor that represents an alert.
+func newErrorRateAlertPayload(alert *ErrorRateAlert, now time.Time) *ErrorPayload {
+	message := fmt.Sprintf("%d errors in the last minute exceeds the threshold of %d", alert.Count, alert.Threshold)
+	return &ErrorPayload{
+		Error:     message,
+		Type:      "ErrorRateAlert",
+		Message:   message,
+		Timestamp: now,
+		Severity:  SeverityCritical,
+		Alert:     alert,
+	}
+}
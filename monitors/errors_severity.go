@@ -0,0 +1,66 @@
+package monitors
+
+import (
+	"fmt"
+)
+
+// Severities of errors, from the least to the most severe.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// severityRanks orders the severities for the minimum severity filter.
+var severityRanks = map[string]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityCritical: 3,
+}
+
+// SeverityRule classifies the errors that match all of its conditions as Severity.
+// A condition with the zero value is not checked, so a rule without conditions matches all errors.
+type SeverityRule struct {
+	// MinStatus and MaxStatus match the errors responded with a status in the inclusive range.
+	// Errors recorded outside of a request have no status and never match the range.
+	MinStatus int
+	MaxStatus int
+	// Type matches the errors whose type, as formatted with %T, is Type, such as "*url.Error".
+	Type string
+	// Match matches the errors for which it returns true.
+	Match func(err error) bool
+	// Severity is the severity of the matched errors: SeverityInfo, SeverityWarning or SeverityCritical.
+	Severity string
+}
+
+// DefaultSeverityRules classifies client errors (4xx) as warnings.
+// Errors that match no rule are critical.
+var DefaultSeverityRules = []SeverityRule{
+	{MinStatus: 400, MaxStatus: 499, Severity: SeverityWarning},
+}
+
+// matches reports whether the rule matches err responded with status.
+func (r SeverityRule) matches(err error, status int) bool {
+	if r.MinStatus != 0 || r.MaxStatus != 0 {
+		if status == 0 || (r.MinStatus != 0 && status < r.MinStatus) || (r.MaxStatus != 0 && status > r.MaxStatus) {
+			return false
+		}
+	}
+	if r.Type != "" && fmt.Sprintf("%T", err) != r.Type {
+		return false
+	}
+	if r.Match != nil && !r.Match(err) {
+		return false
+	}
+	return true
+}
+
+// classifySeverity returns the severity of the first rule that matches err, or SeverityCritical.
+func classifySeverity(rules []SeverityRule, err error, status int) string {
+	for _, rule := range rules {
+		if rule.matches(err, status) {
+			return rule.Severity
+		}
+	}
+	return SeverityCritical
+}
@@ -0,0 +1,86 @@
+package monitors
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// StackFrame represents a frame of the stack trace of an error.
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// extractStackFrames returns the frames of the stack trace of the deepest error in the chain of err
+// that has one, which is where the error originated. It supports the StackTrace methods that return
+// the program counters of the frames:
+//  1. StackTrace() errors.StackTrace of github.com/pkg/errors, or any slice of uintptr-based frames
+//  2. StackTrace() *runtime.Frames
+//
+// It returns nil if no error in the chain has such a stack trace.
+func extractStackFrames(err error) []StackFrame {
+	var frames []StackFrame
+	for ; err != nil; err = errors.Unwrap(err) {
+		if f := stackFramesOf(err); f != nil {
+			frames = f
+		}
+	}
+	return frames
+}
+
+// stackFramesOf returns the frames of the stack trace of err itself, or nil if it has none.
+func stackFramesOf(err error) []StackFrame {
+	if st, ok := err.(interface{ StackTrace() *runtime.Frames }); ok {
+		return framesFromRuntime(st.StackTrace())
+	}
+
+	// pkg/errors returns a slice of frames, each of which is a program counter as returned by runtime.Callers.
+	// It is matched by reflection so as not to depend on the package.
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil
+	}
+	t := method.Type()
+	if t.NumIn() != 0 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Slice || t.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	v := method.Call(nil)[0]
+	if v.Len() == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, v.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(v.Index(i).Uint())
+	}
+	return framesFromRuntime(runtime.CallersFrames(pcs))
+}
+
+// framesFromRuntime converts runtime frames to stack frames.
+func framesFromRuntime(frames *runtime.Frames) []StackFrame {
+	if frames == nil {
+		return nil
+	}
+	var result []StackFrame
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			result = append(result, StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			return result
+		}
+	}
+}
+
+// formatStackFrames formats stack frames like the goroutine stacks of Go.
+func formatStackFrames(frames []StackFrame) string {
+	var b strings.Builder
+	for _, frame := range frames {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return b.String()
+}
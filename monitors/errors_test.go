@@ -0,0 +1,621 @@
+package monitors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+func TestPanicRecoveryMiddleware(t *testing.T) {
+	m := debugmonitor.New()
+	requestsMonitor, requestsMiddleware := NewRequestsMonitor(nil)
+	m.AddMonitor(requestsMonitor)
+	errorsMonitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{})
+	m.AddMonitor(errorsMonitor)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandlerWrapper(recorder, e.HTTPErrorHandler)
+	e.Use(requestsMiddleware, PanicRecoveryMiddleware(recorder))
+	e.GET("/panic", func(c echo.Context) error {
+		var items []string
+		_ = items[3]
+		return nil
+	})
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", rec.Code)
+	}
+
+	var errorPayloads []*ErrorPayload
+	var requestPayload *RequestPayload
+	for requestPayload == nil {
+		switch payload := (<-sub.C).Entry.Payload.(type) {
+		case *ErrorPayload:
+			errorPayloads = append(errorPayloads, payload)
+		case *RequestPayload:
+			requestPayload = payload
+		}
+	}
+	if len(errorPayloads) != 1 {
+		t.Fatalf("Expected the panic to be recorded once, got %d errors", len(errorPayloads))
+	}
+	payload := errorPayloads[0]
+	if !strings.Contains(payload.Message, "index out of range") {
+		t.Errorf("Unexpected message: %s", payload.Message)
+	}
+	if !strings.Contains(payload.StackTrace, "errors_test.go") {
+		t.Errorf("Expected the stack of the handler, got %s", payload.StackTrace)
+	}
+	if payload.RequestID == "" || payload.RequestID != requestPayload.RequestID {
+		t.Errorf("Expected the panic to be recorded with the request ID %q, got %q", requestPayload.RequestID, payload.RequestID)
+	}
+}
+
+func TestErrorsMonitor_Groups(t *testing.T) {
+	for _, deduplicate := range []bool{false, true} {
+		m := debugmonitor.New()
+		monitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{Deduplicate: deduplicate})
+		m.AddMonitor(monitor)
+
+		for i := 0; i < 3; i++ {
+			recorder(fmt.Errorf("user %d not found", 40+i))
+		}
+		recorder(errors.New("connection refused"))
+
+		e := echo.New()
+		e.GET("/monitor", m.Handler())
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=errors&action=groups&format=json", nil))
+		var groups []*ErrorGroup
+		if err := json.Unmarshal(rec.Body.Bytes(), &groups); err != nil {
+			t.Fatal(err)
+		}
+		counts := map[string]int{}
+		for _, group := range groups {
+			counts[group.Message] = group.Count
+		}
+		if len(groups) != 2 || counts["user ? not found"] != 3 || counts["connection refused"] != 1 {
+			t.Errorf("Unexpected groups: %v", counts)
+		}
+
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=errors&action=data", nil))
+		var entries []json.RawMessage
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatal(err)
+		}
+		want := 4
+		if deduplicate {
+			want = 2
+		}
+		if len(entries) != want {
+			t.Errorf("deduplicate=%v: expected %d entries, got %d", deduplicate, want, len(entries))
+		}
+	}
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	stack := "main.handler()\n\t/app/main.go:10 +0x1d\nmain.main()\n\t/app/main.go:20 +0x25\n"
+	if errorFingerprint("*errors.errorString", "id 1", stack) != errorFingerprint("*errors.errorString", "id 2", stack) {
+		t.Errorf("Expected messages that differ in numbers to share a fingerprint")
+	}
+	if errorFingerprint("*errors.errorString", "id 1", stack) == errorFingerprint("*errors.errorString", "id 1", strings.Replace(stack, ":10", ":11", 1)) {
+		t.Errorf("Expected errors raised at different places to have different fingerprints")
+	}
+}
+
+func TestErrorsMonitor_PanicFingerprint(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{Deduplicate: true})
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandlerWrapper(recorder, e.HTTPErrorHandler)
+	e.Use(PanicRecoveryMiddleware(recorder))
+	// Two handlers that panic with the same message
+	e.GET("/a", func(c echo.Context) error {
+		var p *RequestPayload
+		return c.String(http.StatusOK, p.Method)
+	})
+	e.GET("/c", func(c echo.Context) error {
+		var p *RequestPayload
+		return c.String(http.StatusOK, p.URI)
+	})
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	var fingerprints []string
+	for _, path := range []string{"/a", "/c"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		select {
+		case event := <-sub.C:
+			fingerprints = append(fingerprints, event.Entry.Payload.(*ErrorPayload).Fingerprint)
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for the panic of %s", path)
+		}
+	}
+	if fingerprints[0] == fingerprints[1] {
+		t.Errorf("Expected the panics of different handlers to have different fingerprints, got %s", fingerprints[0])
+	}
+}
+
+// stackError mimics an error of github.com/pkg/errors, whose StackTrace method returns program counters.
+type stackError struct {
+	msg   string
+	stack stackFrames
+}
+
+type stackFrame uintptr
+
+type stackFrames []stackFrame
+
+func newStackError(msg string) error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	stack := make(stackFrames, n)
+	for i, pc := range pcs[:n] {
+		stack[i] = stackFrame(pc)
+	}
+	return &stackError{msg: msg, stack: stack}
+}
+
+func (e *stackError) Error() string           { return e.msg }
+func (e *stackError) StackTrace() stackFrames { return e.stack }
+
+func TestErrorsMonitor_StackFrames(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	recorder(fmt.Errorf("wrapped: %w", newStackError("boom")))
+
+	payload := (<-sub.C).Entry.Payload.(*ErrorPayload)
+	if len(payload.Frames) == 0 {
+		t.Fatalf("Expected structured frames, got none")
+	}
+	top := payload.Frames[0]
+	if !strings.HasSuffix(top.Function, "TestErrorsMonitor_StackFrames") {
+		t.Errorf("Expected the top frame to be the test function, got %q", top.Function)
+	}
+	if !strings.HasSuffix(top.File, "errors_test.go") || top.Line == 0 {
+		t.Errorf("Expected the top frame to point to errors_test.go, got %s:%d", top.File, top.Line)
+	}
+	if !strings.Contains(payload.StackTrace, top.Function+"\n\t"+top.File) {
+		t.Errorf("Expected the stack trace text to be built from the frames, got %q", payload.StackTrace)
+	}
+}
+
+func TestErrorsMonitor_Severity(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{
+		SeverityRules: append([]SeverityRule{
+			{Match: func(err error) bool { return errors.Is(err, context.Canceled) }, Severity: SeverityInfo},
+		}, DefaultSeverityRules...),
+	})
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandlerWrapper(recorder, e.HTTPErrorHandler)
+	e.GET("/monitor", m.Handler())
+	e.GET("/missing", func(c echo.Context) error { return echo.ErrNotFound })
+	e.GET("/canceled", func(c echo.Context) error { return fmt.Errorf("query: %w", context.Canceled) })
+	e.GET("/broken", func(c echo.Context) error { return errors.New("broken") })
+	for _, path := range []string{"/missing", "/canceled", "/broken"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	severities := func(query string) map[string]string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=errors&action=data"+query, nil))
+		var entries []struct {
+			Payload ErrorPayload `json:"payload"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatal(err)
+		}
+		result := map[string]string{}
+		for _, entry := range entries {
+			result[entry.Payload.URI] = entry.Payload.Severity
+		}
+		return result
+	}
+
+	all := severities("")
+	if all["/missing"] != SeverityWarning || all["/canceled"] != SeverityInfo || all["/broken"] != SeverityCritical {
+		t.Errorf("Unexpected severities: %v", all)
+	}
+	if filtered := severities("&severity=warning"); len(filtered) != 2 || filtered["/canceled"] != "" {
+		t.Errorf("Expected warning and critical errors, got %v", filtered)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	bodies := make(chan map[string]string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		bodies <- body
+	}))
+	defer server.Close()
+
+	m := debugmonitor.New()
+	monitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{
+		OnError: NewWebhookNotifier(WebhookNotifierConfig{
+			URL:       server.URL,
+			Throttle:  100 * time.Millisecond,
+			OnFailure: func(err error) { t.Error(err) },
+		}),
+	})
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/users", nil), httptest.NewRecorder())
+	recorder.RecordWithContext(c, echo.ErrNotFound)
+	for i := 0; i < 3; i++ {
+		recorder(errors.New("database is down"))
+	}
+
+	body := <-bodies
+	if !strings.Contains(body["text"], "[critical]") || !strings.Contains(body["text"], "database is down") {
+		t.Errorf("Unexpected notification: %q", body["text"])
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	recorder(errors.New("database is down"))
+	body = <-bodies
+	if !strings.Contains(body["text"], "(2 more since the last notification)") {
+		t.Errorf("Expected the throttled errors to be counted, got %q", body["text"])
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("Unexpected notification: %q", body["text"])
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestErrorsMonitor_Resolve(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{Deduplicate: true})
+	m.AddMonitor(monitor)
+	e := echo.New()
+	e.Any("/monitor", m.Handler())
+
+	recorder(errors.New("cache miss for key 1"))
+	recorder(errors.New("cache miss for key 2"))
+
+	getGroups := func() []*ErrorGroup {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=errors&action=groups&format=json", nil))
+		var groups []*ErrorGroup
+		if err := json.Unmarshal(rec.Body.Bytes(), &groups); err != nil {
+			t.Fatal(err)
+		}
+		return groups
+	}
+	resolve := func(fingerprint string) int {
+		req := httptest.NewRequest(http.MethodPost, "/monitor?monitor=errors&action=resolve&fingerprint="+fingerprint, nil)
+		req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+		req.Header.Set("X-CSRF-Token", "test-token")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	groups := getGroups()
+	if len(groups) != 1 {
+		t.Fatalf("Expected 1 group, got %d", len(groups))
+	}
+	if code := resolve("unknown"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown group, got %d", code)
+	}
+	if code := resolve(groups[0].Fingerprint); code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", code)
+	}
+	if groups := getGroups(); !groups[0].Resolved || groups[0].Regression {
+		t.Errorf("Expected the group to be resolved, got %+v", groups[0])
+	}
+
+	recorder(errors.New("cache miss for key 3"))
+
+	if groups := getGroups(); groups[0].Resolved || !groups[0].Regression || groups[0].Count != 3 {
+		t.Errorf("Expected the group to be a regression, got %+v", groups[0])
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=errors&action=data", nil))
+	var entries []struct {
+		Payload ErrorPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Payload.Regression || !entries[1].Payload.Regression {
+		t.Errorf("Expected the recurred error to be added as a regression despite deduplication, got %+v", entries)
+	}
+}
+
+func TestErrorsMonitor_RateAlert(t *testing.T) {
+	m := debugmonitor.New()
+	var alerts []*ErrorRateAlert
+	monitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{
+		AlertThreshold: 2,
+		OnAlert:        func(alert *ErrorRateAlert) { alerts = append(alerts, alert) },
+	})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	for i := 0; i < 4; i++ {
+		recorder(errors.New("upstream unavailable"))
+	}
+
+	var alertPayloads []*ErrorPayload
+	for i := 0; i < 5; i++ {
+		if payload := (<-sub.C).Entry.Payload.(*ErrorPayload); payload.Alert != nil {
+			alertPayloads = append(alertPayloads, payload)
+		}
+	}
+	if len(alertPayloads) != 1 || alertPayloads[0].Alert.Count != 3 || alertPayloads[0].Severity != SeverityCritical {
+		t.Errorf("Expected one alert entry when the third error exceeds the threshold, got %+v", alertPayloads)
+	}
+	if len(alerts) != 1 || alerts[0].Threshold != 2 {
+		t.Errorf("Expected OnAlert to be called once, got %+v", alerts)
+	}
+}
+
+func TestErrorRate_Window(t *testing.T) {
+	r := newErrorRate(1)
+	now := time.Now()
+	if r.record(now) != nil {
+		t.Errorf("Expected no alert at the threshold")
+	}
+	if r.record(now.Add(time.Second)) == nil {
+		t.Errorf("Expected an alert above the threshold")
+	}
+	if r.record(now.Add(2*time.Second)) != nil {
+		t.Errorf("Expected no alert while already alerting")
+	}
+	if r.record(now.Add(3*time.Minute)) != nil {
+		t.Errorf("Expected the errors out of the window to expire")
+	}
+	if r.record(now.Add(3*time.Minute+time.Second)) == nil {
+		t.Errorf("Expected an alert again after the rate fell back")
+	}
+}
+
+func TestErrorsMonitor_HTTPErrorInternal(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	recorder(echo.NewHTTPError(http.StatusBadGateway, "payment service is unavailable").SetInternal(newStackError("dial tcp: connection refused")))
+	recorder(echo.NewHTTPError(http.StatusBadGateway, "payment service is unavailable").SetInternal(errors.New("tls: handshake failure")))
+
+	first := (<-sub.C).Entry.Payload.(*ErrorPayload)
+	second := (<-sub.C).Entry.Payload.(*ErrorPayload)
+	if first.Message != "payment service is unavailable" {
+		t.Errorf("Expected the HTTP-facing message, got %q", first.Message)
+	}
+	if first.Internal == nil || first.Internal.Type != "*monitors.stackError" || first.Internal.Message != "dial tcp: connection refused" {
+		t.Fatalf("Expected the internal error to be recorded, got %+v", first.Internal)
+	}
+	if len(first.Internal.Frames) == 0 || first.Internal.StackTrace == "" {
+		t.Errorf("Expected the stack trace of the internal error to be extracted")
+	}
+	if first.StackTrace != "" || first.Frames != nil {
+		t.Errorf("Expected the stack trace to be recorded only on the internal error")
+	}
+	if first.Fingerprint == second.Fingerprint {
+		t.Errorf("Expected HTTP errors with different internal errors to have different fingerprints")
+	}
+}
+
+func TestErrorsMonitor_Exporters(t *testing.T) {
+	type request struct {
+		path   string
+		header http.Header
+		body   string
+	}
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{path: r.URL.Path, header: r.Header, body: string(body)}
+	}))
+	defer server.Close()
+
+	sentry, err := NewSentryExporter(SentryExporterConfig{
+		DSN:         strings.Replace(server.URL, "http://", "http://public-key@", 1) + "/42",
+		Environment: "staging",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSentryExporter(SentryExporterConfig{DSN: server.URL}); err == nil {
+		t.Errorf("Expected an error for a DSN without a key and a project")
+	}
+
+	_, recorder := NewErrorsMonitor(ErrorsMonitorConfig{
+		Exporters:       []ErrorExporter{sentry, NewOTLPExporter(OTLPExporterConfig{Endpoint: server.URL, ServiceName: "shop"})},
+		OnExportFailure: func(err error) { t.Error(err) },
+	})
+
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/orders/1", nil), httptest.NewRecorder())
+	c.SetPath("/orders/:id")
+	recorder.RecordWithContext(c, newStackError("order not found"))
+
+	got := map[string]request{}
+	for i := 0; i < 2; i++ {
+		r := <-requests
+		got[r.path] = r
+	}
+
+	r, ok := got["/api/42/envelope/"]
+	if !ok {
+		t.Fatalf("Expected an envelope posted to Sentry, got %v", got)
+	}
+	if !strings.Contains(r.header.Get("X-Sentry-Auth"), "sentry_key=public-key") {
+		t.Errorf("Unexpected auth header: %q", r.header.Get("X-Sentry-Auth"))
+	}
+	lines := strings.Split(strings.TrimSpace(r.body), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected an envelope of 3 lines, got %q", r.body)
+	}
+	var event struct {
+		Environment string `json:"environment"`
+		Request     struct {
+			Method string `json:"method"`
+		} `json:"request"`
+		Exception struct {
+			Values []struct {
+				Type       string `json:"type"`
+				Stacktrace struct {
+					Frames []map[string]any `json:"frames"`
+				} `json:"stacktrace"`
+			} `json:"values"`
+		} `json:"exception"`
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Environment != "staging" || event.Request.Method != http.MethodGet || len(event.Exception.Values) != 1 || len(event.Exception.Values[0].Stacktrace.Frames) == 0 {
+		t.Errorf("Unexpected Sentry event: %s", lines[2])
+	}
+
+	r, ok = got["/v1/logs"]
+	if !ok {
+		t.Fatalf("Expected logs posted to the OTLP endpoint, got %v", got)
+	}
+	for _, want := range []string{`"service.name"`, `"shop"`, `"exception.stacktrace"`, `"http.route"`, `"/orders/:id"`, `"severityText":"ERROR"`} {
+		if !strings.Contains(r.body, want) {
+			t.Errorf("Expected the OTLP body to contain %s, got %s", want, r.body)
+		}
+	}
+}
+
+func TestHTTPErrorHandlerWrapper_RequestContext(t *testing.T) {
+	m := debugmonitor.New()
+	errorsMonitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{})
+	m.AddMonitor(errorsMonitor)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandlerWrapper(recorder, e.HTTPErrorHandler)
+	e.GET("/users/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	})
+	e.GET("/handled", func(c echo.Context) error {
+		recorder.RecordWithContext(c, errors.New("cache unavailable"))
+		return c.NoContent(http.StatusOK)
+	})
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42?expand=1", nil))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/handled", nil))
+
+	payload := (<-sub.C).Entry.Payload.(*ErrorPayload)
+	if payload.Method != http.MethodGet || payload.URI != "/users/42?expand=1" || payload.Route != "/users/:id" || payload.Status != http.StatusNotFound {
+		t.Errorf("Unexpected request context: %+v", payload)
+	}
+	payload = (<-sub.C).Entry.Payload.(*ErrorPayload)
+	if payload.Message != "cache unavailable" || payload.Route != "/handled" || payload.Status != http.StatusInternalServerError {
+		t.Errorf("Unexpected request context of a handled error: %+v", payload)
+	}
+}
+
+func TestHTTPErrorHandlerWrapper_ProductionSafeURI(t *testing.T) {
+	m := debugmonitor.NewProductionSafe()
+	var recorded *ErrorPayload
+	errorsMonitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{
+		OnError: func(payload *ErrorPayload) { recorded = payload },
+	})
+	m.AddMonitor(errorsMonitor)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandlerWrapper(recorder, e.HTTPErrorHandler)
+	e.GET("/reset", func(c echo.Context) error {
+		return errors.New("invalid token")
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reset?token=secret", nil))
+	if recorded == nil {
+		t.Fatal("Expected the error to be recorded")
+	}
+	if strings.Contains(recorded.URI, "secret") || !strings.HasPrefix(recorded.URI, "/reset?token=") {
+		t.Errorf("Expected the query values to be redacted, got %q", recorded.URI)
+	}
+}
+
+func TestErrorsMonitor_Origin(t *testing.T) {
+	m := debugmonitor.New()
+	errorsMonitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{})
+	m.AddMonitor(errorsMonitor)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandlerWrapper(recorder, e.HTTPErrorHandler)
+	e.Use(PanicRecoveryMiddleware(recorder))
+	e.GET("/monitor", m.Handler())
+	e.GET("/unhandled", func(c echo.Context) error { return errors.New("unhandled") })
+	e.GET("/panic", func(c echo.Context) error { panic("panicked") })
+	e.GET("/handled", func(c echo.Context) error {
+		recorder.RecordWithContext(c, errors.New("handled in request"))
+		return c.NoContent(http.StatusOK)
+	})
+	recorder(errors.New("handled in background"))
+	for _, path := range []string{"/unhandled", "/panic", "/handled"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	origins := func(query string) map[string]string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=errors&action=data"+query, nil))
+		var entries []struct {
+			Payload ErrorPayload `json:"payload"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatal(err)
+		}
+		result := map[string]string{}
+		for _, entry := range entries {
+			result[entry.Payload.Message] = entry.Payload.Origin
+		}
+		return result
+	}
+
+	want := map[string]string{
+		"unhandled":             OriginUnhandled,
+		"panic: panicked":       OriginUnhandled,
+		"handled in request":    OriginHandled,
+		"handled in background": OriginHandled,
+	}
+	if got := origins(""); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected origins %v, got %v", want, got)
+	}
+	if got := origins("&origin=unhandled"); len(got) != 2 || got["unhandled"] == "" || got["panic: panicked"] == "" {
+		t.Errorf("Expected only the unhandled errors, got %v", got)
+	}
+}
@@ -0,0 +1,161 @@
+package monitors
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// EventPayload represents the data structure for application event monitoring
+type EventPayload struct {
+	RequestID     string    `json:"requestId,omitempty"` // ID of the request the event was fired in
+	Topic         string    `json:"topic"`
+	Data          string    `json:"data,omitempty"` // JSON encoding of the event data
+	DataTruncated bool      `json:"dataTruncated,omitempty"`
+	Caller        string    `json:"caller,omitempty"` // file:line of the application code that fired the event
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// TimelineRequestID implements debugmonitor.TimelinePayload.
+func (p *EventPayload) TimelineRequestID() string { return p.RequestID }
+
+// TimelineTime implements debugmonitor.TimelinePayload.
+func (p *EventPayload) TimelineTime() time.Time { return p.Timestamp }
+
+// TimelineSummary implements debugmonitor.TimelinePayload.
+func (p *EventPayload) TimelineSummary() string { return "event " + p.Topic }
+
+// EventRecorder records the events of an in-process event bus or message publications to the events monitor.
+// Call it from the publish function of the bus. Pass the context of the request the event is fired in
+// to link the event to the request.
+type EventRecorder func(ctx context.Context, topic string, data any)
+
+// Record records an event that does not belong to any request.
+func (r EventRecorder) Record(topic string, data any) {
+	r(context.Background(), topic, data)
+}
+
+// RecordWithContext records an event fired with ctx, such as the context of an HTTP request.
+func (r EventRecorder) RecordWithContext(ctx context.Context, topic string, data any) {
+	r(ctx, topic, data)
+}
+
+// EventsMonitorConfig defines the config for Events monitor.
+type EventsMonitorConfig struct {
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+	// MaxDataSize is the maximum number of bytes of the JSON encoding of the event data to capture.
+	// Optional. Default: DefaultMaxBodySize
+	MaxDataSize int
+	// CaptureCaller enables recording the file and line of the application code that fired each event.
+	CaptureCaller bool
+}
+
+//go:embed events.html
+var eventsView string
+
+// eventsViewTemplate is the parsed template for the events view
+var eventsViewTemplate = template.Must(template.New("eventsView").Funcs(debugmonitor.TemplateFuncs).Parse(eventsView))
+
+// NewEventsMonitor creates a new monitor for application events and returns
+// the monitor along with an event recording function.
+// In production-safe mode, only the topics of the events are recorded.
+func NewEventsMonitor(config EventsMonitorConfig) (*debugmonitor.Monitor, EventRecorder) {
+	if config.MaxDataSize <= 0 {
+		config.MaxDataSize = DefaultMaxBodySize
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "events",
+		DisplayName: "Events",
+		MaxRecords:  1000,
+		Icon:        debugmonitor.IconArrowsRightLeft,
+		Group:       "Application",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, eventsViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStreamWithFilter(c, store, eventsFilter(c))
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSONWithFilter(c, store, eventsFilter(c))
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPollWithFilter(c, store, eventsFilter(c))
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	recorder := func(ctx context.Context, topic string, data any) {
+		payload := &EventPayload{
+			RequestID: debugmonitor.RequestIDFromContext(ctx),
+			Topic:     topic,
+			Timestamp: time.Now(),
+		}
+		if config.CaptureCaller {
+			payload.Caller = eventCaller()
+		}
+
+		if data != nil && !m.IsProductionSafe() {
+			// The data is encoded when the event is fired, so that later changes to it are not reflected
+			var encoded string
+			if b, err := json.Marshal(data); err == nil {
+				encoded = string(b)
+			} else {
+				encoded = fmt.Sprintf("%+v", data)
+			}
+			if len(encoded) > config.MaxDataSize {
+				encoded = encoded[:config.MaxDataSize]
+				payload.DataTruncated = true
+			}
+			payload.Data = encoded
+		}
+
+		m.Add(payload)
+	}
+
+	return m, recorder
+}
+
+// eventsFilter returns the filter of the events given by the "topic" query parameter, or nil if it is not given.
+func eventsFilter(c echo.Context) debugmonitor.EntryFilter {
+	topic := c.QueryParam("topic")
+	if topic == "" {
+		return nil
+	}
+	return func(entry *debugmonitor.DataEntry) bool {
+		payload, ok := entry.Payload.(*EventPayload)
+		return ok && payload.Topic == topic
+	}
+}
+
+// eventCallerSkipPrefixes are the prefixes of the functions that are skipped to find the code that fired an event:
+// the runtime and the event recorder of this package.
+var eventCallerSkipPrefixes = []string{
+	"runtime.",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.EventRecorder.",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.NewEventsMonitor.",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.eventCaller",
+}
+
+// eventCaller returns the file:line of the first frame on the stack that is not in the runtime or
+// the event recorder. It returns an empty string if there is none.
+func eventCaller() string {
+	return callerSkipping(eventCallerSkipPrefixes)
+}
@@ -0,0 +1,60 @@
+package monitors
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+func TestEventsMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, events := NewEventsMonitor(EventsMonitorConfig{CaptureCaller: true, MaxDataSize: 32})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	type orderPlaced struct {
+		OrderID int    `json:"orderId"`
+		Email   string `json:"email"`
+	}
+	data := &orderPlaced{OrderID: 42, Email: "alice@example.com"}
+	events.RecordWithContext(debugmonitor.ContextWithRequestID(context.Background(), "req-1"), "order.placed", data)
+	// Changes after the event is fired are not reflected
+	data.OrderID = 43
+
+	payload := (<-sub.C).Entry.Payload.(*EventPayload)
+	if payload.Topic != "order.placed" || payload.RequestID != "req-1" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if payload.Data != `{"orderId":42,"email":"alice@exa` || !payload.DataTruncated {
+		t.Errorf("Expected the truncated data as of the event, got %q", payload.Data)
+	}
+	if !strings.Contains(payload.Caller, "events_test.go") {
+		t.Errorf("Expected the caller to be the test, got %q", payload.Caller)
+	}
+
+	events.Record("cache.cleared", nil)
+	payload = (<-sub.C).Entry.Payload.(*EventPayload)
+	if payload.RequestID != "" || payload.Data != "" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if got := m.Timeline("req-1"); len(got) != 1 || got[0].Summary != "event order.placed" {
+		t.Errorf("Expected the event in the timeline of the request, got %+v", got)
+	}
+}
+
+func TestEventsMonitorProductionSafe(t *testing.T) {
+	m := debugmonitor.NewProductionSafe()
+	monitor, events := NewEventsMonitor(EventsMonitorConfig{})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	events.Record("user.signed_up", map[string]string{"email": "alice@example.com"})
+	payload := (<-sub.C).Entry.Payload.(*EventPayload)
+	if payload.Topic != "user.signed_up" || payload.Data != "" {
+		t.Errorf("Expected only the topic in production-safe mode, got %+v", payload)
+	}
+}
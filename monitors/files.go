@@ -0,0 +1,267 @@
+package monitors
+
+import (
+	_ "embed"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// FilePayload represents the data structure for file I/O monitoring
+type FilePayload struct {
+	Op        string    `json:"op"` // one of the FileOp constants
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`     // bytes read or written, or the number of directory entries
+	Duration  float64   `json:"duration"` // in milliseconds
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// File operations recorded by the files monitor.
+const (
+	// FileOpOpen is the opening of a file. Its duration is the time Open took.
+	FileOpOpen = "open"
+	// FileOpRead is the reading of an opened file, recorded when it is closed.
+	// Its duration is the time the file was open.
+	FileOpRead = "read"
+	// FileOpWrite is the writing of a file, recorded when it is closed.
+	// Its duration is the time the file was open.
+	FileOpWrite = "write"
+	// FileOpReadFile is the reading of a whole file with fs.ReadFile.
+	FileOpReadFile = "readfile"
+	// FileOpStat is a call of fs.Stat.
+	FileOpStat = "stat"
+	// FileOpReadDir is a call of fs.ReadDir.
+	FileOpReadDir = "readdir"
+)
+
+// FileRecorder records file operations to the files monitor.
+// Call it directly to record the operations of file systems that cannot be wrapped with FS or Writer.
+// An afero.Fs can be wrapped with the aferomonitor module.
+type FileRecorder func(op string, path string, size int64, duration time.Duration, err error)
+
+// FS returns a file system that records the operations on fsys: opening, reading, stat and reading directories.
+// The reads of an opened file are recorded as one entry when the file is closed.
+// Use it in place of fsys, such as for embedded templates or http.FS.
+func (r FileRecorder) FS(fsys fs.FS) fs.FS {
+	return &monitoredFS{fsys: fsys, recorder: r}
+}
+
+// Writer returns an io.WriteCloser that writes to w and records the bytes written when it is closed.
+// Wrap a file opened for writing with it, such as the *os.File returned by os.Create.
+func (r FileRecorder) Writer(path string, w io.WriteCloser) io.WriteCloser {
+	return &monitoredWriter{w: w, path: path, recorder: r, opened: time.Now()}
+}
+
+// monitoredFS wraps an fs.FS and records the operations on it.
+// It implements the optional interfaces of fs.FS so that fs.ReadFile, fs.Stat and fs.ReadDir are recorded
+// as one operation each.
+type monitoredFS struct {
+	fsys     fs.FS
+	recorder FileRecorder
+}
+
+func (m *monitoredFS) Open(name string) (fs.File, error) {
+	start := time.Now()
+	f, err := m.fsys.Open(name)
+	m.recorder(FileOpOpen, name, 0, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	return &monitoredFile{file: f, path: name, recorder: m.recorder, opened: start}, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (m *monitoredFS) ReadFile(name string) ([]byte, error) {
+	start := time.Now()
+	data, err := fs.ReadFile(m.fsys, name)
+	m.recorder(FileOpReadFile, name, int64(len(data)), time.Since(start), err)
+	return data, err
+}
+
+// Stat implements fs.StatFS.
+func (m *monitoredFS) Stat(name string) (fs.FileInfo, error) {
+	start := time.Now()
+	info, err := fs.Stat(m.fsys, name)
+	m.recorder(FileOpStat, name, 0, time.Since(start), err)
+	return info, err
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (m *monitoredFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	start := time.Now()
+	entries, err := fs.ReadDir(m.fsys, name)
+	m.recorder(FileOpReadDir, name, int64(len(entries)), time.Since(start), err)
+	return entries, err
+}
+
+// monitoredFile counts the bytes read from a file opened through monitoredFS and records them when it is closed.
+// It passes io.Seeker, io.ReaderAt and fs.ReadDirFile through to the wrapped file, so that it can be served by http.FS.
+type monitoredFile struct {
+	file     fs.File
+	path     string
+	recorder FileRecorder
+	opened   time.Time
+	read     int64
+	err      error
+}
+
+func (f *monitoredFile) Stat() (fs.FileInfo, error) {
+	return f.file.Stat()
+}
+
+func (f *monitoredFile) Read(p []byte) (int, error) {
+	n, err := f.file.Read(p)
+	f.read += int64(n)
+	if err != nil && err != io.EOF && f.err == nil {
+		f.err = err
+	}
+	return n, err
+}
+
+func (f *monitoredFile) ReadAt(p []byte, off int64) (int, error) {
+	ra, ok := f.file.(io.ReaderAt)
+	if !ok {
+		return 0, errors.New("monitors: file does not implement io.ReaderAt")
+	}
+	n, err := ra.ReadAt(p, off)
+	f.read += int64(n)
+	return n, err
+}
+
+func (f *monitoredFile) Seek(offset int64, whence int) (int64, error) {
+	s, ok := f.file.(io.Seeker)
+	if !ok {
+		return 0, errors.New("monitors: file does not implement io.Seeker")
+	}
+	return s.Seek(offset, whence)
+}
+
+func (f *monitoredFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	d, ok := f.file.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: f.path, Err: errors.New("not implemented")}
+	}
+	return d.ReadDir(n)
+}
+
+func (f *monitoredFile) Close() error {
+	err := f.file.Close()
+	f.recorder(FileOpRead, f.path, f.read, time.Since(f.opened), f.err)
+	return err
+}
+
+// monitoredWriter counts the bytes written to a file and records them when it is closed.
+type monitoredWriter struct {
+	w        io.WriteCloser
+	path     string
+	recorder FileRecorder
+	opened   time.Time
+	written  int64
+	err      error
+}
+
+func (w *monitoredWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *monitoredWriter) Close() error {
+	err := w.w.Close()
+	recorded := w.err
+	if recorded == nil {
+		recorded = err
+	}
+	w.recorder(FileOpWrite, w.path, w.written, time.Since(w.opened), recorded)
+	return err
+}
+
+// FilesMonitorConfig defines the config for Files monitor.
+type FilesMonitorConfig struct {
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+	// SkipOpen disables recording the opening of files, which are followed by a read entry when they are closed.
+	SkipOpen bool
+}
+
+//go:embed files.html
+var filesView string
+
+// filesViewTemplate is the parsed template for the files view
+var filesViewTemplate = template.Must(template.New("filesView").Funcs(debugmonitor.TemplateFuncs).Parse(filesView))
+
+// NewFilesMonitor creates a new monitor for file I/O and returns
+// the monitor along with a file operation recording function.
+func NewFilesMonitor(config FilesMonitorConfig) (*debugmonitor.Monitor, FileRecorder) {
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "files",
+		DisplayName: "File I/O",
+		MaxRecords:  1000,
+		Icon:        debugmonitor.IconDocumentText,
+		Group:       "Runtime",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, filesViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStreamWithFilter(c, store, filesFilter(c))
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSONWithFilter(c, store, filesFilter(c))
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPollWithFilter(c, store, filesFilter(c))
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	recorder := func(op string, path string, size int64, duration time.Duration, err error) {
+		if op == FileOpOpen && config.SkipOpen && err == nil {
+			return
+		}
+		payload := &FilePayload{
+			Op:        op,
+			Path:      path,
+			Size:      size,
+			Duration:  float64(duration) / float64(time.Millisecond),
+			Timestamp: time.Now(),
+		}
+		if err != nil {
+			payload.Error = err.Error()
+		}
+		m.Add(payload)
+	}
+
+	return m, recorder
+}
+
+// filesFilter returns the filter of the file operations given by the "op" query parameter,
+// or nil if it is not given.
+func filesFilter(c echo.Context) debugmonitor.EntryFilter {
+	op := c.QueryParam("op")
+	if op == "" {
+		return nil
+	}
+	return func(entry *debugmonitor.DataEntry) bool {
+		payload, ok := entry.Payload.(*FilePayload)
+		return ok && payload.Op == op
+	}
+}
@@ -0,0 +1,88 @@
+package monitors
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+func TestFilesMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, recorder := NewFilesMonitor(FilesMonitorConfig{SkipOpen: true})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	fsys := recorder.FS(fstest.MapFS{
+		"templates/page.html":  {Data: []byte("<h1>hello</h1>")},
+		"templates/empty.html": {Data: []byte{}},
+	})
+
+	next := func() *FilePayload {
+		return (<-sub.C).Entry.Payload.(*FilePayload)
+	}
+
+	if data, err := fs.ReadFile(fsys, "templates/page.html"); err != nil || string(data) != "<h1>hello</h1>" {
+		t.Fatalf("Unexpected read: %q, %v", data, err)
+	}
+	if p := next(); p.Op != FileOpReadFile || p.Path != "templates/page.html" || p.Size != 14 {
+		t.Errorf("Unexpected payload: %+v", p)
+	}
+
+	if entries, err := fs.ReadDir(fsys, "templates"); err != nil || len(entries) != 2 {
+		t.Fatalf("Unexpected directory: %v, %v", entries, err)
+	}
+	if p := next(); p.Op != FileOpReadDir || p.Size != 2 {
+		t.Errorf("Unexpected payload: %+v", p)
+	}
+
+	// A file opened through the wrapper is recorded when it is closed. Successful opens are skipped.
+	f, err := fsys.Open("templates/page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.ReadAll(f)
+	_ = f.Close()
+	if p := next(); p.Op != FileOpRead || p.Size != 14 {
+		t.Errorf("Unexpected payload: %+v", p)
+	}
+
+	if _, err := fsys.Open("missing.html"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if p := next(); p.Op != FileOpOpen || p.Error == "" {
+		t.Errorf("Expected the failed open, got %+v", p)
+	}
+
+	// The wrapper can be served by http.FS, which needs io.Seeker
+	rec := httptest.NewRecorder()
+	http.FileServer(http.FS(fsys)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/templates/page.html", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "<h1>hello</h1>" {
+		t.Errorf("Expected the file to be served, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "out.txt")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := recorder.Writer(path, file)
+	_, _ = w.Write([]byte("hello world"))
+	_ = w.Close()
+	// Skip the entries of http.FS
+	for {
+		if p := next(); p.Op == FileOpWrite {
+			if p.Path != path || p.Size != 11 {
+				t.Errorf("Unexpected payload: %+v", p)
+			}
+			break
+		}
+	}
+}
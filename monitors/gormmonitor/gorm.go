@@ -0,0 +1,29 @@
+// Package gormmonitor records the queries run through GORM in the queries monitor of echo-debugmonitor.
+// It is a separate module so that the monitors package does not depend on GORM.
+package gormmonitor
+
+import (
+	"database/sql"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+	"gorm.io/gorm"
+)
+
+// NewGormQueriesMonitor creates a queries monitor for the database of config, as monitors.NewQueriesMonitor does,
+// and returns the monitor along with a *gorm.DB on the monitored connection. dialector creates the dialector
+// of the database on the connection, so that GORM does not open its own:
+//
+//	queriesMonitor, db, err := gormmonitor.NewGormQueriesMonitor(monitors.QueriesMonitorConfig{Driver: &pq.Driver{}, DSN: dsn},
+//		func(conn *sql.DB) gorm.Dialector { return postgres.New(postgres.Config{Conn: conn}) }, &gorm.Config{})
+//
+// Pass the context of the request with db.WithContext(c.Request().Context()) to correlate the queries to the request.
+func NewGormQueriesMonitor(config monitors.QueriesMonitorConfig, dialector func(conn *sql.DB) gorm.Dialector, opts ...gorm.Option) (*debugmonitor.Monitor, *gorm.DB, error) {
+	monitor, conn := monitors.NewQueriesMonitor(config)
+	db, err := gorm.Open(dialector(conn), opts...)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	return monitor, db, nil
+}
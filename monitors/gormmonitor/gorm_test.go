@@ -0,0 +1,57 @@
+package gormmonitor
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+	gormsqlite "gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"modernc.org/sqlite"
+)
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func TestNewGormQueriesMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db, err := NewGormQueriesMonitor(monitors.QueriesMonitorConfig{Driver: &sqlite.Driver{}, DSN: ":memory:"},
+		func(conn *sql.DB) gorm.Dialector {
+			conn.SetMaxOpenConns(1)
+			return gormsqlite.Dialector{Conn: conn}
+		}, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.AddMonitor(monitor)
+	if err := db.AutoMigrate(&user{}); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	if err := db.Create(&user{Name: "alice"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-sub.C:
+			payload := event.Entry.Payload.(*monitors.QueryPayload)
+			if strings.HasPrefix(payload.Query, "INSERT INTO `users`") {
+				if payload.Table != "users" {
+					t.Errorf("Unexpected payload: %+v", payload)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("Expected the insert to be recorded")
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package monitors
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"html/template"
+	"io/fs"
+	"math"
+	"net/http"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// GoroutinesPayload represents a sample of the goroutine and scheduler metrics of the process
+type GoroutinesPayload struct {
+	NumGoroutine int       `json:"numGoroutine"`
+	Delta        int       `json:"delta"` // change of NumGoroutine since the previous sample
+	GOMAXPROCS   int       `json:"gomaxprocs"`
+	NumCgoCall   int64     `json:"numCgoCall"`
+	Timestamp    time.Time `json:"timestamp"`
+	// SchedLatencyP50 and SchedLatencyP99 are the percentiles of the time goroutines spent runnable
+	// before running, since the process started, in milliseconds.
+	SchedLatencyP50 float64 `json:"schedLatencyP50"`
+	SchedLatencyP99 float64 `json:"schedLatencyP99"`
+}
+
+// DefaultGoroutinesSampleInterval is the default interval of the goroutines samples.
+const DefaultGoroutinesSampleInterval = 5 * time.Second
+
+// GoroutinesMonitorConfig defines the config for Goroutines monitor.
+type GoroutinesMonitorConfig struct {
+	// Interval is the interval of the samples.
+	// Optional. Default: DefaultGoroutinesSampleInterval
+	Interval time.Duration
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+	// EnableDump enables the dump action, which returns the stacks of all goroutines.
+	// It is never available in production-safe mode.
+	EnableDump bool
+}
+
+//go:embed goroutines.html
+var goroutinesView string
+
+// goroutinesViewTemplate is the parsed template for the goroutines view
+var goroutinesViewTemplate = template.Must(template.New("goroutinesView").Funcs(debugmonitor.TemplateFuncs).Parse(goroutinesView))
+
+// GoroutinesMonitor is a plugin that samples the goroutine and scheduler metrics in the background.
+// Add it with debugmonitor.Manager.AddPlugin, which starts the sampler and stops it when the manager is closed.
+type GoroutinesMonitor struct {
+	monitor  *debugmonitor.Monitor
+	interval time.Duration
+	previous int
+}
+
+// NewGoroutinesMonitor creates a new monitor for goroutines.
+func NewGoroutinesMonitor(config GoroutinesMonitorConfig) *GoroutinesMonitor {
+	if config.Interval <= 0 {
+		config.Interval = DefaultGoroutinesSampleInterval
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "goroutines",
+		DisplayName: "Goroutines",
+		MaxRecords:  720,
+		Icon:        debugmonitor.IconCpuChip,
+		Group:       "Runtime",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, goroutinesViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+					"EnableDump":      config.EnableDump && !m.IsProductionSafe(),
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStream(c, store)
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSON(c, store)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPoll(c, store)
+			case "dump":
+				return handleGoroutineDump(c, m, &config)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	return &GoroutinesMonitor{
+		monitor:  m,
+		interval: config.Interval,
+	}
+}
+
+// Monitor implements debugmonitor.MonitorPlugin.
+func (g *GoroutinesMonitor) Monitor() *debugmonitor.Monitor {
+	return g.monitor
+}
+
+// Assets implements debugmonitor.MonitorPlugin.
+func (g *GoroutinesMonitor) Assets() fs.FS {
+	return nil
+}
+
+// Run implements debugmonitor.MonitorPluginRunner. It records a sample at each interval until ctx is canceled.
+func (g *GoroutinesMonitor) Run(ctx context.Context) {
+	g.sample()
+
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.sample()
+		}
+	}
+}
+
+// sample records the current metrics.
+func (g *GoroutinesMonitor) sample() {
+	payload := &GoroutinesPayload{
+		NumGoroutine: runtime.NumGoroutine(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumCgoCall:   runtime.NumCgoCall(),
+		Timestamp:    time.Now(),
+	}
+	if g.previous > 0 {
+		payload.Delta = payload.NumGoroutine - g.previous
+	}
+	g.previous = payload.NumGoroutine
+
+	samples := []metrics.Sample{{Name: "/sched/latencies:seconds"}}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() == metrics.KindFloat64Histogram {
+		h := samples[0].Value.Float64Histogram()
+		payload.SchedLatencyP50 = histogramPercentile(h, 0.5) * 1000
+		payload.SchedLatencyP99 = histogramPercentile(h, 0.99) * 1000
+	}
+
+	g.monitor.Add(payload)
+}
+
+// histogramPercentile returns the upper bound of the bucket the percentile p (between 0 and 1) falls into.
+// Infinite bounds are replaced with the finite bound of the bucket.
+func histogramPercentile(h *metrics.Float64Histogram, p float64) float64 {
+	var total uint64
+	for _, count := range h.Counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	threshold := uint64(math.Ceil(float64(total) * p))
+	var cumulative uint64
+	for i, count := range h.Counts {
+		cumulative += count
+		if cumulative >= threshold {
+			// Bucket i spans h.Buckets[i] to h.Buckets[i+1]
+			if upper := h.Buckets[i+1]; !math.IsInf(upper, 0) {
+				return upper
+			}
+			return h.Buckets[i]
+		}
+	}
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+// handleGoroutineDump returns the stacks of all goroutines as text, in the format of a panic.
+func handleGoroutineDump(c echo.Context, m *debugmonitor.Monitor, config *GoroutinesMonitorConfig) error {
+	if c.Request().Method != http.MethodPost {
+		return echo.NewHTTPError(http.StatusMethodNotAllowed)
+	}
+	if !config.EnableDump || m.IsProductionSafe() {
+		return echo.NewHTTPError(http.StatusForbidden, "goroutine dump is disabled")
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		return err
+	}
+	return c.String(http.StatusOK, buf.String())
+}
@@ -0,0 +1,60 @@
+package monitors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+func TestGoroutinesMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	defer m.Close()
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	m.AddPlugin(NewGoroutinesMonitor(GoroutinesMonitorConfig{Interval: 10 * time.Millisecond, EnableDump: true}))
+
+	next := func() *GoroutinesPayload {
+		select {
+		case event := <-sub.C:
+			return event.Entry.Payload.(*GoroutinesPayload)
+		case <-time.After(time.Second):
+			t.Fatal("Expected a sample")
+			return nil
+		}
+	}
+
+	first := next()
+	if first.NumGoroutine == 0 || first.GOMAXPROCS == 0 {
+		t.Errorf("Unexpected sample: %+v", first)
+	}
+
+	// The change is relative to the previous sample
+	second := next()
+	if second.Delta != second.NumGoroutine-first.NumGoroutine {
+		t.Errorf("Expected the change from %d to %d, got %d", first.NumGoroutine, second.NumGoroutine, second.Delta)
+	}
+
+	e := echo.New()
+	e.Any("/monitor", m.Handler())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=goroutines&action=dump", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/monitor?monitor=goroutines&action=dump", nil)
+	req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+	req.Header.Set("X-CSRF-Token", "test-token")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "TestGoroutinesMonitor") {
+		t.Errorf("Expected a dump of the goroutine stacks, got %d: %.200s", rec.Code, rec.Body.String())
+	}
+}
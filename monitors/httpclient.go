@@ -0,0 +1,341 @@
+package monitors
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// HTTPClientPayload represents the data structure for outgoing HTTP request monitoring
+type HTTPClientPayload struct {
+	RequestID string            `json:"requestId,omitempty"` // ID of the inbound request the call was made in
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Host      string            `json:"host"`
+	Status    int               `json:"status,omitempty"`  // zero if no response was received
+	Latency   int64             `json:"latency"`           // until the response headers were received, in milliseconds
+	Attempt   int               `json:"attempt,omitempty"` // attempt number given by ContextWithRetryAttempt
+	Error     string            `json:"error,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"` // request headers
+	Timestamp time.Time         `json:"timestamp"`
+	// ContentType is the Content-Type header of the response.
+	ContentType string `json:"contentType,omitempty"`
+	// RequestSize is the Content-Length of the request body, or -1 if it is unknown.
+	RequestSize int64 `json:"requestSize"`
+	// ResponseSize is the Content-Length of the response body, or -1 if it is unknown.
+	ResponseSize int64 `json:"responseSize"`
+	// RequestBody is the captured request body. It is only set when CaptureRequestBody is enabled.
+	RequestBody string `json:"requestBody,omitempty"`
+	// RequestBodyTruncated reports whether the captured request body was cut at MaxBodySize.
+	RequestBodyTruncated bool `json:"requestBodyTruncated,omitempty"`
+	// ResponseBody is the captured response body. It is only set when CaptureResponseBody is enabled.
+	ResponseBody string `json:"responseBody,omitempty"`
+	// ResponseBodyTruncated reports whether the captured response body was cut at MaxBodySize.
+	ResponseBodyTruncated bool `json:"responseBodyTruncated,omitempty"`
+}
+
+// TimelineRequestID implements debugmonitor.TimelinePayload.
+func (p *HTTPClientPayload) TimelineRequestID() string { return p.RequestID }
+
+// TimelineTime implements debugmonitor.TimelinePayload.
+func (p *HTTPClientPayload) TimelineTime() time.Time { return p.Timestamp }
+
+// TimelineSummary implements debugmonitor.TimelinePayload.
+func (p *HTTPClientPayload) TimelineSummary() string {
+	if p.Error != "" {
+		return fmt.Sprintf("%s %s failed: %s (%dms)", p.Method, p.URL, p.Error, p.Latency)
+	}
+	return fmt.Sprintf("%s %s %d (%dms)", p.Method, p.URL, p.Status, p.Latency)
+}
+
+// HTTPClientMonitorConfig defines the config for HTTP client monitor.
+type HTTPClientMonitorConfig struct {
+	// Transport is the RoundTripper that sends the requests.
+	// Optional. Default: http.DefaultTransport
+	Transport http.RoundTripper
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+	// IgnoreHeaders are the names of the request headers not to capture.
+	// Optional. Default: DefaultIgnoreHeaders
+	IgnoreHeaders []string
+	// CaptureRequestBody enables capturing the request body.
+	// Bodies are never captured in production-safe mode.
+	CaptureRequestBody bool
+	// CaptureResponseBody enables capturing the response body as the caller reads it.
+	// The entry is recorded when the caller closes the body or reads it to the end.
+	// Bodies are never captured in production-safe mode.
+	CaptureResponseBody bool
+	// MaxBodySize is the maximum number of bytes of a body to capture.
+	// Optional. Default: DefaultMaxBodySize
+	MaxBodySize int
+	// BodyContentTypes are the content types of bodies to capture.
+	// An entry ending with "/" matches any subtype, such as "text/".
+	// Optional. Default: DefaultBodyContentTypes
+	BodyContentTypes []string
+	// RedactBodyFields are the names of JSON and form fields whose values are redacted in captured bodies.
+	RedactBodyFields []string
+}
+
+//go:embed httpclient.html
+var httpClientView string
+
+// httpClientViewTemplate is the parsed template for the HTTP client view
+var httpClientViewTemplate = template.Must(template.New("httpClientView").Funcs(debugmonitor.TemplateFuncs).Parse(httpClientView))
+
+// NewHTTPClientMonitor creates a new monitor for outgoing HTTP requests and returns
+// the monitor along with an http.RoundTripper that records the requests sent through it.
+// Set it as the Transport of the http.Client used to call third-party APIs.
+// Pass the context of the inbound request to the outgoing requests to link them to it.
+func NewHTTPClientMonitor(config *HTTPClientMonitorConfig) (*debugmonitor.Monitor, http.RoundTripper) {
+	// Defaults
+	if config == nil {
+		config = &HTTPClientMonitorConfig{}
+	}
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+	if config.IgnoreHeaders == nil {
+		config.IgnoreHeaders = DefaultIgnoreHeaders
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultMaxBodySize
+	}
+	if config.BodyContentTypes == nil {
+		config.BodyContentTypes = DefaultBodyContentTypes
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "httpclient",
+		DisplayName: "HTTP Client",
+		MaxRecords:  1000,
+		Icon:        debugmonitor.IconArrowsRightLeft,
+		Group:       "HTTP",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, httpClientViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStream(c, store)
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSON(c, store)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPoll(c, store)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	return m, &httpClientTransport{
+		monitor: m,
+		config:  config,
+	}
+}
+
+// retryAttemptKey is the context key of the retry attempt number.
+type retryAttemptKey struct{}
+
+// ContextWithRetryAttempt returns a copy of ctx carrying the attempt number of a request, starting at 1.
+// Retry loops use it for the requests they send so that the HTTP client monitor shows the retries.
+func ContextWithRetryAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, retryAttemptKey{}, attempt)
+}
+
+// httpClientTransport is the http.RoundTripper that records the requests to the HTTP client monitor.
+type httpClientTransport struct {
+	monitor *debugmonitor.Monitor
+	config  *HTTPClientMonitorConfig
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *httpClientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	productionSafe := t.monitor.IsProductionSafe()
+
+	payload := &HTTPClientPayload{
+		RequestID:    debugmonitor.RequestIDFromContext(req.Context()),
+		Method:       req.Method,
+		URL:          req.URL.String(),
+		Host:         req.URL.Host,
+		Headers:      make(map[string]string),
+		Timestamp:    start,
+		RequestSize:  req.ContentLength,
+		ResponseSize: -1,
+	}
+	if payload.Method == "" {
+		payload.Method = http.MethodGet
+	}
+	if attempt, ok := req.Context().Value(retryAttemptKey{}).(int); ok {
+		payload.Attempt = attempt
+	}
+	for key, values := range req.Header {
+		if containsFold(t.config.IgnoreHeaders, key) || len(values) == 0 {
+			continue
+		}
+		payload.Headers[key] = values[0]
+	}
+	if productionSafe {
+		payload.URL = redactURI(payload.URL)
+		for key := range payload.Headers {
+			if !productionSafeHeaders[key] {
+				payload.Headers[key] = redacted
+			}
+		}
+	}
+
+	// Capture the request body. A copy is read with GetBody if the request can provide one,
+	// otherwise the body is captured as the transport sends it.
+	var requestBody *httpClientRequestBody
+	requestContentType := req.Header.Get(echo.HeaderContentType)
+	if t.config.CaptureRequestBody && !productionSafe && req.Body != nil && req.Body != http.NoBody &&
+		matchContentType(requestContentType, t.config.BodyContentTypes) {
+		if req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				requestBody = &httpClientRequestBody{ReadCloser: body, buf: &bodyBuffer{limit: t.config.MaxBodySize}}
+				_, _ = io.Copy(io.Discard, io.LimitReader(requestBody, int64(t.config.MaxBodySize)+1))
+				requestBody.Close()
+			}
+		} else {
+			requestBody = &httpClientRequestBody{ReadCloser: req.Body, buf: &bodyBuffer{limit: t.config.MaxBodySize}}
+			req = req.Clone(req.Context())
+			req.Body = requestBody
+		}
+	}
+	// The request body is read when the entry is recorded, which may be before the transport finishes sending it
+	recordRequestBody := func() {
+		if requestBody == nil {
+			return
+		}
+		body, truncated := requestBody.captured()
+		payload.RequestBody = redactBody(body, requestContentType, t.config.RedactBodyFields)
+		payload.RequestBodyTruncated = truncated
+	}
+
+	resp, err := t.config.Transport.RoundTrip(req)
+	payload.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		payload.Error = err.Error()
+		recordRequestBody()
+		t.monitor.Add(payload)
+		return resp, err
+	}
+
+	payload.Status = resp.StatusCode
+	payload.ContentType = resp.Header.Get(echo.HeaderContentType)
+	payload.ResponseSize = resp.ContentLength
+
+	if t.config.CaptureResponseBody && !productionSafe && resp.Body != nil && resp.Body != http.NoBody &&
+		matchContentType(payload.ContentType, t.config.BodyContentTypes) {
+		// Record the entry once the caller is done with the body
+		resp.Body = &httpClientResponseBody{
+			ReadCloser:        resp.Body,
+			buf:               &bodyBuffer{limit: t.config.MaxBodySize},
+			encoding:          resp.Header.Get(echo.HeaderContentEncoding),
+			payload:           payload,
+			transport:         t,
+			recordRequestBody: recordRequestBody,
+		}
+		return resp, nil
+	}
+
+	recordRequestBody()
+	t.monitor.Add(payload)
+	return resp, nil
+}
+
+// httpClientRequestBody captures a request body as the transport reads it. The transport may read it on another
+// goroutine, even after RoundTrip returns, so the captured body is guarded by a mutex.
+type httpClientRequestBody struct {
+	io.ReadCloser
+	mu   sync.Mutex
+	buf  *bodyBuffer
+	done bool // whether the body was read to the end or closed
+}
+
+func (b *httpClientRequestBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > 0 {
+		b.buf.Write(p[:n])
+	}
+	if err != nil {
+		b.done = true
+	}
+	return n, err
+}
+
+func (b *httpClientRequestBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = true
+	return err
+}
+
+// captured returns the body captured so far, and whether it is incomplete because it was cut at the maximum
+// body size or the transport has not finished sending it.
+func (b *httpClientRequestBody) captured() (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.buf.String(), b.buf.truncated || !b.done
+}
+
+// httpClientResponseBody captures a response body as the caller reads it and records the entry
+// when the caller reads it to the end or closes it.
+type httpClientResponseBody struct {
+	io.ReadCloser
+	buf       *bodyBuffer
+	encoding  string
+	payload   *HTTPClientPayload
+	transport *httpClientTransport
+	once      sync.Once
+
+	recordRequestBody func()
+}
+
+func (b *httpClientResponseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.buf.Write(p[:n])
+	}
+	if err != nil {
+		b.record()
+	}
+	return n, err
+}
+
+func (b *httpClientResponseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.record()
+	return err
+}
+
+func (b *httpClientResponseBody) record() {
+	b.once.Do(func() {
+		config := b.transport.config
+		body, truncated, ok := decodeBody(b.buf.buf.Bytes(), b.encoding, config.MaxBodySize)
+		if ok {
+			b.payload.ResponseBody = redactBody(body, b.payload.ContentType, config.RedactBodyFields)
+			b.payload.ResponseBodyTruncated = truncated || b.buf.truncated
+		}
+		b.recordRequestBody()
+		b.transport.monitor.Add(b.payload)
+	})
+}
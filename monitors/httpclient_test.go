@@ -0,0 +1,123 @@
+package monitors
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+func TestHTTPClientMonitor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token":"secret","echo":` + string(body) + `}`))
+	}))
+	defer server.Close()
+
+	m := debugmonitor.New()
+	monitor, transport := NewHTTPClientMonitor(&HTTPClientMonitorConfig{
+		CaptureRequestBody:  true,
+		CaptureResponseBody: true,
+		RedactBodyFields:    []string{"token"},
+	})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+	client := &http.Client{Transport: transport}
+
+	ctx := ContextWithRetryAttempt(debugmonitor.ContextWithRequestID(t.Context(), "req-1"), 2)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/items?page=1", strings.NewReader(`{"name":"pen"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `"secret"`) {
+		t.Errorf("Expected the caller to read the original response body, got %s", body)
+	}
+
+	payload := (<-sub.C).Entry.Payload.(*HTTPClientPayload)
+	if payload.Method != http.MethodPost || payload.URL != server.URL+"/items?page=1" || payload.Status != http.StatusOK {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if payload.RequestID != "req-1" || payload.Attempt != 2 {
+		t.Errorf("Expected the request ID and the attempt from the context, got %q and %d", payload.RequestID, payload.Attempt)
+	}
+	if _, ok := payload.Headers["Authorization"]; ok {
+		t.Errorf("Expected the Authorization header not to be captured")
+	}
+	if payload.RequestBody != `{"name":"pen"}` {
+		t.Errorf("Unexpected request body: %q", payload.RequestBody)
+	}
+	if payload.ResponseBody != `{"echo":{"name":"pen"},"token":"[REDACTED]"}` {
+		t.Errorf("Unexpected response body: %q", payload.ResponseBody)
+	}
+
+	resp, err = client.Get(server.URL + "/fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	payload = (<-sub.C).Entry.Payload.(*HTTPClientPayload)
+	if payload.Status != http.StatusServiceUnavailable || payload.ResponseBody != "" {
+		t.Errorf("Expected the failed response without a body of an uncaptured content type, got %+v", payload)
+	}
+
+	_, err = client.Get("http://127.0.0.1:1/unreachable")
+	if err == nil {
+		t.Fatal("Expected an error for an unreachable host")
+	}
+	payload = (<-sub.C).Entry.Payload.(*HTTPClientPayload)
+	if payload.Error == "" || payload.Status != 0 {
+		t.Errorf("Expected the transport error to be recorded, got %+v", payload)
+	}
+}
+
+func TestHTTPClientMonitor_StreamedRequestBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	m := debugmonitor.New()
+	monitor, transport := NewHTTPClientMonitor(&HTTPClientMonitorConfig{CaptureRequestBody: true})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+	client := &http.Client{Transport: transport}
+
+	// A body without GetBody is captured as the transport sends it
+	req, _ := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader(`{"name":"pen"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	if req.GetBody != nil {
+		t.Fatal("Expected a request without GetBody")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	select {
+	case event := <-sub.C:
+		payload := event.Entry.Payload.(*HTTPClientPayload)
+		if payload.RequestBody != `{"name":"pen"}` || payload.RequestBodyTruncated {
+			t.Errorf("Unexpected request body: %q (truncated: %v)", payload.RequestBody, payload.RequestBodyTruncated)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for the request")
+	}
+}
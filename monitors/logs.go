@@ -2,10 +2,12 @@ package monitors
 
 import (
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
@@ -15,21 +17,45 @@ import (
 
 // LogPayload represents the data structure for log monitoring
 type LogPayload struct {
-	Level     string    `json:"level"`
-	Message   string    `json:"message"`
-	Timestamp time.Time `json:"timestamp"`
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	Timestamp time.Time      `json:"timestamp"`
+	RequestID string         `json:"requestId,omitempty"` // ID of the request the message was logged in
+	Caller    string         `json:"caller,omitempty"`    // file:line of the code that logged the message
+	Fields    map[string]any `json:"fields,omitempty"`    // structured fields of the message
 }
 
+// TimelineRequestID implements debugmonitor.TimelinePayload.
+func (p *LogPayload) TimelineRequestID() string { return p.RequestID }
+
+// TimelineTime implements debugmonitor.TimelinePayload.
+func (p *LogPayload) TimelineTime() time.Time { return p.Timestamp }
+
+// TimelineSummary implements debugmonitor.TimelinePayload.
+func (p *LogPayload) TimelineSummary() string { return p.Level + ": " + p.Message }
+
 //go:embed logs.html
 var logsView string
 
 // logsViewTemplate is the parsed template for the logs view
-var logsViewTemplate = template.Must(template.New("logsView").Parse(logsView))
+var logsViewTemplate = template.Must(template.New("logsView").Funcs(debugmonitor.TemplateFuncs).Parse(logsView))
+
+//go:embed logs_stats.html
+var logStatsView string
+
+// logStatsViewTemplate is the parsed template for the per-minute throughput view
+var logStatsViewTemplate = template.Must(template.New("logStatsView").Funcs(debugmonitor.TemplateFuncs).Parse(logStatsView))
+
+// logStatsLevels are the levels shown in the throughput view, in order of severity.
+var logStatsLevels = []string{"DEBUG", "INFO", "WARN", "ERROR", "PANIC", "FATAL", "PRINT"}
 
 // LoggerWrapper wraps an echo.Logger and intercepts all logging calls
 type LoggerWrapper struct {
-	original echo.Logger
-	monitor  *debugmonitor.Monitor
+	original   echo.Logger
+	monitor    *debugmonitor.Monitor
+	throughput *logThroughput
+	minRank    int    // rank in logLevelRanks below which messages are not recorded
+	requestID  string // ID of the request the logger is bound to by WithContext
 }
 
 // LogsMonitorConfig defines the config for Logs monitor.
@@ -38,28 +64,72 @@ type LogsMonitorConfig struct {
 	Logger echo.Logger
 	// UsePolling enables polling mode instead of SSE for real-time updates.
 	UsePolling bool
+	// MinLevel is the minimum level of the messages recorded in the monitor, such as "INFO",
+	// independent of the level of the logger. PRINT messages have no level and are always recorded,
+	// as the logger always outputs them. It does not apply to JSONLogWriter and Record, whose entries are
+	// filtered by the level of the logging library.
+	// Optional. Default: all levels are recorded.
+	MinLevel string
+	// EnableLevelControl enables the level action, which changes the level of the logger at runtime,
+	// such as to DEBUG while reproducing an issue. It is never available in production-safe mode.
+	EnableLevelControl bool
 }
 
 // NewLogsMonitor creates a new monitor for logging and returns
 // the monitor along with a wrapped logger
-func NewLogsMonitor(config LogsMonitorConfig) (*debugmonitor.Monitor, echo.Logger) {
-	m := &debugmonitor.Monitor{
+func NewLogsMonitor(config LogsMonitorConfig) (*debugmonitor.Monitor, *LoggerWrapper) {
+	// throughput holds the per-minute counts of the entries recorded by the logger and its JSON log writers.
+	throughput := &logThroughput{}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
 		Name:        "logs",
 		DisplayName: "Logs",
 		MaxRecords:  1000,
 		Icon:        debugmonitor.IconDocumentText,
+		Group:       "Application",
 		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
 			switch action {
 			case "render":
 				return debugmonitor.RenderTemplate(c, logsViewTemplate, map[string]any{
-					"UsePolling": config.UsePolling,
+					"UsePolling":         config.UsePolling,
+					"PollingInterval":    m.Settings().PollingInterval,
+					"EnableLevelControl": config.EnableLevelControl && !m.IsProductionSafe(),
+					"LoggerLevel":        loggerLevelNames[config.Logger.Level()],
 				})
 			case "stream":
 				// SSE endpoint for real-time updates
-				return debugmonitor.HandleSSEStream(c, store)
+				return debugmonitor.HandleSSEStreamWithFilter(c, store, logsFilter(c))
 			case "data":
 				// JSON endpoint for polling mode
-				return debugmonitor.HandleDataJSON(c, store)
+				return debugmonitor.HandleDataJSONWithFilter(c, store, logsFilter(c))
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPollWithFilter(c, store, logsFilter(c))
+			case "level":
+				return handleLoggerLevel(c, m, &config)
+			case "search":
+				// Substring or regex search over the stored messages, paged with limit and since
+				return handleLogsSearch(c, store)
+			case "download":
+				// The captured logs as a plaintext or NDJSON attachment
+				return debugmonitor.HandleDownload(c, store, logsFilter(c), m.Name, logLine)
+			case "stats":
+				// Per-minute counts of the entries per level over the last hour, most recent first,
+				// as JSON with format=json or as an HTML fragment. POST resets the counts.
+				if c.Request().Method == http.MethodPost {
+					throughput.reset()
+					return c.NoContent(http.StatusNoContent)
+				}
+				minutes := throughput.snapshot(time.Now())
+				if c.QueryParam("format") == "json" {
+					return c.JSON(http.StatusOK, minutes)
+				}
+				return debugmonitor.RenderTemplate(c, logStatsViewTemplate, map[string]any{
+					"Minutes": minutes,
+					"Levels":  logStatsLevels,
+				})
 			default:
 				return echo.NewHTTPError(http.StatusBadRequest)
 			}
@@ -67,8 +137,12 @@ func NewLogsMonitor(config LogsMonitorConfig) (*debugmonitor.Monitor, echo.Logge
 	}
 
 	wrapper := &LoggerWrapper{
-		original: config.Logger,
-		monitor:  m,
+		original:   config.Logger,
+		monitor:    m,
+		throughput: throughput,
+	}
+	if config.MinLevel != "" {
+		wrapper.minRank = logLevelRanks[normalizeLogLevel(config.MinLevel)]
 	}
 
 	return m, wrapper
@@ -76,13 +150,79 @@ func NewLogsMonitor(config LogsMonitorConfig) (*debugmonitor.Monitor, echo.Logge
 
 // addLog is a helper function to add log entries to the monitor
 func (l *LoggerWrapper) addLog(level string, message string) {
-	l.monitor.Add(&LogPayload{
+	if level != "PRINT" && logLevelRanks[level] < l.minRank {
+		return
+	}
+	addLogPayload(l.monitor, l.throughput, &LogPayload{
 		Level:     level,
 		Message:   message,
 		Timestamp: time.Now(),
+		RequestID: l.requestID,
+		Caller:    logCaller(),
 	})
 }
 
+// Record records a structured entry of another logging library in the logs monitor, such as by the zap core
+// of the zapmonitor package. Its level is normalized to the levels of the monitor, and its request ID is taken
+// from the request ID fields if it has none. It is not filtered by MinLevel. It reports whether the entry was stored.
+func (l *LoggerWrapper) Record(payload *LogPayload) bool {
+	payload.Level = normalizeLogLevel(payload.Level)
+	if payload.RequestID == "" {
+		for _, key := range jsonLogRequestIDKeys {
+			if v, ok := payload.Fields[key].(string); ok {
+				payload.RequestID = v
+				break
+			}
+		}
+	}
+	if payload.Timestamp.IsZero() {
+		payload.Timestamp = time.Now()
+	}
+	return addLogPayload(l.monitor, l.throughput, payload)
+}
+
+// logCallerSkipPrefixes are the prefixes of the functions that are skipped to find the caller of a log message:
+// the runtime and the logger wrapper.
+var logCallerSkipPrefixes = []string{
+	"runtime.",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.(*LoggerWrapper)",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.logCaller",
+}
+
+// logCaller returns the file:line of the code that called the logger wrapper, like the file and line
+// in the header of gommon's log output. It returns an empty string if there is none.
+func logCaller() string {
+	return callerSkipping(logCallerSkipPrefixes)
+}
+
+// WithContext returns a logger that records its messages with the ID of the request of c,
+// so that they can be joined to the request in the requests monitor and its timeline.
+// The ID is the one set by the requests monitor middleware, or the X-Request-ID response header.
+func (l *LoggerWrapper) WithContext(c echo.Context) *LoggerWrapper {
+	requestID := debugmonitor.RequestIDFromContext(c.Request().Context())
+	if requestID == "" {
+		requestID = c.Response().Header().Get(echo.HeaderXRequestID)
+	}
+	bound := *l
+	bound.requestID = requestID
+	return &bound
+}
+
+// ContextLogger returns a middleware that replaces the logger of each request with a logger bound to the request
+// by LoggerWrapper.WithContext, so that messages logged with c.Logger() are correlated to the request.
+// It must be used after the requests monitor middleware, and requires e.Logger to be the logger returned
+// by NewLogsMonitor. It does nothing otherwise.
+func ContextLogger() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if l, ok := c.Logger().(*LoggerWrapper); ok {
+				c.SetLogger(l.WithContext(c))
+			}
+			return next(c)
+		}
+	}
+}
+
 // Output returns the output writer
 func (l *LoggerWrapper) Output() io.Writer {
 	return l.original.Output()
@@ -243,3 +383,81 @@ func (l *LoggerWrapper) Panicj(j log.JSON) {
 	l.addLog("PANIC", fmt.Sprintf("%v", j))
 	l.original.Panicj(j)
 }
+
+// loggerLevelNames are the names of the levels of the logger that can be set by the level action.
+var loggerLevelNames = map[log.Lvl]string{
+	log.DEBUG: "DEBUG",
+	log.INFO:  "INFO",
+	log.WARN:  "WARN",
+	log.ERROR: "ERROR",
+	log.OFF:   "OFF",
+}
+
+// handleLoggerLevel sets the level of the logger to the "level" parameter, such as "debug" or "off",
+// and returns the new level as JSON.
+func handleLoggerLevel(c echo.Context, m *debugmonitor.Monitor, config *LogsMonitorConfig) error {
+	if c.Request().Method != http.MethodPost {
+		return echo.NewHTTPError(http.StatusMethodNotAllowed)
+	}
+	if !config.EnableLevelControl || m.IsProductionSafe() {
+		return echo.NewHTTPError(http.StatusForbidden, "level control is disabled")
+	}
+
+	name := strings.ToUpper(c.QueryParam("level"))
+	for lvl, levelName := range loggerLevelNames {
+		if levelName == name {
+			config.Logger.SetLevel(lvl)
+			return c.JSON(http.StatusOK, map[string]string{"level": levelName})
+		}
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, "invalid level")
+}
+
+// logLevelRanks are the severities of the log levels. PRINT has no level and ranks below all of them.
+var logLevelRanks = map[string]int{
+	"PRINT": 0,
+	"DEBUG": 1,
+	"INFO":  2,
+	"WARN":  3,
+	"ERROR": 4,
+	"PANIC": 5,
+	"FATAL": 6,
+}
+
+// logLine returns the line of a log entry in the downloaded plaintext logs.
+func logLine(entry *debugmonitor.DataEntry) string {
+	payload, ok := entry.Payload.(*LogPayload)
+	if !ok {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s %s", payload.Timestamp.Format(time.RFC3339Nano), payload.Level, payload.Message)
+	if payload.RequestID != "" {
+		fmt.Fprintf(&b, " request_id=%s", payload.RequestID)
+	}
+	if payload.Caller != "" {
+		fmt.Fprintf(&b, " caller=%s", payload.Caller)
+	}
+	if len(payload.Fields) > 0 {
+		if fields, err := json.Marshal(payload.Fields); err == nil {
+			fmt.Fprintf(&b, " %s", fields)
+		}
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// logsFilter returns the entry filter for the query parameters of the data and stream actions.
+// The "level" parameter selects the entries of the level or higher, such as "warn" for warnings and errors.
+// PRINT entries are only selected by "print". It returns nil if no filter is requested.
+func logsFilter(c echo.Context) debugmonitor.EntryFilter {
+	level := c.QueryParam("level")
+	if level == "" {
+		return nil
+	}
+	minRank := logLevelRanks[normalizeLogLevel(level)]
+	return func(entry *debugmonitor.DataEntry) bool {
+		payload, ok := entry.Payload.(*LogPayload)
+		return ok && logLevelRanks[payload.Level] >= minRank
+	}
+}
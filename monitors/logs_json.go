@@ -0,0 +1,192 @@
+package monitors
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSONLogKeys are the keys of the standard fields of JSON log lines.
+// The other keys of a line are recorded as the fields of the log entry.
+type JSONLogKeys struct {
+	Level   string
+	Message string
+	Time    string
+	Caller  string
+}
+
+// ZapJSONKeys are the keys used by zap's production JSON encoder.
+var ZapJSONKeys = JSONLogKeys{Level: "level", Message: "msg", Time: "ts", Caller: "caller"}
+
+// ZerologJSONKeys are the keys used by zerolog's default field names.
+var ZerologJSONKeys = JSONLogKeys{Level: "level", Message: "message", Time: "time", Caller: "caller"}
+
+// GommonJSONKeys are the keys of gommon's default JSON header. The file and line of the header
+// are recorded as the caller by the logger writer monitor.
+var GommonJSONKeys = JSONLogKeys{Level: "level", Message: "message", Time: "time"}
+
+// jsonLogRequestIDKeys are the field keys whose value is recorded as the request ID of the log entry.
+var jsonLogRequestIDKeys = []string{"request_id", "requestId", "requestID"}
+
+// JSONLogWriter is an io.Writer that records JSON log lines in a logs monitor as structured entries
+// with their level, message, caller and fields. Lines that are not JSON objects are recorded as PRINT entries.
+// It also implements zapcore.WriteSyncer, so it can be the output of a zap core.
+type JSONLogWriter struct {
+	logger *LoggerWrapper
+	keys   JSONLogKeys
+	mu     sync.Mutex
+	buf    []byte // incomplete line of the previous writes
+}
+
+// NewJSONLogWriter creates a JSONLogWriter that records into the logs monitor of the logger returned
+// by NewLogsMonitor. Its entries are counted in the same throughput as the messages of the logger.
+func NewJSONLogWriter(logger *LoggerWrapper, keys JSONLogKeys) *JSONLogWriter {
+	return &JSONLogWriter{logger: logger, keys: keys}
+}
+
+// NewZapWriter creates a JSONLogWriter for zap's JSON encoder. Tee it with the core of the application
+// to record its entries in the logs monitor:
+//
+//	logsMonitor, wrappedLogger := monitors.NewLogsMonitor(monitors.LogsMonitorConfig{Logger: e.Logger})
+//	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+//	core = zapcore.NewTee(core, zapcore.NewCore(encoder, monitors.NewZapWriter(wrappedLogger), zap.DebugLevel))
+//
+// The zapmonitor module provides a zapcore.Core that records the entries without encoding them as JSON.
+func NewZapWriter(logger *LoggerWrapper) *JSONLogWriter {
+	return NewJSONLogWriter(logger, ZapJSONKeys)
+}
+
+// NewZerologWriter creates a JSONLogWriter for zerolog's JSON output. Combine it with the output of the
+// application to record its events in the logs monitor:
+//
+//	logger := zerolog.New(zerolog.MultiLevelWriter(os.Stderr, monitors.NewZerologWriter(wrappedLogger))).With().Timestamp().Logger()
+func NewZerologWriter(logger *LoggerWrapper) *JSONLogWriter {
+	return NewJSONLogWriter(logger, ZerologJSONKeys)
+}
+
+// Write records each complete line of p. An incomplete last line is kept until the next write completes it.
+func (w *JSONLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSpace(w.buf[:i])
+		w.buf = w.buf[i+1:]
+		if len(line) > 0 {
+			addLogPayload(w.logger.monitor, w.logger.throughput, parseJSONLogLine(line, w.keys))
+		}
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Sync implements zapcore.WriteSyncer. Lines are recorded as soon as they are complete, so there is nothing to flush.
+func (w *JSONLogWriter) Sync() error {
+	return nil
+}
+
+// parseJSONLogLine parses a JSON log line into a log payload.
+func parseJSONLogLine(line []byte, keys JSONLogKeys) *LogPayload {
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		return &LogPayload{Level: "PRINT", Message: string(line), Timestamp: time.Now()}
+	}
+
+	payload := &LogPayload{Level: "PRINT", Timestamp: time.Now()}
+	if v, ok := fields[keys.Level]; ok {
+		payload.Level = normalizeLogLevel(stringValue(v))
+		delete(fields, keys.Level)
+	}
+	if v, ok := fields[keys.Message]; ok {
+		payload.Message = stringValue(v)
+		delete(fields, keys.Message)
+	}
+	if v, ok := fields[keys.Time]; ok {
+		if t, ok := parseLogTime(v); ok {
+			payload.Timestamp = t
+			delete(fields, keys.Time)
+		}
+	}
+	if v, ok := fields[keys.Caller]; ok {
+		payload.Caller = stringValue(v)
+		delete(fields, keys.Caller)
+	}
+	for _, key := range jsonLogRequestIDKeys {
+		if v, ok := fields[key].(string); ok {
+			payload.RequestID = v
+			break
+		}
+	}
+	if len(fields) > 0 {
+		payload.Fields = fields
+	}
+	return payload
+}
+
+// normalizeLogLevel maps the level names of logging libraries to the levels of the logs monitor.
+func normalizeLogLevel(level string) string {
+	switch strings.ToUpper(level) {
+	case "TRACE", "DEBUG":
+		return "DEBUG"
+	case "INFO":
+		return "INFO"
+	case "WARN", "WARNING":
+		return "WARN"
+	case "ERROR":
+		return "ERROR"
+	case "DPANIC", "PANIC":
+		return "PANIC"
+	case "FATAL":
+		return "FATAL"
+	default:
+		return "PRINT"
+	}
+}
+
+// parseLogTime parses a timestamp that is either an RFC 3339 string or a Unix time number,
+// whose unit (seconds, milliseconds, microseconds or nanoseconds) is guessed from its magnitude.
+func parseLogTime(v any) (time.Time, bool) {
+	switch v := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		switch {
+		case f < 1e11:
+			sec, frac := math.Modf(f)
+			return time.Unix(int64(sec), int64(frac*1e9)), true
+		case f < 1e14:
+			return time.UnixMilli(int64(f)), true
+		case f < 1e17:
+			return time.UnixMicro(int64(f)), true
+		default:
+			return time.Unix(0, int64(f)), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// stringValue returns v as a string, encoding non-string values as JSON.
+func stringValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,67 @@
+package monitors
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultLogSearchLimit is the default maximum number of entries returned by a search.
+const defaultLogSearchLimit = 100
+
+// LogSearchResult represents a page of the log entries matched by a search.
+type LogSearchResult struct {
+	Entries []*debugmonitor.DataEntry `json:"entries"`
+	// Next is the cursor to pass as "since" to get the next page, or 0 if there are no more matches.
+	Next int64 `json:"next"`
+}
+
+// handleLogsSearch searches the messages of the stored log entries, oldest first.
+// The "q" parameter is matched as a case-insensitive substring, or as a regular expression with regex=1.
+// The "limit" parameter is the maximum number of entries of a page, and the "since" parameter the cursor
+// of the page. The "level" parameter applies as in the data action.
+func handleLogsSearch(c echo.Context, store *debugmonitor.Store) error {
+	q := c.QueryParam("q")
+	var match func(message string) bool
+	if regex, _ := strconv.ParseBool(c.QueryParam("regex")); regex {
+		re, err := regexp.Compile(q)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid regular expression: "+err.Error())
+		}
+		match = re.MatchString
+	} else {
+		lower := strings.ToLower(q)
+		match = func(message string) bool {
+			return strings.Contains(strings.ToLower(message), lower)
+		}
+	}
+
+	limit := defaultLogSearchLimit
+	if n, err := strconv.Atoi(c.QueryParam("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	sinceID := int64(0)
+	if id, err := strconv.ParseInt(c.QueryParam("since"), 10, 64); err == nil {
+		sinceID = id
+	}
+	filter := logsFilter(c)
+
+	result := &LogSearchResult{Entries: make([]*debugmonitor.DataEntry, 0)}
+	for _, entry := range store.GetSince(sinceID) {
+		payload, ok := entry.Payload.(*LogPayload)
+		if !ok || (filter != nil && !filter(entry)) || !match(payload.Message) {
+			continue
+		}
+		if len(result.Entries) == limit {
+			// There are more matches than the page can hold
+			result.Next = result.Entries[limit-1].Id
+			break
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	return c.JSON(http.StatusOK, result)
+}
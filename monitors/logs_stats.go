@@ -0,0 +1,94 @@
+package monitors
+
+import (
+	"sync"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+// LogThroughput represents the number of log entries recorded per level in a minute.
+type LogThroughput struct {
+	Minute time.Time      `json:"minute"`
+	Counts map[string]int `json:"counts"`
+	Total  int            `json:"total"`
+}
+
+// logThroughputWindow is the number of minutes for which the throughput is kept.
+const logThroughputWindow = 60
+
+// logThroughput is a sub-store of the logs monitor that maintains rolling per-minute counts of the entries per level.
+// Unlike the entries in the store, the counts are not limited by MaxRecords.
+type logThroughput struct {
+	mu      sync.Mutex
+	minutes []*LogThroughput // in chronological order, within the window
+}
+
+// addLogPayload adds a log entry to the logs monitor m and counts it in the throughput of the monitor
+// if it was stored, so that the entries dropped by the settings of the monitor are not counted.
+// It reports whether the entry was stored.
+func addLogPayload(m *debugmonitor.Monitor, throughput *logThroughput, payload *LogPayload) bool {
+	if !m.TryAdd(payload) {
+		return false
+	}
+	throughput.record(payload.Level, time.Now())
+	return true
+}
+
+// record counts an entry of the level in the minute of t.
+func (s *logThroughput) record(level string, t time.Time) {
+	minute := t.Truncate(time.Minute)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current *LogThroughput
+	if n := len(s.minutes); n > 0 && s.minutes[n-1].Minute.Equal(minute) {
+		current = s.minutes[n-1]
+	} else {
+		current = &LogThroughput{Minute: minute, Counts: make(map[string]int)}
+		s.minutes = append(s.minutes, current)
+	}
+	current.Counts[level]++
+	current.Total++
+	s.expire(minute)
+}
+
+// expire removes the minutes that are out of the window ending at the minute now.
+// The caller must hold the lock.
+func (s *logThroughput) expire(now time.Time) {
+	oldest := now.Add(-(logThroughputWindow - 1) * time.Minute)
+	i := 0
+	for i < len(s.minutes) && s.minutes[i].Minute.Before(oldest) {
+		i++
+	}
+	if i > 0 {
+		s.minutes = append([]*LogThroughput{}, s.minutes[i:]...)
+	}
+}
+
+// snapshot returns the counts of the minutes within the window ending at now that have entries,
+// with the most recent minute first.
+func (s *logThroughput) snapshot(now time.Time) []*LogThroughput {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now.Truncate(time.Minute))
+	result := make([]*LogThroughput, 0, len(s.minutes))
+	for i := len(s.minutes) - 1; i >= 0; i-- {
+		copied := *s.minutes[i]
+		copied.Counts = make(map[string]int, len(s.minutes[i].Counts))
+		for level, count := range s.minutes[i].Counts {
+			copied.Counts[level] = count
+		}
+		result = append(result, &copied)
+	}
+	return result
+}
+
+// reset removes all counts.
+func (s *logThroughput) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minutes = nil
+}
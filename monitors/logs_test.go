@@ -0,0 +1,536 @@
+package monitors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+func TestZapWriter(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	w := NewZapWriter(logger)
+	// A line may be split across writes
+	lines := `{"level":"info","ts":1700000000.5,"caller":"app/main.go:42","msg":"user created","user_id":7,"request_id":"req-1"}` + "\n" +
+		`{"level":"dpanic","ts":1700000001,"msg":"bad state","err":{"code":1}}` + "\n" +
+		`not json` + "\n"
+	if _, err := w.Write([]byte(lines[:50])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(lines[50:])); err != nil {
+		t.Fatal(err)
+	}
+
+	info := (<-sub.C).Entry.Payload.(*LogPayload)
+	if info.Level != "INFO" || info.Message != "user created" || info.Caller != "app/main.go:42" || info.RequestID != "req-1" {
+		t.Errorf("Unexpected payload: %+v", info)
+	}
+	if !info.Timestamp.Equal(time.Unix(1700000000, 500000000)) {
+		t.Errorf("Unexpected timestamp: %v", info.Timestamp)
+	}
+	if fmt.Sprint(info.Fields["user_id"]) != "7" {
+		t.Errorf("Unexpected fields: %v", info.Fields)
+	}
+
+	panicked := (<-sub.C).Entry.Payload.(*LogPayload)
+	if panicked.Level != "PANIC" || panicked.Fields["err"] == nil {
+		t.Errorf("Unexpected payload: %+v", panicked)
+	}
+
+	raw := (<-sub.C).Entry.Payload.(*LogPayload)
+	if raw.Level != "PRINT" || raw.Message != "not json" {
+		t.Errorf("Unexpected payload: %+v", raw)
+	}
+}
+
+func TestZerologWriter(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	w := NewZerologWriter(logger)
+	line := `{"level":"warn","error":"timeout","time":"2024-05-01T10:00:00+09:00","message":"retrying"}` + "\n"
+	if _, err := w.Write([]byte(line)); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := (<-sub.C).Entry.Payload.(*LogPayload)
+	if payload.Level != "WARN" || payload.Message != "retrying" || payload.Fields["error"] != "timeout" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if payload.Timestamp.Unix() != 1714525200 {
+		t.Errorf("Unexpected timestamp: %v", payload.Timestamp)
+	}
+}
+
+func TestLoggerWrapper_Record(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	if !logger.Record(&LogPayload{Level: "dpanic", Message: "bad state", Fields: map[string]any{"request_id": "req-1"}}) {
+		t.Fatal("Expected the entry to be stored")
+	}
+	payload := (<-sub.C).Entry.Payload.(*LogPayload)
+	if payload.Level != "PANIC" || payload.RequestID != "req-1" || payload.Timestamp.IsZero() {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+}
+
+func TestLogsMonitor_LevelFilter(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger})
+	m.AddMonitor(monitor)
+
+	logger.Print("print")
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	e.GET("/monitor", m.Handler())
+	tests := []struct {
+		level string
+		want  []string
+	}{
+		{"", []string{"print", "debug", "info", "warn", "error"}},
+		{"warn", []string{"warn", "error"}},
+		{"WARNING", []string{"warn", "error"}},
+		{"debug", []string{"debug", "info", "warn", "error"}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=data&level="+tt.level, nil))
+		var entries []struct {
+			Payload LogPayload `json:"payload"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Payload.Message)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("level=%s: got %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestLogsMonitor_MinLevel(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger, MinLevel: "info"})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	logger.Debug("debug")
+	logger.Print("print")
+	logger.Info("info")
+
+	for _, want := range []string{"print", "info"} {
+		if payload := (<-sub.C).Entry.Payload.(*LogPayload); payload.Message != want {
+			t.Errorf("Expected %q, got %q", want, payload.Message)
+		}
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	requestsMonitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(requestsMonitor)
+	logsMonitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger})
+	m.AddMonitor(logsMonitor)
+	e.Logger = logger
+	e.Use(mw, ContextLogger())
+	e.GET("/", func(c echo.Context) error {
+		c.Logger().Info("handled")
+		return c.NoContent(http.StatusOK)
+	})
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	e.Logger.Info("outside")
+
+	var logs []*LogPayload
+	for len(logs) < 2 {
+		if payload, ok := (<-sub.C).Entry.Payload.(*LogPayload); ok {
+			logs = append(logs, payload)
+		}
+	}
+	if logs[0].Message != "handled" || logs[0].RequestID != "req-1" {
+		t.Errorf("Expected the message to be stamped with the request ID, got %+v", logs[0])
+	}
+	if logs[1].RequestID != "" {
+		t.Errorf("Expected no request ID outside requests, got %+v", logs[1])
+	}
+}
+
+func TestLogsMonitor_Polling(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger, UsePolling: true})
+	m.AddMonitor(monitor)
+	e.GET("/monitor", m.Handler())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=render", nil))
+	if !strings.Contains(rec.Body.String(), "logsMonitor(true") {
+		t.Errorf("Expected the view to start in polling mode")
+	}
+
+	sub := m.Subscribe()
+	defer sub.Close()
+	logger.Info("first")
+	firstID := (<-sub.C).Entry.Id
+	logger.Info("second")
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=data&since="+strconv.FormatInt(firstID, 10), nil))
+	var entries []struct {
+		Payload LogPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Payload.Message != "second" {
+		t.Errorf("Expected only the entry after since, got %+v", entries)
+	}
+}
+
+func TestLogsMonitor_TranslatedView(t *testing.T) {
+	e := echo.New()
+	monitor, _ := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger})
+	m := debugmonitor.New(debugmonitor.WithLocale("ja"))
+	m.AddMonitor(monitor)
+	e.GET("/monitor", m.Handler())
+
+	// The view is rendered more than once with the same template
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=render", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "ログはまだありません") || strings.Contains(rec.Body.String(), "No logs yet") {
+			t.Errorf("Expected the view to be translated to Japanese")
+		}
+	}
+}
+
+func TestLogsMonitor_Caller(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+	logger.Infof("hello %s", "world")
+
+	payload := (<-sub.C).Entry.Payload.(*LogPayload)
+	if !strings.Contains(payload.Caller, "logs_test.go:") {
+		t.Errorf("Expected the caller to be the test, got %q", payload.Caller)
+	}
+}
+
+func TestLogsMonitor_StatsAction(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger})
+	m.AddMonitor(monitor)
+
+	logger.Info("info")
+	logger.Error("error 1")
+	logger.Error("error 2")
+	if _, err := NewZapWriter(logger).Write([]byte(`{"level":"warn","msg":"warn"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=stats&format=json", nil))
+	var minutes []*LogThroughput
+	if err := json.Unmarshal(rec.Body.Bytes(), &minutes); err != nil {
+		t.Fatal(err)
+	}
+	if len(minutes) == 0 {
+		t.Fatal("Expected the throughput of the current minute")
+	}
+	var total, errorCount int
+	for _, minute := range minutes {
+		total += minute.Total
+		errorCount += minute.Counts["ERROR"]
+	}
+	if total != 4 || errorCount != 2 {
+		t.Errorf("Expected 4 entries with 2 errors, got %d with %d errors", total, errorCount)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=stats", nil))
+	if !strings.Contains(rec.Body.String(), "ERROR") {
+		t.Errorf("Expected HTML throughput, got %s", rec.Body.String())
+	}
+
+	// Reset the counts
+	req := httptest.NewRequest(http.MethodPost, "/monitor?monitor=logs&action=stats", nil)
+	req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+	req.Header.Set("X-CSRF-Token", "test-token")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=stats&format=json", nil))
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("Expected the counts to be reset, got %s", rec.Body.String())
+	}
+}
+
+func TestLogsMonitor_StatsCountStoredEntries(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger})
+	m.AddMonitor(monitor)
+
+	// The entries dropped by a zero sample rate are not counted
+	settings, _ := m.MonitorSettings(monitor.Name)
+	settings.SampleRate = 0
+	if err := m.UpdateMonitorSettings(monitor.Name, settings); err != nil {
+		t.Fatal(err)
+	}
+	logger.Error("error")
+	if _, err := NewZapWriter(logger).Write([]byte(`{"level":"warn","msg":"warn"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=stats&format=json", nil))
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("Expected no counts, got %s", rec.Body.String())
+	}
+}
+
+func TestLogThroughput_Window(t *testing.T) {
+	s := &logThroughput{}
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	s.record("INFO", now.Add(-2*time.Hour))
+	s.record("INFO", now.Add(-time.Minute))
+	s.record("ERROR", now)
+	s.record("ERROR", now)
+
+	minutes := s.snapshot(now)
+	if len(minutes) != 2 {
+		t.Fatalf("Expected 2 minutes within the window, got %d", len(minutes))
+	}
+	if !minutes[0].Minute.Equal(now.Truncate(time.Minute)) || minutes[0].Counts["ERROR"] != 2 {
+		t.Errorf("Unexpected most recent minute: %+v", minutes[0])
+	}
+	if minutes[1].Counts["INFO"] != 1 {
+		t.Errorf("Unexpected previous minute: %+v", minutes[1])
+	}
+}
+
+func TestLogsMonitor_Download(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger})
+	m.AddMonitor(monitor)
+
+	logger.Info("starting")
+	logger.Error("failed")
+
+	e.GET("/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=download&level=error", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if got := rec.Header().Get(echo.HeaderContentDisposition); got != `attachment; filename="logs.log"` {
+		t.Errorf("Unexpected Content-Disposition: %s", got)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, " ERROR failed caller=") || strings.Contains(body, "starting") {
+		t.Errorf("Unexpected plaintext logs: %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=download&format=ndjson", nil))
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 NDJSON lines, got %d", len(lines))
+	}
+	var entry struct {
+		Payload LogPayload `json:"payload"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Payload.Message != "starting" {
+		t.Errorf("Unexpected first entry: %+v", entry.Payload)
+	}
+
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=download&from="+url.QueryEscape(future), nil))
+	if rec.Body.Len() != 0 {
+		t.Errorf("Expected no entries after from, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=download&from=yesterday", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid time, got %d", rec.Code)
+	}
+}
+
+func TestLogsMonitor_Search(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	e.Logger.SetLevel(log.DEBUG)
+	monitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger})
+	m.AddMonitor(monitor)
+
+	for i := 1; i <= 5; i++ {
+		logger.Infof("created Order %d", 12340+i)
+		logger.Debugf("cache miss %d", i)
+	}
+	logger.Error("failed to ship order 12345")
+
+	e.GET("/monitor", m.Handler())
+	search := func(params string) (*LogSearchResult, int) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=search&"+params, nil))
+		result := &LogSearchResult{}
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), result); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return result, rec.Code
+	}
+	messages := func(result *LogSearchResult) []string {
+		var got []string
+		for _, entry := range result.Entries {
+			got = append(got, entry.Payload.(map[string]any)["message"].(string))
+		}
+		return got
+	}
+
+	result, _ := search("q=order+12345")
+	if fmt.Sprint(messages(result)) != "[created Order 12345 failed to ship order 12345]" || result.Next != 0 {
+		t.Errorf("Unexpected substring matches: %v (next %d)", messages(result), result.Next)
+	}
+
+	result, _ = search("q=" + url.QueryEscape(`^created Order 1234[1-4]$`) + "&regex=1&limit=3")
+	if fmt.Sprint(messages(result)) != "[created Order 12341 created Order 12342 created Order 12343]" || result.Next == 0 {
+		t.Fatalf("Unexpected first page: %v (next %d)", messages(result), result.Next)
+	}
+	result, _ = search("q=" + url.QueryEscape(`^created Order 1234[1-4]$`) + "&regex=1&limit=3&since=" + strconv.FormatInt(result.Next, 10))
+	if fmt.Sprint(messages(result)) != "[created Order 12344]" || result.Next != 0 {
+		t.Errorf("Unexpected second page: %v (next %d)", messages(result), result.Next)
+	}
+
+	result, _ = search("q=12345&level=error")
+	if fmt.Sprint(messages(result)) != "[failed to ship order 12345]" {
+		t.Errorf("Unexpected matches of the level: %v", messages(result))
+	}
+
+	if _, code := search("q=" + url.QueryEscape("(") + "&regex=1"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid regular expression, got %d", code)
+	}
+}
+
+func TestLogsMonitor_LevelControl(t *testing.T) {
+	setLevel := func(e *echo.Echo, level string) int {
+		req := httptest.NewRequest(http.MethodPost, "/monitor?monitor=logs&action=level&level="+level, nil)
+		req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+		req.Header.Set("X-CSRF-Token", "test-token")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		m := debugmonitor.New()
+		e := echo.New()
+		e.Logger.SetOutput(io.Discard)
+		e.Logger.SetLevel(log.ERROR)
+		monitor, logger := NewLogsMonitor(LogsMonitorConfig{Logger: e.Logger, EnableLevelControl: true})
+		m.AddMonitor(monitor)
+		e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor", m.Handler())
+
+		if code := setLevel(e, "debug"); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		if logger.Level() != log.DEBUG {
+			t.Errorf("Expected the logger level to be DEBUG, got %v", logger.Level())
+		}
+		if code := setLevel(e, "verbose"); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unknown level, got %d", code)
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=logs&action=render", nil))
+		if !strings.Contains(rec.Body.String(), `data-level="DEBUG"`) {
+			t.Errorf("Expected the level control in the view")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		for _, m := range []*debugmonitor.Manager{debugmonitor.New(), debugmonitor.NewProductionSafe()} {
+			e := echo.New()
+			e.Logger.SetOutput(io.Discard)
+			monitor, _ := NewLogsMonitor(LogsMonitorConfig{
+				Logger:             e.Logger,
+				EnableLevelControl: m.IsProductionSafe(),
+			})
+			m.AddMonitor(monitor)
+			e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor", m.Handler())
+
+			if code := setLevel(e, "debug"); code != http.StatusForbidden {
+				t.Errorf("Expected status 403, got %d", code)
+			}
+		}
+	})
+}
@@ -0,0 +1,324 @@
+package monitors
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// MailPayload represents the data structure for outgoing mail monitoring
+type MailPayload struct {
+	From        string            `json:"from"`
+	To          []string          `json:"to"` // envelope recipients, including Bcc
+	Subject     string            `json:"subject"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Text        string            `json:"text,omitempty"` // text/plain body
+	HTML        string            `json:"html,omitempty"` // text/html body, rendered by the preview action
+	Truncated   bool              `json:"truncated,omitempty"`
+	Attachments []*MailAttachment `json:"attachments,omitempty"`
+	Size        int               `json:"size"`            // size of the raw message, in bytes
+	Error       string            `json:"error,omitempty"` // error of the delivery or of parsing the message
+	Timestamp   time.Time         `json:"timestamp"`
+}
+
+// MailAttachment is the metadata of a part of a message that is not displayed as its body.
+type MailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"` // decoded size, in bytes
+}
+
+// MailRecorder records outgoing messages to the mail monitor.
+// from and to are the envelope sender and recipients, msg is the RFC 5322 message as sent over SMTP,
+// and sendErr is the error of the delivery, if any.
+type MailRecorder func(from string, to []string, msg []byte, sendErr error)
+
+// SendMailFunc is the signature of smtp.SendMail.
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// SendMail returns a function that records each message and passes it to send.
+// Use it in place of smtp.SendMail. If send is nil, the messages are recorded without being delivered,
+// so that emails can be inspected during development without a real mailbox.
+func (r MailRecorder) SendMail(send SendMailFunc) SendMailFunc {
+	return func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		var err error
+		if send != nil {
+			err = send(addr, a, from, to, msg)
+		}
+		r(from, to, msg, err)
+		return err
+	}
+}
+
+// Writer returns an io.WriteCloser that writes the message to w and records it when it is closed.
+// Wrap the writer returned by smtp.Client.Data with it. If w is nil, the message is only recorded.
+func (r MailRecorder) Writer(from string, to []string, w io.WriteCloser) io.WriteCloser {
+	return &mailWriter{recorder: r, from: from, to: to, w: w}
+}
+
+// mailWriter tees a message to the underlying writer and records it on Close.
+type mailWriter struct {
+	recorder MailRecorder
+	from     string
+	to       []string
+	w        io.WriteCloser
+	buf      bytes.Buffer
+	err      error
+}
+
+func (w *mailWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	if w.w == nil {
+		return len(p), nil
+	}
+	n, err := w.w.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *mailWriter) Close() error {
+	var err error
+	if w.w != nil {
+		err = w.w.Close()
+	}
+	sendErr := w.err
+	if sendErr == nil {
+		sendErr = err
+	}
+	w.recorder(w.from, w.to, w.buf.Bytes(), sendErr)
+	return err
+}
+
+// MailMonitorConfig defines the config for Mail monitor.
+type MailMonitorConfig struct {
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+	// MaxBodySize is the maximum number of bytes of the text and HTML bodies to capture.
+	// Optional. Default: DefaultMaxBodySize
+	MaxBodySize int
+}
+
+//go:embed mail.html
+var mailView string
+
+// mailViewTemplate is the parsed template for the mail view
+var mailViewTemplate = template.Must(template.New("mailView").Funcs(debugmonitor.TemplateFuncs).Parse(mailView))
+
+// mailPreviewPolicy is the Content-Security-Policy of the HTML preview. It blocks scripts and remote content
+// other than images, so that previewing a message cannot act on the dashboard.
+const mailPreviewPolicy = "sandbox; default-src 'none'; img-src data: https: http:; style-src 'unsafe-inline'; font-src data:"
+
+// mailSafeHeaders are the headers whose values are kept in production-safe mode.
+// The values of all other headers are redacted.
+var mailSafeHeaders = map[string]bool{
+	"Content-Type": true,
+	"Date":         true,
+	"Message-Id":   true,
+	"Mime-Version": true,
+	"Subject":      true,
+}
+
+// NewMailMonitor creates a new monitor for outgoing mail and returns
+// the monitor along with a mail recording function.
+// In production-safe mode, bodies are not captured and the addresses are redacted.
+func NewMailMonitor(config MailMonitorConfig) (*debugmonitor.Monitor, MailRecorder) {
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultMaxBodySize
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "mail",
+		DisplayName: "Mail",
+		MaxRecords:  200,
+		Icon:        debugmonitor.IconDocumentText,
+		Group:       "Application",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, mailViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStream(c, store)
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSON(c, store)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPoll(c, store)
+			case "preview":
+				return handleMailPreview(c, store)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	recorder := func(from string, to []string, msg []byte, sendErr error) {
+		payload := parseMail(msg, !m.IsProductionSafe(), config.MaxBodySize)
+		payload.From = from
+		payload.To = append([]string(nil), to...)
+		payload.Timestamp = time.Now()
+		if sendErr != nil {
+			payload.Error = sendErr.Error()
+		}
+
+		if m.IsProductionSafe() {
+			payload.From = redacted
+			for i := range payload.To {
+				payload.To[i] = redacted
+			}
+			for key := range payload.Headers {
+				if !mailSafeHeaders[key] {
+					payload.Headers[key] = redacted
+				}
+			}
+		}
+
+		m.Add(payload)
+	}
+
+	return m, recorder
+}
+
+// parseMail parses an RFC 5322 message into a payload. The bodies are only captured if captureBodies is true,
+// up to limit bytes each. A message that cannot be parsed is recorded with the parse error.
+func parseMail(msg []byte, captureBodies bool, limit int) *MailPayload {
+	payload := &MailPayload{Size: len(msg)}
+
+	message, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		payload.Error = "parse: " + err.Error()
+		return payload
+	}
+
+	decoder := new(mime.WordDecoder)
+	payload.Headers = make(map[string]string, len(message.Header))
+	for key, values := range message.Header {
+		decoded := make([]string, len(values))
+		for i, v := range values {
+			if d, err := decoder.DecodeHeader(v); err == nil {
+				v = d
+			}
+			decoded[i] = v
+		}
+		payload.Headers[key] = strings.Join(decoded, ", ")
+	}
+	payload.Subject = payload.Headers["Subject"]
+
+	if err := parseMailPart(payload, textproto.MIMEHeader(message.Header), message.Body, captureBodies, limit); err != nil {
+		payload.Error = "parse: " + err.Error()
+	}
+	return payload
+}
+
+// parseMailPart sets the text and HTML bodies of the payload from a part of a message, or adds it as an attachment.
+// Multipart parts are walked recursively. The first text/plain and text/html parts are the bodies.
+func parseMailPart(payload *MailPayload, header textproto.MIMEHeader, body io.Reader, captureBodies bool, limit int) error {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "application/octet-stream"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := parseMailPart(payload, part.Header, part, captureBodies, limit); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	isBody := disposition != "attachment" && filename == ""
+
+	switch {
+	case isBody && mediaType == "text/plain" && payload.Text == "":
+		if captureBodies {
+			payload.Text, payload.Truncated = truncateMailBody(data, limit, payload.Truncated)
+		}
+	case isBody && mediaType == "text/html" && payload.HTML == "":
+		if captureBodies {
+			payload.HTML, payload.Truncated = truncateMailBody(data, limit, payload.Truncated)
+		}
+	default:
+		payload.Attachments = append(payload.Attachments, &MailAttachment{
+			Filename:    filename,
+			ContentType: mediaType,
+			Size:        len(data),
+		})
+	}
+	return nil
+}
+
+// truncateMailBody returns data as a string cut at limit bytes, and whether any body of the message was cut.
+func truncateMailBody(data []byte, limit int, truncated bool) (string, bool) {
+	if len(data) > limit {
+		return string(data[:limit]), true
+	}
+	return string(data), truncated
+}
+
+// handleMailPreview serves the HTML body of the entry given by the "id" parameter, or its text body
+// if it has no HTML body. Scripts are blocked by the Content-Security-Policy.
+func handleMailPreview(c echo.Context, store *debugmonitor.Store) error {
+	entry, err := debugmonitor.GetEntryFromQuery(c, store)
+	if err != nil {
+		return err
+	}
+	payload := entry.Payload.(*MailPayload)
+
+	c.Response().Header().Set("Content-Security-Policy", mailPreviewPolicy)
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	if payload.HTML != "" {
+		return c.HTML(http.StatusOK, payload.HTML)
+	}
+	return c.HTML(http.StatusOK, fmt.Sprintf("<pre style=\"white-space: pre-wrap\">%s</pre>", template.HTMLEscapeString(payload.Text)))
+}
@@ -0,0 +1,121 @@
+package monitors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+const testMailMessage = "From: App <app@example.com>\r\n" +
+	"To: alice@example.com\r\n" +
+	"Subject: =?UTF-8?B?V2VsY29tZSDwn46J?=\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=UTF-8\r\n" +
+	"\r\n" +
+	"Hello Alice\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=UTF-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"<p style=3D\"color: red\">Hello Alice</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; name=\"notes.txt\"\r\n" +
+	"Content-Disposition: attachment; filename=\"notes.txt\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"aGVsbG8g\r\n" +
+	"d29ybGQ=\r\n" +
+	"--outer--\r\n"
+
+func TestMailMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, recorder := NewMailMonitor(MailMonitorConfig{})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	// Without a send function, the message is only recorded
+	send := recorder.SendMail(nil)
+	if err := send("localhost:25", nil, "app@example.com", []string{"alice@example.com", "audit@example.com"}, []byte(testMailMessage)); err != nil {
+		t.Fatal(err)
+	}
+
+	event := <-sub.C
+	payload := event.Entry.Payload.(*MailPayload)
+	if payload.Subject != "Welcome 🎉" {
+		t.Errorf("Expected the decoded subject, got %q", payload.Subject)
+	}
+	if len(payload.To) != 2 || payload.To[1] != "audit@example.com" {
+		t.Errorf("Expected the envelope recipients, got %v", payload.To)
+	}
+	if strings.TrimSpace(payload.Text) != "Hello Alice" {
+		t.Errorf("Unexpected text body: %q", payload.Text)
+	}
+	if strings.TrimSpace(payload.HTML) != `<p style="color: red">Hello Alice</p>` {
+		t.Errorf("Unexpected HTML body: %q", payload.HTML)
+	}
+	if len(payload.Attachments) != 1 || payload.Attachments[0].Filename != "notes.txt" || payload.Attachments[0].Size != len("hello world") {
+		t.Errorf("Unexpected attachments: %+v", payload.Attachments)
+	}
+
+	e := echo.New()
+	e.Any("/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=mail&action=preview&id="+strconv.FormatInt(event.Entry.Id, 10), nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Hello Alice</p>") {
+		t.Errorf("Expected the HTML preview, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Header().Get("Content-Security-Policy"), "sandbox") {
+		t.Errorf("Expected the preview to be sandboxed, got %q", rec.Header().Get("Content-Security-Policy"))
+	}
+
+	// Messages written through Writer are recorded on Close with the delivery error
+	w := recorder.Writer("app@example.com", []string{"bob@example.com"}, &failingWriteCloser{})
+	_, _ = w.Write([]byte("Subject: Hi\r\n\r\nHello Bob\r\n"))
+	_ = w.Close()
+	payload = (<-sub.C).Entry.Payload.(*MailPayload)
+	if payload.Subject != "Hi" || payload.Error != "connection reset" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+}
+
+func TestMailMonitorProductionSafe(t *testing.T) {
+	m := debugmonitor.NewProductionSafe()
+	monitor, recorder := NewMailMonitor(MailMonitorConfig{})
+	m.AddMonitor(monitor)
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	recorder("app@example.com", []string{"alice@example.com"}, []byte(testMailMessage), nil)
+
+	payload := (<-sub.C).Entry.Payload.(*MailPayload)
+	if payload.Text != "" || payload.HTML != "" {
+		t.Errorf("Expected no bodies in production-safe mode, got %+v", payload)
+	}
+	if payload.From != redacted || payload.To[0] != redacted || payload.Headers["To"] != redacted {
+		t.Errorf("Expected the addresses to be redacted, got %+v", payload)
+	}
+	if payload.Headers["Mime-Version"] != "1.0" {
+		t.Errorf("Expected the safe headers to be kept, got %v", payload.Headers)
+	}
+}
+
+// failingWriteCloser fails all writes.
+type failingWriteCloser struct{}
+
+func (failingWriteCloser) Write(p []byte) (int, error) { return 0, errors.New("connection reset") }
+
+func (failingWriteCloser) Close() error { return nil }
@@ -0,0 +1,146 @@
+package monitors
+
+import (
+	"context"
+	_ "embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"runtime"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// MemoryPayload represents a sample of the memory and GC statistics of the process
+type MemoryPayload struct {
+	HeapAlloc   uint64    `json:"heapAlloc"`   // bytes of allocated heap objects
+	HeapInuse   uint64    `json:"heapInuse"`   // bytes in in-use heap spans
+	HeapObjects uint64    `json:"heapObjects"` // number of allocated heap objects
+	Sys         uint64    `json:"sys"`         // bytes of memory obtained from the OS
+	NextGC      uint64    `json:"nextGC"`      // target heap size of the next GC cycle
+	NumGC       uint32    `json:"numGC"`       // number of completed GC cycles
+	GCs         uint32    `json:"gcs"`         // number of GC cycles since the previous sample
+	PauseTotal  float64   `json:"pauseTotal"`  // total GC pause time since the process started, in milliseconds
+	Pause       float64   `json:"pause"`       // GC pause time since the previous sample, in milliseconds
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// DefaultMemorySampleInterval is the default interval of the memory samples.
+const DefaultMemorySampleInterval = 5 * time.Second
+
+// MemoryMonitorConfig defines the config for Memory monitor.
+type MemoryMonitorConfig struct {
+	// Interval is the interval of the samples. Sampling briefly stops the world, so it should not be too short.
+	// Optional. Default: DefaultMemorySampleInterval
+	Interval time.Duration
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+}
+
+//go:embed memory.html
+var memoryView string
+
+// memoryViewTemplate is the parsed template for the memory view
+var memoryViewTemplate = template.Must(template.New("memoryView").Funcs(debugmonitor.TemplateFuncs).Parse(memoryView))
+
+// MemoryMonitor is a plugin that samples the memory and GC statistics in the background.
+// Add it with debugmonitor.Manager.AddPlugin, which starts the sampler and stops it when the manager is closed.
+type MemoryMonitor struct {
+	monitor  *debugmonitor.Monitor
+	interval time.Duration
+	previous *runtime.MemStats
+}
+
+// NewMemoryMonitor creates a new monitor for memory and GC statistics.
+func NewMemoryMonitor(config MemoryMonitorConfig) *MemoryMonitor {
+	if config.Interval <= 0 {
+		config.Interval = DefaultMemorySampleInterval
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "memory",
+		DisplayName: "Memory",
+		MaxRecords:  720,
+		Icon:        debugmonitor.IconCpuChip,
+		Group:       "Runtime",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, memoryViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStream(c, store)
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSON(c, store)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPoll(c, store)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	return &MemoryMonitor{
+		monitor:  m,
+		interval: config.Interval,
+	}
+}
+
+// Monitor implements debugmonitor.MonitorPlugin.
+func (mm *MemoryMonitor) Monitor() *debugmonitor.Monitor {
+	return mm.monitor
+}
+
+// Assets implements debugmonitor.MonitorPlugin.
+func (mm *MemoryMonitor) Assets() fs.FS {
+	return nil
+}
+
+// Run implements debugmonitor.MonitorPluginRunner. It records a sample at each interval until ctx is canceled.
+func (mm *MemoryMonitor) Run(ctx context.Context) {
+	mm.sample()
+
+	ticker := time.NewTicker(mm.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			mm.sample()
+		}
+	}
+}
+
+// sample records the current statistics.
+func (mm *MemoryMonitor) sample() {
+	stats := &runtime.MemStats{}
+	runtime.ReadMemStats(stats)
+
+	payload := &MemoryPayload{
+		HeapAlloc:   stats.HeapAlloc,
+		HeapInuse:   stats.HeapInuse,
+		HeapObjects: stats.HeapObjects,
+		Sys:         stats.Sys,
+		NextGC:      stats.NextGC,
+		NumGC:       stats.NumGC,
+		PauseTotal:  float64(stats.PauseTotalNs) / float64(time.Millisecond),
+		Timestamp:   time.Now(),
+	}
+	if mm.previous != nil {
+		payload.GCs = stats.NumGC - mm.previous.NumGC
+		payload.Pause = float64(stats.PauseTotalNs-mm.previous.PauseTotalNs) / float64(time.Millisecond)
+	}
+	mm.previous = stats
+
+	mm.monitor.Add(payload)
+}
@@ -0,0 +1,47 @@
+package monitors
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+func TestMemoryMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	defer m.Close()
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	m.AddPlugin(NewMemoryMonitor(MemoryMonitorConfig{Interval: 10 * time.Millisecond}))
+
+	var first *MemoryPayload
+	select {
+	case event := <-sub.C:
+		first = event.Entry.Payload.(*MemoryPayload)
+	case <-time.After(time.Second):
+		t.Fatal("Expected a sample")
+	}
+	if first.HeapAlloc == 0 || first.Sys == 0 || first.HeapObjects == 0 {
+		t.Errorf("Unexpected sample: %+v", first)
+	}
+
+	runtime.GC()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-sub.C:
+			sample := event.Entry.Payload.(*MemoryPayload)
+			if sample.NumGC <= first.NumGC {
+				continue
+			}
+			if sample.GCs == 0 || sample.PauseTotal < first.PauseTotal {
+				t.Errorf("Expected the GC cycles since the previous sample, got %+v", sample)
+			}
+			return
+		case <-timeout:
+			t.Fatal("Expected a sample after a GC cycle")
+		}
+	}
+}
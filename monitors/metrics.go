@@ -0,0 +1,311 @@
+package monitors
+
+import (
+	"context"
+	_ "embed"
+	"html/template"
+	"io/fs"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsPayload represents a sample of the user-defined metrics
+type MetricsPayload struct {
+	Metrics   []*MetricSample `json:"metrics"` // sorted by name
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// MetricSample is the value of a metric at a sample.
+type MetricSample struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // one of the MetricType constants
+	// Value is the total of a counter, the value of a gauge, or the mean duration of the observations
+	// of a timer since the previous sample, in milliseconds.
+	Value float64 `json:"value"`
+	// Delta is the increase of a counter since the previous sample.
+	Delta float64 `json:"delta,omitempty"`
+	// Count is the number of observations of a timer since the previous sample.
+	Count int64 `json:"count,omitempty"`
+	// Max is the longest observation of a timer since the previous sample, in milliseconds.
+	Max float64 `json:"max,omitempty"`
+}
+
+// Metric types.
+const (
+	MetricTypeCounter = "counter"
+	MetricTypeGauge   = "gauge"
+	MetricTypeTimer   = "timer"
+)
+
+// Counter is a metric that only goes up, such as the number of successful checkouts.
+type Counter struct {
+	value    atomic.Int64
+	previous int64 // value at the previous sample, only accessed by the sampler
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add increments the counter by n. Negative values are ignored.
+func (c *Counter) Add(n int64) {
+	if n > 0 {
+		c.value.Add(n)
+	}
+}
+
+// Value returns the current total of the counter.
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+// Gauge is a metric that goes up and down, such as the number of items in a queue.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set sets the value of the gauge.
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Add adds delta, which can be negative, to the value of the gauge.
+func (g *Gauge) Add(delta float64) {
+	for {
+		old := g.bits.Load()
+		if g.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// Timer is a metric of durations, such as the time taken to call a payment provider.
+// Each sample reports the observations since the previous sample.
+type Timer struct {
+	mu    sync.Mutex
+	count int64
+	sum   time.Duration
+	max   time.Duration
+}
+
+// Observe records a duration.
+func (t *Timer) Observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.count++
+	t.sum += d
+	if d > t.max {
+		t.max = d
+	}
+}
+
+// ObserveSince records the duration since start. Use it with defer:
+//
+//	defer metrics.Timer("payment.charge").ObserveSince(time.Now())
+func (t *Timer) ObserveSince(start time.Time) {
+	t.Observe(time.Since(start))
+}
+
+// reset returns the observations since the previous call and clears them.
+func (t *Timer) reset() (count int64, sum, max time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	count, sum, max = t.count, t.sum, t.max
+	t.count, t.sum, t.max = 0, 0, 0
+	return count, sum, max
+}
+
+// DefaultMetricsSampleInterval is the default interval of the metrics samples.
+const DefaultMetricsSampleInterval = 5 * time.Second
+
+// MetricsMonitorConfig defines the config for Metrics monitor.
+type MetricsMonitorConfig struct {
+	// Interval is the interval of the samples.
+	// Optional. Default: DefaultMetricsSampleInterval
+	Interval time.Duration
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+}
+
+//go:embed metrics.html
+var metricsView string
+
+// metricsViewTemplate is the parsed template for the metrics view
+var metricsViewTemplate = template.Must(template.New("metricsView").Funcs(debugmonitor.TemplateFuncs).Parse(metricsView))
+
+// MetricsMonitor is a plugin that holds the counters, gauges and timers defined by the application
+// and samples them in the background, so that application-specific metrics can be watched without
+// a Prometheus stack. Add it with debugmonitor.Manager.AddPlugin.
+type MetricsMonitor struct {
+	monitor  *debugmonitor.Monitor
+	interval time.Duration
+
+	mu       sync.Mutex
+	counters map[string]*Counter
+	gauges   map[string]*Gauge
+	timers   map[string]*Timer
+}
+
+// NewMetricsMonitor creates a new monitor for user-defined metrics.
+func NewMetricsMonitor(config MetricsMonitorConfig) *MetricsMonitor {
+	if config.Interval <= 0 {
+		config.Interval = DefaultMetricsSampleInterval
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "metrics",
+		DisplayName: "Metrics",
+		MaxRecords:  720,
+		Icon:        debugmonitor.IconCpuChip,
+		Group:       "Application",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, metricsViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStream(c, store)
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSON(c, store)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPoll(c, store)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	return &MetricsMonitor{
+		monitor:  m,
+		interval: config.Interval,
+		counters: make(map[string]*Counter),
+		gauges:   make(map[string]*Gauge),
+		timers:   make(map[string]*Timer),
+	}
+}
+
+// Counter returns the counter with the given name, creating it if it does not exist.
+func (mm *MetricsMonitor) Counter(name string) *Counter {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	c, ok := mm.counters[name]
+	if !ok {
+		c = &Counter{}
+		mm.counters[name] = c
+	}
+	return c
+}
+
+// Gauge returns the gauge with the given name, creating it if it does not exist.
+func (mm *MetricsMonitor) Gauge(name string) *Gauge {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	g, ok := mm.gauges[name]
+	if !ok {
+		g = &Gauge{}
+		mm.gauges[name] = g
+	}
+	return g
+}
+
+// Timer returns the timer with the given name, creating it if it does not exist.
+func (mm *MetricsMonitor) Timer(name string) *Timer {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	t, ok := mm.timers[name]
+	if !ok {
+		t = &Timer{}
+		mm.timers[name] = t
+	}
+	return t
+}
+
+// Monitor implements debugmonitor.MonitorPlugin.
+func (mm *MetricsMonitor) Monitor() *debugmonitor.Monitor {
+	return mm.monitor
+}
+
+// Assets implements debugmonitor.MonitorPlugin.
+func (mm *MetricsMonitor) Assets() fs.FS {
+	return nil
+}
+
+// Run implements debugmonitor.MonitorPluginRunner. It records a sample at each interval until ctx is canceled.
+func (mm *MetricsMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(mm.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			mm.sample()
+		}
+	}
+}
+
+// sample records the current values of all metrics. Nothing is recorded until a metric is defined.
+func (mm *MetricsMonitor) sample() {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	payload := &MetricsPayload{Timestamp: time.Now()}
+	for name, c := range mm.counters {
+		value := c.Value()
+		payload.Metrics = append(payload.Metrics, &MetricSample{
+			Name:  name,
+			Type:  MetricTypeCounter,
+			Value: float64(value),
+			Delta: float64(value - c.previous),
+		})
+		c.previous = value
+	}
+	for name, g := range mm.gauges {
+		payload.Metrics = append(payload.Metrics, &MetricSample{
+			Name:  name,
+			Type:  MetricTypeGauge,
+			Value: g.Value(),
+		})
+	}
+	for name, t := range mm.timers {
+		count, sum, max := t.reset()
+		s := &MetricSample{
+			Name:  name,
+			Type:  MetricTypeTimer,
+			Count: count,
+			Max:   float64(max) / float64(time.Millisecond),
+		}
+		if count > 0 {
+			s.Value = float64(sum) / float64(count) / float64(time.Millisecond)
+		}
+		payload.Metrics = append(payload.Metrics, s)
+	}
+	if len(payload.Metrics) == 0 {
+		return
+	}
+
+	sort.Slice(payload.Metrics, func(i, j int) bool {
+		return payload.Metrics[i].Name < payload.Metrics[j].Name
+	})
+	mm.monitor.Add(payload)
+}
@@ -0,0 +1,59 @@
+package monitors
+
+import (
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+func TestMetricsMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	defer m.Close()
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	metrics := NewMetricsMonitor(MetricsMonitorConfig{Interval: 10 * time.Millisecond})
+	metrics.Counter("checkout.success").Inc()
+	metrics.Counter("checkout.success").Add(2)
+	metrics.Gauge("queue.size").Set(5)
+	metrics.Gauge("queue.size").Add(-1.5)
+	metrics.Timer("payment.charge").Observe(10 * time.Millisecond)
+	metrics.Timer("payment.charge").Observe(30 * time.Millisecond)
+	m.AddPlugin(metrics)
+
+	samples := func() map[string]*MetricSample {
+		var payload *MetricsPayload
+		select {
+		case event := <-sub.C:
+			payload = event.Entry.Payload.(*MetricsPayload)
+		case <-time.After(time.Second):
+			t.Fatal("Expected a sample")
+		}
+		result := map[string]*MetricSample{}
+		for _, s := range payload.Metrics {
+			result[s.Name] = s
+		}
+		return result
+	}
+
+	first := samples()
+	if s := first["checkout.success"]; s.Type != MetricTypeCounter || s.Value != 3 || s.Delta != 3 {
+		t.Errorf("Unexpected counter: %+v", s)
+	}
+	if s := first["queue.size"]; s.Type != MetricTypeGauge || s.Value != 3.5 {
+		t.Errorf("Unexpected gauge: %+v", s)
+	}
+	if s := first["payment.charge"]; s.Type != MetricTypeTimer || s.Count != 2 || s.Value != 20 || s.Max != 30 {
+		t.Errorf("Unexpected timer: %+v", s)
+	}
+
+	// The increase of counters and the observations of timers are relative to the previous sample
+	second := samples()
+	if s := second["checkout.success"]; s.Value != 3 || s.Delta != 0 {
+		t.Errorf("Unexpected counter: %+v", s)
+	}
+	if s := second["payment.charge"]; s.Count != 0 || s.Value != 0 {
+		t.Errorf("Unexpected timer: %+v", s)
+	}
+}
@@ -0,0 +1,217 @@
+package monitors
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// ProcessPayload represents a sample of the OS-level statistics of the process
+type ProcessPayload struct {
+	CPUPercent float64   `json:"cpuPercent"` // CPU usage since the previous sample, 100 per fully used core
+	CPUTime    float64   `json:"cpuTime"`    // user and system CPU time since the process started, in milliseconds
+	NumCPU     int       `json:"numCPU"`     // number of logical CPUs usable by the process
+	RSS        uint64    `json:"rss"`        // bytes of resident memory
+	VMS        uint64    `json:"vms"`        // bytes of virtual memory
+	OpenFDs    int       `json:"openFDs"`    // number of open file descriptors
+	MaxFDs     uint64    `json:"maxFDs"`     // soft limit of the number of open file descriptors
+	Threads    int       `json:"threads"`    // number of OS threads
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// processStats are the statistics read by readProcessStats.
+type processStats struct {
+	cpuTime time.Duration
+	rss     uint64
+	vms     uint64
+	openFDs int
+	maxFDs  uint64
+	threads int
+}
+
+// errProcessStatsUnsupported is returned by readProcessStats on the platforms it is not implemented for.
+var errProcessStatsUnsupported = errors.New("monitors: process statistics are not supported on this platform")
+
+// DefaultProcessSampleInterval is the default interval of the process samples.
+const DefaultProcessSampleInterval = 5 * time.Second
+
+// ProcessMonitorConfig defines the config for Process monitor.
+type ProcessMonitorConfig struct {
+	// Interval is the interval of the samples.
+	// Optional. Default: DefaultProcessSampleInterval
+	Interval time.Duration
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+}
+
+//go:embed process.html
+var processView string
+
+// processViewTemplate is the parsed template for the process view
+var processViewTemplate = template.Must(template.New("processView").Funcs(debugmonitor.TemplateFuncs).Parse(processView))
+
+// ProcessMonitor is a plugin that samples the CPU usage, resident memory, open file descriptors and threads
+// of the process in the background, so that resource exhaustion outside the Go heap is visible.
+// The statistics are read from /proc on Linux. On other platforms, no samples are recorded.
+// Add it with debugmonitor.Manager.AddPlugin.
+type ProcessMonitor struct {
+	monitor  *debugmonitor.Monitor
+	interval time.Duration
+	previous *processStats
+	sampled  time.Time
+}
+
+// NewProcessMonitor creates a new monitor for OS process statistics.
+func NewProcessMonitor(config ProcessMonitorConfig) *ProcessMonitor {
+	if config.Interval <= 0 {
+		config.Interval = DefaultProcessSampleInterval
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "process",
+		DisplayName: "Process",
+		MaxRecords:  720,
+		Icon:        debugmonitor.IconCpuChip,
+		Group:       "Runtime",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, processViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStream(c, store)
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSON(c, store)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPoll(c, store)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	return &ProcessMonitor{
+		monitor:  m,
+		interval: config.Interval,
+	}
+}
+
+// Monitor implements debugmonitor.MonitorPlugin.
+func (pm *ProcessMonitor) Monitor() *debugmonitor.Monitor {
+	return pm.monitor
+}
+
+// Assets implements debugmonitor.MonitorPlugin.
+func (pm *ProcessMonitor) Assets() fs.FS {
+	return nil
+}
+
+// Run implements debugmonitor.MonitorPluginRunner. It records a sample at each interval until ctx is canceled.
+// It returns immediately if the statistics are not supported on the platform.
+func (pm *ProcessMonitor) Run(ctx context.Context) {
+	if err := pm.sample(); errors.Is(err, errProcessStatsUnsupported) {
+		return
+	}
+
+	ticker := time.NewTicker(pm.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = pm.sample()
+		}
+	}
+}
+
+// sample records the current statistics. The CPU usage of the first sample is the average since
+// the process started, since there is no previous sample.
+func (pm *ProcessMonitor) sample() error {
+	stats, err := readProcessStats()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	payload := &ProcessPayload{
+		CPUTime:   float64(stats.cpuTime) / float64(time.Millisecond),
+		NumCPU:    runtime.NumCPU(),
+		RSS:       stats.rss,
+		VMS:       stats.vms,
+		OpenFDs:   stats.openFDs,
+		MaxFDs:    stats.maxFDs,
+		Threads:   stats.threads,
+		Timestamp: now,
+	}
+	if pm.previous != nil {
+		if elapsed := now.Sub(pm.sampled); elapsed > 0 {
+			payload.CPUPercent = float64(stats.cpuTime-pm.previous.cpuTime) / float64(elapsed) * 100
+		}
+	} else if elapsed := time.Since(processStartTime); elapsed > 0 {
+		payload.CPUPercent = float64(stats.cpuTime) / float64(elapsed) * 100
+	}
+	pm.previous = stats
+	pm.sampled = now
+
+	pm.monitor.Add(payload)
+	return nil
+}
+
+// processStartTime approximates the start time of the process with the initialization of this package.
+var processStartTime = time.Now()
+
+// parseProcStatus reads the resident and virtual memory and the number of threads from the format of
+// /proc/[pid]/status into stats.
+func parseProcStatus(r io.Reader, stats *processStats) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		switch key {
+		case "VmRSS", "VmSize":
+			// The sizes are in kB
+			n, err := strconv.ParseUint(fields[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			if key == "VmRSS" {
+				stats.rss = n * 1024
+			} else {
+				stats.vms = n * 1024
+			}
+		case "Threads":
+			n, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return err
+			}
+			stats.threads = n
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,43 @@
+package monitors
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// readProcessStats reads the statistics of the current process from /proc and the resource usage and limits
+// of the process.
+func readProcessStats() (*processStats, error) {
+	stats := &processStats{}
+
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return nil, err
+	}
+	stats.cpuTime = time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return nil, err
+	}
+	err = parseProcStatus(f, stats)
+	// The file is closed before the file descriptors are counted
+	_ = f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return nil, err
+	}
+	// The directory read above is itself an open file descriptor, which is not counted
+	stats.openFDs = len(fds) - 1
+
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err == nil {
+		stats.maxFDs = limit.Cur
+	}
+	return stats, nil
+}
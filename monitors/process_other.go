@@ -0,0 +1,8 @@
+//go:build !linux
+
+package monitors
+
+// readProcessStats returns errProcessStatsUnsupported, since /proc is only read on Linux.
+func readProcessStats() (*processStats, error) {
+	return nil, errProcessStatsUnsupported
+}
@@ -0,0 +1,62 @@
+package monitors
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+func TestProcessMonitor(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process statistics are only supported on Linux")
+	}
+	m := debugmonitor.New()
+	defer m.Close()
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	m.AddPlugin(NewProcessMonitor(ProcessMonitorConfig{Interval: 10 * time.Millisecond}))
+
+	first := (<-sub.C).Entry.Payload.(*ProcessPayload)
+	if first.RSS == 0 || first.VMS == 0 || first.Threads == 0 || first.OpenFDs == 0 || first.NumCPU == 0 {
+		t.Errorf("Unexpected sample: %+v", first)
+	}
+
+	// An opened file is counted in the next samples
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-sub.C:
+			sample := event.Entry.Payload.(*ProcessPayload)
+			if sample.OpenFDs <= first.OpenFDs {
+				continue
+			}
+			if sample.CPUTime < first.CPUTime || sample.CPUPercent < 0 {
+				t.Errorf("Unexpected CPU usage: %+v", sample)
+			}
+			return
+		case <-timeout:
+			t.Fatal("Expected a sample with the opened file")
+		}
+	}
+}
+
+func TestParseProcStatus(t *testing.T) {
+	status := "Name:\tserver\nVmSize:\t  123456 kB\nVmRSS:\t   2048 kB\nThreads:\t12\nSigQ:\t0/63304\n"
+	stats := &processStats{}
+	if err := parseProcStatus(strings.NewReader(status), stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.vms != 123456*1024 || stats.rss != 2048*1024 || stats.threads != 12 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,235 @@
+package monitors
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// ProfilePayload represents a profile captured on demand
+type ProfilePayload struct {
+	Type      string    `json:"type"`              // cpu, heap, allocs, block, mutex or goroutine
+	Seconds   int       `json:"seconds,omitempty"` // duration of the capture, zero for snapshots
+	Size      int       `json:"size"`              // size of the profile, in bytes
+	Timestamp time.Time `json:"timestamp"`         // when the capture started
+
+	// data is the profile in the gzipped protobuf format read by "go tool pprof".
+	// It is served by the download action instead of the data endpoints.
+	data []byte
+}
+
+// DefaultProfileSeconds is the default duration of the CPU, block and mutex profile captures.
+const DefaultProfileSeconds = 10
+
+// DefaultMaxProfileSeconds is the default maximum duration of a capture.
+const DefaultMaxProfileSeconds = 60
+
+// ProfilesMonitorConfig defines the config for Profiles monitor.
+type ProfilesMonitorConfig struct {
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+	// MaxSeconds is the maximum duration of a capture requested by the "seconds" parameter.
+	// Optional. Default: DefaultMaxProfileSeconds
+	MaxSeconds int
+	// MaxRecords is the maximum number of profiles to keep. Profiles can be large, so it is small by default.
+	// Optional. Default: 20
+	MaxRecords int
+	// BlockProfileRate is the rate the application passes to runtime.SetBlockProfileRate, if any.
+	// The runtime does not report the current rate, so block captures rely on it to keep the rate of
+	// the application instead of turning the sampling off after the capture.
+	// Optional. Default: 0 (the block profile is not sampled by the application)
+	BlockProfileRate int
+}
+
+//go:embed profiles.html
+var profilesView string
+
+// profilesViewTemplate is the parsed template for the profiles view
+var profilesViewTemplate = template.Must(template.New("profilesView").Funcs(debugmonitor.TemplateFuncs).Parse(profilesView))
+
+// NewProfilesMonitor creates a new monitor that captures CPU, heap, block, mutex and goroutine profiles on demand
+// and keeps them as downloadable entries, in place of mounting net/http/pprof separately.
+// Captures are never available in production-safe mode.
+func NewProfilesMonitor(config ProfilesMonitorConfig) *debugmonitor.Monitor {
+	if config.MaxSeconds <= 0 {
+		config.MaxSeconds = DefaultMaxProfileSeconds
+	}
+	if config.MaxRecords <= 0 {
+		config.MaxRecords = 20
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "profiles",
+		DisplayName: "Profiles",
+		MaxRecords:  config.MaxRecords,
+		Icon:        debugmonitor.IconCpuChip,
+		Group:       "Runtime",
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, profilesViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+					"EnableCapture":   !m.IsProductionSafe(),
+					"MaxSeconds":      config.MaxSeconds,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStream(c, store)
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSON(c, store)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPoll(c, store)
+			case "capture":
+				return handleProfileCapture(c, m, &config)
+			case "download":
+				return handleProfileDownload(c, store)
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+	return m
+}
+
+// handleProfileCapture captures the profile of the "type" parameter and records it.
+// CPU, block and mutex profiles are captured for the number of seconds of the "seconds" parameter,
+// so the request takes that long. The other profiles are snapshots.
+func handleProfileCapture(c echo.Context, m *debugmonitor.Monitor, config *ProfilesMonitorConfig) error {
+	if c.Request().Method != http.MethodPost {
+		return echo.NewHTTPError(http.StatusMethodNotAllowed)
+	}
+	if m.IsProductionSafe() {
+		return echo.NewHTTPError(http.StatusForbidden, "profile capture is disabled")
+	}
+
+	payload := &ProfilePayload{
+		Type:      c.QueryParam("type"),
+		Timestamp: time.Now(),
+	}
+	switch payload.Type {
+	case "cpu", "block", "mutex":
+		payload.Seconds = DefaultProfileSeconds
+		if v := c.QueryParam("seconds"); v != "" {
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds <= 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid seconds")
+			}
+			payload.Seconds = seconds
+		}
+		if payload.Seconds > config.MaxSeconds {
+			payload.Seconds = config.MaxSeconds
+		}
+	case "heap", "allocs", "goroutine":
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid type")
+	}
+
+	data, err := captureProfile(c, config, payload.Type, time.Duration(payload.Seconds)*time.Second)
+	if err != nil {
+		return err
+	}
+	payload.data = data
+	payload.Size = len(data)
+
+	m.Add(payload)
+	return c.JSON(http.StatusOK, payload)
+}
+
+// samplingMu serializes the captures of block and mutex profiles, which change the sampling rates of the runtime
+// for their duration. Without it, a capture that ends would turn the sampling off under another running capture.
+var samplingMu sync.Mutex
+
+// captureProfile writes the profile of the given type, waiting for d for the profiles that are captured over time.
+// The wait ends early if the client goes away.
+func captureProfile(c echo.Context, config *ProfilesMonitorConfig, profileType string, d time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+	wait := func() error {
+		select {
+		case <-time.After(d):
+			return nil
+		case <-c.Request().Context().Done():
+			return c.Request().Context().Err()
+		}
+	}
+
+	switch profileType {
+	case "cpu":
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			// Another CPU profile is running
+			return nil, echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		err := wait()
+		pprof.StopCPUProfile()
+		if err != nil {
+			return nil, err
+		}
+	case "block":
+		samplingMu.Lock()
+		defer samplingMu.Unlock()
+		// Turn the sampling on for the capture unless the application has already set a rate.
+		// The runtime does not report the current rate, so it is given by the config.
+		if config.BlockProfileRate <= 0 {
+			runtime.SetBlockProfileRate(1)
+			defer runtime.SetBlockProfileRate(0)
+		}
+		if err := wait(); err != nil {
+			return nil, err
+		}
+		if err := pprof.Lookup("block").WriteTo(&buf, 0); err != nil {
+			return nil, err
+		}
+	case "mutex":
+		samplingMu.Lock()
+		defer samplingMu.Unlock()
+		// Turn the sampling on for the capture unless the application has already set a fraction
+		if previous := runtime.SetMutexProfileFraction(1); previous > 0 {
+			runtime.SetMutexProfileFraction(previous)
+		} else {
+			defer runtime.SetMutexProfileFraction(0)
+		}
+		if err := wait(); err != nil {
+			return nil, err
+		}
+		if err := pprof.Lookup("mutex").WriteTo(&buf, 0); err != nil {
+			return nil, err
+		}
+	default:
+		if profileType == "heap" {
+			// Report the heap as of the last GC like net/http/pprof with gc=1
+			runtime.GC()
+		}
+		if err := pprof.Lookup(profileType).WriteTo(&buf, 0); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// handleProfileDownload serves the profile of the entry given by the "id" parameter as a file
+// to open with "go tool pprof".
+func handleProfileDownload(c echo.Context, store *debugmonitor.Store) error {
+	entry, err := debugmonitor.GetEntryFromQuery(c, store)
+	if err != nil {
+		return err
+	}
+	payload := entry.Payload.(*ProfilePayload)
+
+	name := fmt.Sprintf("%s-%s.pb.gz", payload.Type, payload.Timestamp.Format("20060102-150405"))
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name))
+	return c.Blob(http.StatusOK, "application/octet-stream", payload.data)
+}
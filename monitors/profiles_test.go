@@ -0,0 +1,130 @@
+package monitors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+func TestProfilesMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	m.AddMonitor(NewProfilesMonitor(ProfilesMonitorConfig{}))
+
+	e := echo.New()
+	e.Any("/monitor", m.Handler())
+
+	post := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+		req.Header.Set("X-CSRF-Token", "test-token")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("/monitor?monitor=profiles&action=capture&type=unknown"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown type, got %d", rec.Code)
+	}
+
+	rec := post("/monitor?monitor=profiles&action=capture&type=heap")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload ProfilePayload
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Type != "heap" || payload.Size == 0 {
+		t.Errorf("Unexpected profile: %+v", payload)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=profiles&action=data", nil))
+	var entries []struct {
+		Id int64 `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 profile, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=profiles&action=download&id="+strconv.FormatInt(entries[0].Id, 10), nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() != payload.Size {
+		t.Errorf("Expected the profile of %d bytes, got %d: %d bytes", payload.Size, rec.Code, rec.Body.Len())
+	}
+	// The profile is gzipped
+	if b := rec.Body.Bytes(); len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		t.Errorf("Expected a gzipped profile")
+	}
+
+	ps := debugmonitor.NewProductionSafe()
+	ps.AddMonitor(NewProfilesMonitor(ProfilesMonitorConfig{}))
+	e = echo.New()
+	e.Any("/monitor", ps.Handler())
+	if rec := post("/monitor?monitor=profiles&action=capture&type=heap"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 in production-safe mode, got %d", rec.Code)
+	}
+}
+
+// blockOnChannel blocks the calling goroutine on a channel for a short time
+func blockOnChannel() {
+	ch := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(ch)
+	}()
+	<-ch
+}
+
+func TestProfilesMonitor_BlockProfileRate(t *testing.T) {
+	capture := func(config ProfilesMonitorConfig) {
+		m := debugmonitor.New()
+		m.AddMonitor(NewProfilesMonitor(config))
+		e := echo.New()
+		e.Any("/monitor", m.Handler())
+		req := httptest.NewRequest(http.MethodPost, "/monitor?monitor=profiles&action=capture&type=block&seconds=1", nil)
+		req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+		req.Header.Set("X-CSRF-Token", "test-token")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	events := func() int64 {
+		records := make([]runtime.BlockProfileRecord, pprof.Lookup("block").Count()+16)
+		n, _ := runtime.BlockProfile(records)
+		var count int64
+		for _, r := range records[:n] {
+			count += r.Count
+		}
+		return count
+	}
+	sampled := func() bool {
+		before := events()
+		blockOnChannel()
+		return events() > before
+	}
+
+	// The sampling is turned off after the capture
+	capture(ProfilesMonitorConfig{})
+	if sampled() {
+		t.Errorf("Expected the block profile not to be sampled after the capture")
+	}
+
+	// The rate of the application is kept
+	runtime.SetBlockProfileRate(1)
+	defer runtime.SetBlockProfileRate(0)
+	capture(ProfilesMonitorConfig{BlockProfileRate: 1})
+	if !sampled() {
+		t.Errorf("Expected the block profile to be sampled at the rate of the application after the capture")
+	}
+}
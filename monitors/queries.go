@@ -5,9 +5,14 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	_ "embed"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
@@ -16,19 +21,56 @@ import (
 
 // QueryPayload represents the data structure for database query monitoring
 type QueryPayload struct {
-	Query     string        `json:"query"`
-	Args      []interface{} `json:"args,omitempty"`
-	Duration  int64         `json:"duration"` // in milliseconds
-	Error     string        `json:"error,omitempty"`
-	Timestamp time.Time     `json:"timestamp"`
-	Operation string        `json:"operation"` // Query, Exec, Prepare, Begin, Commit, Rollback
+	Query       string        `json:"query"`
+	Args        []interface{} `json:"args,omitempty"`
+	Duration    int64         `json:"duration"` // in milliseconds
+	Error       string        `json:"error,omitempty"`
+	Timestamp   time.Time     `json:"timestamp"`
+	Operation   string        `json:"operation"`             // Query, Exec, Prepare, Begin, Commit, Rollback
+	RequestID   string        `json:"requestId,omitempty"`   // ID of the request the query was executed in
+	Slow        bool          `json:"slow,omitempty"`        // whether the query took SlowThreshold or longer
+	Count       int           `json:"count,omitempty"`       // number of runs summarized by an N+1 or Duplicate entry
+	Database    string        `json:"database,omitempty"`    // name of the database the query was executed on
+	Table       string        `json:"table,omitempty"`       // table the query primarily reads or writes
+	Caller      string        `json:"caller,omitempty"`      // file:line of the application code that ran the query
+	StatementID int64         `json:"statementId,omitempty"` // ID of the prepared statement the query prepared or ran
+}
+
+// TimelineRequestID implements debugmonitor.TimelinePayload.
+func (p *QueryPayload) TimelineRequestID() string { return p.RequestID }
+
+// TimelineTime implements debugmonitor.TimelinePayload.
+func (p *QueryPayload) TimelineTime() time.Time { return p.Timestamp }
+
+// TimelineSummary implements debugmonitor.TimelinePayload.
+func (p *QueryPayload) TimelineSummary() string {
+	if p.Query == "" {
+		return fmt.Sprintf("%s (%dms)", p.Operation, p.Duration)
+	}
+	return fmt.Sprintf("%s: %s (%dms)", p.Operation, p.Query, p.Duration)
 }
 
 //go:embed queries.html
 var queriesView string
 
 // queriesViewTemplate is the parsed template for the queries view
-var queriesViewTemplate = template.Must(template.New("queriesView").Parse(queriesView))
+var queriesViewTemplate = template.Must(template.New("queriesView").Funcs(debugmonitor.TemplateFuncs).Parse(queriesView))
+
+//go:embed queries_stats.html
+var queryStatsView string
+
+// queryStatsViewTemplate is the parsed template for the per-fingerprint stats view
+var queryStatsViewTemplate = template.Must(template.New("queryStatsView").Funcs(debugmonitor.TemplateFuncs).Parse(queryStatsView))
+
+//go:embed queries_explain.html
+var queryExplainView string
+
+// queryExplainViewTemplate is the parsed template for the query plan view
+var queryExplainViewTemplate = template.Must(template.New("queryExplainView").Funcs(debugmonitor.TemplateFuncs).Parse(queryExplainView))
+
+// DefaultNPlusOneThreshold is the default number of runs of the same statement during a request
+// at which it is recorded as an N+1 suspect.
+const DefaultNPlusOneThreshold = 5
 
 // QueriesMonitorConfig defines the config for Queries monitor.
 type QueriesMonitorConfig struct {
@@ -36,75 +78,246 @@ type QueriesMonitorConfig struct {
 	DSN string
 	// Driver is the database driver to wrap with monitoring.
 	Driver driver.Driver
+	// Connector is the connector to wrap with monitoring, for drivers that are only usable through
+	// a driver.Connector. If set, DSN and Driver are ignored.
+	Connector driver.Connector
 	// UsePolling enables polling mode instead of SSE for real-time updates.
 	UsePolling bool
+	// NPlusOneThreshold is the number of times the same statement must run during a request to be recorded
+	// as an N+1 suspect, or as a duplicate if every run had the same arguments. Detection requires the
+	// requests monitor, which correlates queries to requests.
+	// Optional. Default: DefaultNPlusOneThreshold. A negative value disables detection.
+	NPlusOneThreshold int
+	// SlowThreshold is the duration at or above which a query is flagged as slow.
+	// Durations are compared in milliseconds. Zero disables the flag.
+	SlowThreshold time.Duration
+	// EnableExplain enables the explain action, which re-runs a recorded SELECT query prefixed with EXPLAIN
+	// against the monitored database. It is always disabled in production-safe mode.
+	EnableExplain bool
+	// Dialect is the SQL dialect of the database used to build EXPLAIN statements:
+	// DialectSQLite, DialectMySQL or DialectPostgres.
+	// Optional. Default: detected from the package path of the driver.
+	Dialect string
+	// ArgSanitizer rewrites the arguments of a query before they are recorded, so that sensitive bind values
+	// never reach the store. Named arguments are passed as "name=value" strings.
+	// Optional. Default: MaskSensitiveArgs.
+	ArgSanitizer func(query string, args []any) []any
+	// Database is the name of the database, such as "primary", recorded with its queries.
+	// Optional.
+	Database string
+	// CaptureCaller enables recording the file and line of the application code that ran each query.
+	// It walks the stack of every query, so it adds overhead.
+	CaptureCaller bool
+	// IgnoreStatements are the prefixes of statements that are not recorded, such as "SET search_path".
+	// Prefixes are matched case-insensitively, ignoring leading whitespace.
+	// Pings and connection establishment are never recorded.
+	IgnoreStatements []string
+	// IgnoreSessionStatements skips recording the session statements in SessionStatements,
+	// which some drivers issue on their own when they open or reset a connection.
+	IgnoreSessionStatements bool
+}
+
+// SessionStatements are the prefixes of the session statements skipped by IgnoreSessionStatements.
+var SessionStatements = []string{"SET ", "SHOW ", "RESET ", "SELECT @@", "SELECT VERSION()", "SELECT CURRENT_SCHEMA"}
+
+// QueriesDatabase defines a database monitored by a queries monitor created with NewMultiQueriesMonitor.
+type QueriesDatabase struct {
+	// Name is the name of the database, such as "primary" or "replica", recorded with its queries.
+	// It must be unique among the databases of the monitor.
+	Name string
+	// DSN is the data source name for the database connection.
+	DSN string
+	// Driver is the database driver to wrap with monitoring.
+	Driver driver.Driver
+	// Connector is the connector to wrap with monitoring. If set, DSN and Driver are ignored.
+	Connector driver.Connector
+	// Dialect is the SQL dialect of the database used to build EXPLAIN statements.
+	// Optional. Default: QueriesMonitorConfig.Dialect, or detected from the package path of the driver.
+	Dialect string
+}
+
+// queriesDatabase is a monitored database and its dialect.
+type queriesDatabase struct {
+	db      *sql.DB
+	dialect string
 }
 
 // NewQueriesMonitor creates a new monitor for database queries and returns a wrapped *sql.DB.
 // This function wraps an existing database driver with monitoring capabilities without requiring
 // changes to existing *sql.DB usage code.
 func NewQueriesMonitor(config QueriesMonitorConfig) (*debugmonitor.Monitor, *sql.DB) {
-	m := &debugmonitor.Monitor{
+	m, dbs := NewMultiQueriesMonitor(config, QueriesDatabase{
+		Name:      config.Database,
+		DSN:       config.DSN,
+		Driver:    config.Driver,
+		Connector: config.Connector,
+		Dialect:   config.Dialect,
+	})
+	return m, dbs[config.Database]
+}
+
+// WrapConnector creates a new monitor for database queries that wraps an existing driver.Connector,
+// such as a connection pool or a cloud SQL connector, and returns the wrapped *sql.DB.
+// Use NewQueriesMonitor with QueriesMonitorConfig.Connector to configure the monitor further.
+func WrapConnector(c driver.Connector) (*debugmonitor.Monitor, *sql.DB) {
+	return NewQueriesMonitor(QueriesMonitorConfig{Connector: c})
+}
+
+// NewMultiQueriesMonitor creates a new monitor for the queries of several databases and returns a wrapped *sql.DB
+// for each database by name. The Driver, DSN, Connector and Database fields of the config are ignored in favor of the databases.
+func NewMultiQueriesMonitor(config QueriesMonitorConfig, databases ...QueriesDatabase) (*debugmonitor.Monitor, map[string]*sql.DB) {
+	if config.NPlusOneThreshold == 0 {
+		config.NPlusOneThreshold = DefaultNPlusOneThreshold
+	}
+	if config.ArgSanitizer == nil {
+		config.ArgSanitizer = MaskSensitiveArgs
+	}
+	if config.IgnoreSessionStatements {
+		config.IgnoreStatements = append(append([]string{}, config.IgnoreStatements...), SessionStatements...)
+	}
+
+	// stats holds the per-fingerprint aggregates of the queries
+	stats := newQueryStats()
+	// statementIDs generates the IDs of prepared statements
+	statementIDs := new(atomic.Int64)
+
+	// m and dbs are declared first so that the action handler can refer to them
+	var m *debugmonitor.Monitor
+	dbs := make(map[string]*queriesDatabase, len(databases))
+	names := make([]string, 0, len(databases))
+	m = &debugmonitor.Monitor{
 		Name:        "queries",
 		DisplayName: "Queries",
 		MaxRecords:  1000,
 		Icon:        debugmonitor.IconCircleStack,
+		Group:       "Database",
 		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
 			switch action {
 			case "render":
 				return debugmonitor.RenderTemplate(c, queriesViewTemplate, map[string]any{
-					"UsePolling": config.UsePolling,
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+					"EnableExplain":   config.EnableExplain && !m.IsProductionSafe(),
+					"Databases":       names,
 				})
 			case "stream":
 				// SSE endpoint for real-time updates
-				return debugmonitor.HandleSSEStream(c, store)
+				return debugmonitor.HandleSSEStreamWithFilter(c, store, queriesFilter(c))
 			case "data":
 				// JSON endpoint for polling mode
-				return debugmonitor.HandleDataJSON(c, store)
+				return debugmonitor.HandleDataJSONWithFilter(c, store, queriesFilter(c))
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPollWithFilter(c, store, queriesFilter(c))
+			case "stats":
+				// Per-fingerprint aggregates ranked by total duration, as JSON with format=json or as an HTML fragment.
+				// POST resets the aggregates.
+				if c.Request().Method == http.MethodPost {
+					stats.reset()
+					return c.NoContent(http.StatusNoContent)
+				}
+				fingerprints := stats.snapshot()
+				if c.QueryParam("format") == "json" {
+					return c.JSON(http.StatusOK, fingerprints)
+				}
+				return debugmonitor.RenderTemplate(c, queryStatsViewTemplate, map[string]any{
+					"Fingerprints": fingerprints,
+				})
+			case "explain":
+				return handleExplain(c, m, store, &config, dbs)
 			default:
 				return echo.NewHTTPError(http.StatusBadRequest)
 			}
 		},
 	}
 
-	// Create a monitored connector
-	connector := &monitoredConnector{
-		driver:  config.Driver,
-		dsn:     config.DSN,
-		monitor: m,
-	}
+	result := make(map[string]*sql.DB, len(databases))
+	for _, database := range databases {
+		dialect := database.Dialect
+		if dialect == "" {
+			dialect = config.Dialect
+		}
+		if database.Connector != nil {
+			database.Driver = database.Connector.Driver()
+		}
+		if dialect == "" {
+			dialect = detectDialect(database.Driver)
+		}
 
-	// Open database with the monitored connector
-	db := sql.OpenDB(connector)
+		// Create a monitored connector
+		connector := &monitoredConnector{
+			connector: database.Connector,
+			driver:    database.Driver,
+			dsn:       database.DSN,
+			recorder:  &queryRecorder{monitor: m, config: &config, stats: stats, statementIDs: statementIDs, database: database.Name},
+		}
 
-	return m, db
+		// Open database with the monitored connector
+		db := sql.OpenDB(connector)
+		dbs[database.Name] = &queriesDatabase{db: db, dialect: dialect}
+		result[database.Name] = db
+		if database.Name != "" {
+			names = append(names, database.Name)
+		}
+	}
+
+	return m, result
 }
 
-// monitoredConnector implements driver.Connector
+// monitoredConnector implements driver.Connector.
+// It opens connections with the wrapped connector if there is one, or with the driver and the DSN otherwise.
 type monitoredConnector struct {
-	driver  driver.Driver
-	dsn     string
-	monitor *debugmonitor.Monitor
+	connector driver.Connector
+	driver    driver.Driver
+	dsn       string
+	recorder  *queryRecorder
 }
 
 func (c *monitoredConnector) Connect(ctx context.Context) (driver.Conn, error) {
-	conn, err := c.driver.Open(c.dsn)
+	var conn driver.Conn
+	var err error
+	if c.connector != nil {
+		conn, err = c.connector.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(c.dsn)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return &monitoredConn{conn: conn, monitor: c.monitor}, nil
+	return &monitoredConn{conn: conn, recorder: c.recorder}, nil
 }
 
 func (c *monitoredConnector) Driver() driver.Driver {
 	return c.driver
 }
 
+// Close closes the wrapped connector if it implements io.Closer, as sql.DB.Close does for connectors.
+func (c *monitoredConnector) Close() error {
+	if closer, ok := c.connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 // monitoredConn wraps a sql connection
 type monitoredConn struct {
-	conn    driver.Conn
-	monitor *debugmonitor.Monitor
+	conn     driver.Conn
+	recorder *queryRecorder
 }
 
+// monitoredConn passes the optional interfaces through to the wrapped connection
+// so that wrapping does not change the behavior of the driver.
+var (
+	_ driver.ConnBeginTx        = (*monitoredConn)(nil)
+	_ driver.ConnPrepareContext = (*monitoredConn)(nil)
+	_ driver.ExecerContext      = (*monitoredConn)(nil)
+	_ driver.QueryerContext     = (*monitoredConn)(nil)
+	_ driver.NamedValueChecker  = (*monitoredConn)(nil)
+	_ driver.SessionResetter    = (*monitoredConn)(nil)
+	_ driver.Validator          = (*monitoredConn)(nil)
+	_ driver.Pinger             = (*monitoredConn)(nil)
+)
+
 func (c *monitoredConn) Prepare(query string) (driver.Stmt, error) {
 	start := time.Now()
 	stmt, err := c.conn.Prepare(query)
@@ -118,13 +331,15 @@ func (c *monitoredConn) Prepare(query string) (driver.Stmt, error) {
 	}
 	if err != nil {
 		payload.Error = err.Error()
+	} else {
+		payload.StatementID = c.recorder.statementIDs.Add(1)
 	}
-	c.monitor.Add(payload)
+	c.recorder.record(payload)
 
 	if err != nil {
 		return nil, err
 	}
-	return &monitoredStmt{stmt: stmt, query: query, monitor: c.monitor}, nil
+	return &monitoredStmt{stmt: stmt, id: payload.StatementID, conn: c.conn, query: query, recorder: c.recorder}, nil
 }
 
 func (c *monitoredConn) Close() error {
@@ -145,17 +360,139 @@ func (c *monitoredConn) Begin() (driver.Tx, error) {
 	if err != nil {
 		payload.Error = err.Error()
 	}
-	c.monitor.Add(payload)
+	c.recorder.record(payload)
 
 	if err != nil {
 		return nil, err
 	}
-	return &monitoredTx{tx: tx, monitor: c.monitor}, nil
+	return &monitoredTx{tx: tx, recorder: c.recorder}, nil
+}
+
+// Implement ConnBeginTx interface
+// Drivers without BeginTx only support the default isolation level and read-write transactions,
+// as database/sql enforces for them.
+func (c *monitoredConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	beginner, ok := c.conn.(driver.ConnBeginTx)
+	if !ok {
+		if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+			return nil, errors.New("sql: driver does not support non-default isolation level")
+		}
+		if opts.ReadOnly {
+			return nil, errors.New("sql: driver does not support read-only transactions")
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if recordingDisabled(ctx) {
+			return c.conn.Begin()
+		}
+		return c.Begin()
+	}
+	if recordingDisabled(ctx) {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	start := time.Now()
+	tx, err := beginner.BeginTx(ctx, opts)
+	duration := time.Since(start)
+
+	payload := &QueryPayload{
+		Query:     "BEGIN",
+		Duration:  duration.Milliseconds(),
+		Timestamp: start,
+		Operation: "Begin",
+		RequestID: debugmonitor.RequestIDFromContext(ctx),
+	}
+	if err != nil {
+		payload.Error = err.Error()
+	}
+	c.recorder.record(payload)
+
+	if err != nil {
+		return nil, err
+	}
+	return &monitoredTx{tx: tx, recorder: c.recorder}, nil
+}
+
+// Implement ConnPrepareContext interface
+func (c *monitoredConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	preparer, ok := c.conn.(driver.ConnPrepareContext)
+	if recordingDisabled(ctx) {
+		if ok {
+			return preparer.PrepareContext(ctx, query)
+		}
+		return c.conn.Prepare(query)
+	}
+	if !ok {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return c.Prepare(query)
+	}
+
+	start := time.Now()
+	stmt, err := preparer.PrepareContext(ctx, query)
+	duration := time.Since(start)
+
+	payload := &QueryPayload{
+		Query:     query,
+		Duration:  duration.Milliseconds(),
+		Timestamp: start,
+		Operation: "Prepare",
+		RequestID: debugmonitor.RequestIDFromContext(ctx),
+	}
+	if err != nil {
+		payload.Error = err.Error()
+	} else {
+		payload.StatementID = c.recorder.statementIDs.Add(1)
+	}
+	c.recorder.record(payload)
+
+	if err != nil {
+		return nil, err
+	}
+	return &monitoredStmt{stmt: stmt, id: payload.StatementID, conn: c.conn, query: query, recorder: c.recorder}, nil
+}
+
+// Implement NamedValueChecker interface
+// driver.ErrSkip makes database/sql fall back to its default conversion.
+func (c *monitoredConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// Implement SessionResetter interface
+func (c *monitoredConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// Implement Validator interface
+func (c *monitoredConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// Implement Pinger interface
+func (c *monitoredConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
 }
 
 // Implement ExecerContext interface
 func (c *monitoredConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
 	if execer, ok := c.conn.(driver.ExecerContext); ok {
+		if recordingDisabled(ctx) {
+			return execer.ExecContext(ctx, query, args)
+		}
 		start := time.Now()
 		result, err := execer.ExecContext(ctx, query, args)
 		duration := time.Since(start)
@@ -166,11 +503,16 @@ func (c *monitoredConn) ExecContext(ctx context.Context, query string, args []dr
 			Duration:  duration.Milliseconds(),
 			Timestamp: start,
 			Operation: "Exec",
+			RequestID: debugmonitor.RequestIDFromContext(ctx),
 		}
 		if err != nil {
 			payload.Error = err.Error()
 		}
-		c.monitor.Add(payload)
+		// The query is tracked before it is recorded, which sanitizes the arguments
+		if t := queryTrackerFromContext(ctx); t != nil {
+			t.add(c.recorder, payload, duration)
+		}
+		c.recorder.record(payload)
 
 		return result, err
 	}
@@ -180,6 +522,9 @@ func (c *monitoredConn) ExecContext(ctx context.Context, query string, args []dr
 // Implement QueryerContext interface
 func (c *monitoredConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
 	if queryer, ok := c.conn.(driver.QueryerContext); ok {
+		if recordingDisabled(ctx) {
+			return queryer.QueryContext(ctx, query, args)
+		}
 		start := time.Now()
 		rows, err := queryer.QueryContext(ctx, query, args)
 		duration := time.Since(start)
@@ -190,11 +535,16 @@ func (c *monitoredConn) QueryContext(ctx context.Context, query string, args []d
 			Duration:  duration.Milliseconds(),
 			Timestamp: start,
 			Operation: "Query",
+			RequestID: debugmonitor.RequestIDFromContext(ctx),
 		}
 		if err != nil {
 			payload.Error = err.Error()
 		}
-		c.monitor.Add(payload)
+		// The query is tracked before it is recorded, which sanitizes the arguments
+		if t := queryTrackerFromContext(ctx); t != nil {
+			t.add(c.recorder, payload, duration)
+		}
+		c.recorder.record(payload)
 
 		return rows, err
 	}
@@ -203,11 +553,21 @@ func (c *monitoredConn) QueryContext(ctx context.Context, query string, args []d
 
 // monitoredStmt wraps a sql statement
 type monitoredStmt struct {
-	stmt    driver.Stmt
-	query   string
-	monitor *debugmonitor.Monitor
+	stmt     driver.Stmt
+	id       int64       // ID recorded as the StatementID of the entries of the statement
+	conn     driver.Conn // connection the statement was prepared on
+	query    string
+	recorder *queryRecorder
 }
 
+// monitoredStmt passes the optional interfaces through to the wrapped statement
+// so that wrapping does not change the behavior of the driver.
+var (
+	_ driver.StmtExecContext   = (*monitoredStmt)(nil)
+	_ driver.StmtQueryContext  = (*monitoredStmt)(nil)
+	_ driver.NamedValueChecker = (*monitoredStmt)(nil)
+)
+
 func (s *monitoredStmt) Close() error {
 	return s.stmt.Close()
 }
@@ -222,16 +582,17 @@ func (s *monitoredStmt) Exec(args []driver.Value) (driver.Result, error) {
 	duration := time.Since(start)
 
 	payload := &QueryPayload{
-		Query:     s.query,
-		Args:      valuesToInterface(args),
-		Duration:  duration.Milliseconds(),
-		Timestamp: start,
-		Operation: "Exec",
+		Query:       s.query,
+		StatementID: s.id,
+		Args:        valuesToInterface(args),
+		Duration:    duration.Milliseconds(),
+		Timestamp:   start,
+		Operation:   "Exec",
 	}
 	if err != nil {
 		payload.Error = err.Error()
 	}
-	s.monitor.Add(payload)
+	s.recorder.record(payload)
 
 	return result, err
 }
@@ -242,24 +603,124 @@ func (s *monitoredStmt) Query(args []driver.Value) (driver.Rows, error) {
 	duration := time.Since(start)
 
 	payload := &QueryPayload{
-		Query:     s.query,
-		Args:      valuesToInterface(args),
-		Duration:  duration.Milliseconds(),
-		Timestamp: start,
-		Operation: "Query",
+		Query:       s.query,
+		StatementID: s.id,
+		Args:        valuesToInterface(args),
+		Duration:    duration.Milliseconds(),
+		Timestamp:   start,
+		Operation:   "Query",
 	}
 	if err != nil {
 		payload.Error = err.Error()
 	}
-	s.monitor.Add(payload)
+	s.recorder.record(payload)
 
 	return rows, err
 }
 
+// Implement StmtExecContext interface
+// Statements without ExecContext get the arguments as values, which rejects named arguments
+// as database/sql does for them.
+func (s *monitoredStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := s.stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				result, err = s.stmt.Exec(values)
+			}
+		}
+	}
+	duration := time.Since(start)
+	// The statement may have been prepared with a context that records the queries
+	if recordingDisabled(ctx) {
+		return result, err
+	}
+
+	payload := &QueryPayload{
+		Query:       s.query,
+		StatementID: s.id,
+		Args:        namedValuesToInterface(args),
+		Duration:    duration.Milliseconds(),
+		Timestamp:   start,
+		Operation:   "Exec",
+		RequestID:   debugmonitor.RequestIDFromContext(ctx),
+	}
+	if err != nil {
+		payload.Error = err.Error()
+	}
+	// The query is tracked before it is recorded, which sanitizes the arguments
+	if t := queryTrackerFromContext(ctx); t != nil {
+		t.add(s.recorder, payload, duration)
+	}
+	s.recorder.record(payload)
+
+	return result, err
+}
+
+// Implement StmtQueryContext interface
+func (s *monitoredStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				rows, err = s.stmt.Query(values)
+			}
+		}
+	}
+	duration := time.Since(start)
+	// The statement may have been prepared with a context that records the queries
+	if recordingDisabled(ctx) {
+		return rows, err
+	}
+
+	payload := &QueryPayload{
+		Query:       s.query,
+		StatementID: s.id,
+		Args:        namedValuesToInterface(args),
+		Duration:    duration.Milliseconds(),
+		Timestamp:   start,
+		Operation:   "Query",
+		RequestID:   debugmonitor.RequestIDFromContext(ctx),
+	}
+	if err != nil {
+		payload.Error = err.Error()
+	}
+	// The query is tracked before it is recorded, which sanitizes the arguments
+	if t := queryTrackerFromContext(ctx); t != nil {
+		t.add(s.recorder, payload, duration)
+	}
+	s.recorder.record(payload)
+
+	return rows, err
+}
+
+// Implement NamedValueChecker interface
+// database/sql asks the statement before the connection, so the connection's checker is used
+// when the statement has none.
+func (s *monitoredStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if checker, ok := s.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
 // monitoredTx wraps a sql transaction
 type monitoredTx struct {
-	tx      driver.Tx
-	monitor *debugmonitor.Monitor
+	tx       driver.Tx
+	recorder *queryRecorder
 }
 
 func (t *monitoredTx) Commit() error {
@@ -276,7 +737,7 @@ func (t *monitoredTx) Commit() error {
 	if err != nil {
 		payload.Error = err.Error()
 	}
-	t.monitor.Add(payload)
+	t.recorder.record(payload)
 
 	return err
 }
@@ -295,7 +756,7 @@ func (t *monitoredTx) Rollback() error {
 	if err != nil {
 		payload.Error = err.Error()
 	}
-	t.monitor.Add(payload)
+	t.recorder.record(payload)
 
 	return err
 }
@@ -309,6 +770,17 @@ func valuesToInterface(values []driver.Value) []interface{} {
 	return result
 }
 
+func namedValuesToValues(values []driver.NamedValue) ([]driver.Value, error) {
+	result := make([]driver.Value, len(values))
+	for i, v := range values {
+		if v.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		result[i] = v.Value
+	}
+	return result, nil
+}
+
 func namedValuesToInterface(values []driver.NamedValue) []interface{} {
 	result := make([]interface{}, len(values))
 	for i, v := range values {
@@ -320,3 +792,102 @@ func namedValuesToInterface(values []driver.NamedValue) []interface{} {
 	}
 	return result
 }
+
+// queriesFilter returns the entry filter for the query parameters of the data and stream actions.
+// The "slow" parameter set to "1" or "true" selects slow queries only, the "database" parameter
+// selects the queries of the named database, the "operation" parameter selects the queries of the
+// operation such as Exec, the "table" parameter selects the queries of the table, and the "statement"
+// parameter selects the preparation and the runs of a prepared statement. It returns nil if no filter is requested.
+func queriesFilter(c echo.Context) debugmonitor.EntryFilter {
+	slow := c.QueryParam("slow") == "1" || c.QueryParam("slow") == "true"
+	database := c.QueryParam("database")
+	operation := c.QueryParam("operation")
+	table := c.QueryParam("table")
+	statement, _ := strconv.ParseInt(c.QueryParam("statement"), 10, 64)
+	if !slow && database == "" && operation == "" && table == "" && statement == 0 {
+		return nil
+	}
+	return func(entry *debugmonitor.DataEntry) bool {
+		payload, ok := entry.Payload.(*QueryPayload)
+		if !ok {
+			return false
+		}
+		if slow && !payload.Slow {
+			return false
+		}
+		if database != "" && payload.Database != database {
+			return false
+		}
+		if operation != "" && !strings.EqualFold(payload.Operation, operation) {
+			return false
+		}
+		if statement != 0 && payload.StatementID != statement {
+			return false
+		}
+		return table == "" || matchTable(payload.Table, table)
+	}
+}
+
+// queryRecorder records the queries executed through the monitored connections.
+type queryRecorder struct {
+	monitor      *debugmonitor.Monitor
+	config       *QueriesMonitorConfig
+	stats        *queryStats
+	statementIDs *atomic.Int64 // shared by the recorders of a monitor
+	database     string
+}
+
+// record adds the query payload to the monitor.
+// Query arguments are sanitized, and dropped in production-safe mode since they often contain sensitive data.
+func (r *queryRecorder) record(payload *QueryPayload) {
+	if r.ignores(payload.Query) {
+		return
+	}
+	payload.Database = r.database
+	if payload.Count == 0 {
+		payload.Table = primaryTable(payload.Query)
+		if r.config.CaptureCaller {
+			payload.Caller = queryCaller()
+		}
+	}
+	if r.monitor.IsProductionSafe() {
+		payload.Args = nil
+	} else if len(payload.Args) > 0 {
+		payload.Args = r.config.ArgSanitizer(payload.Query, payload.Args)
+	}
+	if r.config.SlowThreshold > 0 && time.Duration(payload.Duration)*time.Millisecond >= r.config.SlowThreshold {
+		payload.Slow = true
+	}
+	if payload.Count == 0 {
+		// N+1 and Duplicate entries summarize queries that are already counted
+		r.stats.record(payload)
+	}
+	r.monitor.Add(payload)
+}
+
+// ignores reports whether the statement is one of the IgnoreStatements.
+func (r *queryRecorder) ignores(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n")
+	for _, prefix := range r.config.IgnoreStatements {
+		if len(query) >= len(prefix) && strings.EqualFold(query[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryCallerSkipPrefixes are the prefixes of the functions that are skipped to find the caller of a query:
+// the runtime, database/sql and the wrappers of this package.
+var queryCallerSkipPrefixes = []string{
+	"runtime.",
+	"database/sql.",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.(*monitored",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.(*queryRecorder)",
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors.queryCaller",
+}
+
+// queryCaller returns the file:line of the first frame on the stack that is not in the runtime,
+// database/sql or the query wrappers. It returns an empty string if there is none.
+func queryCaller() string {
+	return callerSkipping(queryCallerSkipPrefixes)
+}
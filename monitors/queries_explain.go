@@ -0,0 +1,159 @@
+package monitors
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// SQL dialects supported by the explain action.
+const (
+	DialectSQLite   = "sqlite"
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+)
+
+// ExplainResult is the plan of a recorded query.
+type ExplainResult struct {
+	Query   string     `json:"query"`
+	Explain string     `json:"explain"`
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// detectDialect guesses the SQL dialect from the package path of the driver.
+// It returns an empty string if the driver is unknown.
+func detectDialect(d driver.Driver) string {
+	if d == nil {
+		return ""
+	}
+	t := reflect.TypeOf(d)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	path := strings.ToLower(t.PkgPath())
+	switch {
+	case strings.Contains(path, "sqlite"):
+		return DialectSQLite
+	case strings.Contains(path, "mysql"):
+		return DialectMySQL
+	case strings.Contains(path, "postgres"), strings.Contains(path, "pgx"), strings.HasSuffix(path, "/pq"):
+		return DialectPostgres
+	default:
+		return ""
+	}
+}
+
+// explainStatement returns the EXPLAIN statement of the query for the dialect.
+// None of the statements execute the query itself.
+func explainStatement(dialect, query string) (string, error) {
+	switch dialect {
+	case DialectSQLite:
+		return "EXPLAIN QUERY PLAN " + query, nil
+	case DialectMySQL, DialectPostgres:
+		return "EXPLAIN " + query, nil
+	default:
+		return "", fmt.Errorf("unsupported dialect %q", dialect)
+	}
+}
+
+// isSelectQuery reports whether the query is a SELECT statement, optionally with a WITH clause.
+func isSelectQuery(query string) bool {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	return strings.HasPrefix(q, "SELECT") || strings.HasPrefix(q, "WITH")
+}
+
+// handleExplain re-runs a recorded SELECT query prefixed with EXPLAIN against the database it was run on
+// and returns the plan as JSON with format=json or as an HTML fragment.
+// The EXPLAIN statement itself is not recorded.
+func handleExplain(c echo.Context, m *debugmonitor.Monitor, store *debugmonitor.Store, config *QueriesMonitorConfig, dbs map[string]*queriesDatabase) error {
+	if c.Request().Method != http.MethodPost {
+		return echo.NewHTTPError(http.StatusMethodNotAllowed)
+	}
+	if !config.EnableExplain || m.IsProductionSafe() {
+		return echo.NewHTTPError(http.StatusForbidden, "explain is disabled")
+	}
+
+	entry, err := debugmonitor.GetEntryFromQuery(c, store)
+	if err != nil {
+		return err
+	}
+	payload := entry.Payload.(*QueryPayload)
+	if payload.Count > 0 || !isSelectQuery(payload.Query) {
+		// N+1 and Duplicate entries hold a normalized statement that cannot be run
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "only SELECT queries can be explained")
+	}
+
+	database, ok := dbs[payload.Database]
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "unknown database")
+	}
+	explain, err := explainStatement(database.dialect, payload.Query)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	rows, err := database.db.QueryContext(contextWithoutRecording(c.Request().Context()), explain, payload.Args...)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	defer rows.Close()
+
+	result := &ExplainResult{
+		Query:   payload.Query,
+		Explain: explain,
+		Rows:    [][]string{},
+	}
+	if result.Columns, err = rows.Columns(); err != nil {
+		return err
+	}
+	for rows.Next() {
+		values := make([]any, len(result.Columns))
+		dest := make([]any, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				row[i] = string(b)
+			} else if v != nil {
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	if c.QueryParam("format") == "json" {
+		return c.JSON(http.StatusOK, result)
+	}
+	return debugmonitor.RenderTemplate(c, queryExplainViewTemplate, map[string]any{
+		"Result": result,
+	})
+}
+
+// recordingDisabledKey is the context key that disables recording of the queries run with the context.
+type recordingDisabledKey struct{}
+
+// contextWithoutRecording returns a context with which the monitored connections run queries without recording them.
+func contextWithoutRecording(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recordingDisabledKey{}, true)
+}
+
+// recordingDisabled reports whether recording is disabled by the context.
+func recordingDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(recordingDisabledKey{}).(bool)
+	return disabled
+}
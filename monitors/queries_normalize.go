@@ -0,0 +1,170 @@
+package monitors
+
+import (
+	"strings"
+)
+
+// normalizeQuery normalizes a SQL statement into a fingerprint that is the same for runs
+// with different literals or arguments. String and numeric literals and placeholders such as
+// $1, :name and @p1 are replaced with "?", lists of them such as IN (1, 2, 3) are collapsed
+// into "(?+)", whitespace is collapsed, and comments are removed.
+func normalizeQuery(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	space := false
+
+	writeSpace := func() {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+	}
+
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			space = true
+		case ch == '-' && i+1 < len(query) && query[i+1] == '-':
+			// Line comment
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			space = true
+		case ch == '/' && i+1 < len(query) && query[i+1] == '*':
+			// Block comment
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+			space = true
+		case ch == '\'':
+			// String literal, where a doubled quote is an escaped quote
+			i++
+			for i < len(query) {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			writeSpace()
+			b.WriteByte('?')
+		case isDigit(ch) && !isIdentifierByte(lastByte(&b, space)):
+			// Numeric literal
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			writeSpace()
+			b.WriteByte('?')
+		case (ch == '$' || ch == ':' || ch == '@') && i+1 < len(query) && isIdentifierByte(query[i+1]) &&
+			!(ch == ':' && lastByte(&b, space) == ':'):
+			// Placeholder. "::" is a PostgreSQL cast, not a placeholder.
+			for i+1 < len(query) && isIdentifierByte(query[i+1]) {
+				i++
+			}
+			writeSpace()
+			b.WriteByte('?')
+		default:
+			writeSpace()
+			b.WriteByte(ch)
+		}
+	}
+
+	return collapseLists(b.String())
+}
+
+// collapseLists collapses lists of placeholders such as "(?, ?, ?)" into "(?+)".
+func collapseLists(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "(?")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		j := i + 2
+		for {
+			rest := strings.TrimLeft(s[j:], " ")
+			if !strings.HasPrefix(rest, ",") {
+				break
+			}
+			rest = strings.TrimLeft(rest[1:], " ")
+			if !strings.HasPrefix(rest, "?") {
+				break
+			}
+			j = len(s) - len(rest) + 1
+		}
+		rest := strings.TrimLeft(s[j:], " ")
+		if strings.HasPrefix(rest, ")") {
+			b.WriteString(s[:i])
+			b.WriteString("(?+)")
+			s = rest[1:]
+			continue
+		}
+		b.WriteString(s[:i+2])
+		s = s[i+2:]
+	}
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentifierByte(ch byte) bool {
+	return ch == '_' || isDigit(ch) || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// lastByte returns the last byte written to b, or a space if a space is pending.
+func lastByte(b *strings.Builder, space bool) byte {
+	if space || b.Len() == 0 {
+		return ' '
+	}
+	s := b.String()
+	return s[len(s)-1]
+}
+
+// primaryTable returns the table a SQL statement primarily reads or writes: the table after the first
+// UPDATE, INTO or FROM outside parentheses. Schema-qualified names are returned as they are, such as
+// "public.users". It returns an empty string if no table is found.
+func primaryTable(query string) string {
+	tokens := sqlTokens(query)
+	depth := 0
+	for i, tok := range tokens {
+		switch tok {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 || i+1 >= len(tokens) {
+			continue
+		}
+		switch strings.ToUpper(tok) {
+		case "UPDATE", "INTO", "FROM":
+			if !isIdentifierToken(tokens[i+1]) {
+				continue
+			}
+			table := tokens[i+1]
+			for j := i + 2; j+1 < len(tokens) && tokens[j] == "." && isIdentifierToken(tokens[j+1]); j += 2 {
+				table += "." + tokens[j+1]
+			}
+			return table
+		}
+	}
+	return ""
+}
+
+// matchTable reports whether the table matches the name, ignoring case and, if the name is not
+// schema-qualified, the schema of the table.
+func matchTable(table, name string) bool {
+	table, name = strings.ToLower(table), strings.ToLower(name)
+	return table == name || (!strings.Contains(name, ".") && strings.HasSuffix(table, "."+name))
+}
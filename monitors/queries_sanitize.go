@@ -0,0 +1,240 @@
+package monitors
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SensitiveColumnNames are the substrings of column names whose bound values are masked by MaskSensitiveArgs.
+var SensitiveColumnNames = []string{"password", "token", "secret"}
+
+// MaskSensitiveArgs is the default ArgSanitizer of the queries monitor. It replaces the arguments bound to
+// columns whose names contain one of SensitiveColumnNames with "[REDACTED]". An argument is bound to a column
+// when its placeholder follows a comparison or assignment such as "password = ?", when it is in the VALUES of an
+// INSERT with a column list, or when it is a named argument such as ":password".
+func MaskSensitiveArgs(query string, args []any) []any {
+	if len(args) == 0 {
+		return args
+	}
+	var masked []any
+	for _, p := range queryPlaceholders(query) {
+		if !isSensitiveColumn(p.column) && !isSensitiveColumn(p.name) {
+			continue
+		}
+		i := p.index
+		if p.name != "" {
+			// Named arguments are recorded as "name=value"
+			i = namedArgIndex(args, p.name)
+		}
+		if i < 0 || i >= len(args) {
+			continue
+		}
+		if masked == nil {
+			masked = make([]any, len(args))
+			copy(masked, args)
+		}
+		if p.name != "" {
+			masked[i] = p.name + "=" + redacted
+		} else {
+			masked[i] = redacted
+		}
+	}
+	if masked == nil {
+		return args
+	}
+	return masked
+}
+
+func isSensitiveColumn(name string) bool {
+	if name == "" {
+		return false
+	}
+	name = strings.ToLower(name)
+	for _, s := range SensitiveColumnNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func namedArgIndex(args []any, name string) int {
+	for i, arg := range args {
+		if s, ok := arg.(string); ok && strings.HasPrefix(s, name+"=") {
+			return i
+		}
+	}
+	return -1
+}
+
+// queryPlaceholder is a placeholder in a SQL statement.
+type queryPlaceholder struct {
+	index  int    // index of the argument for positional placeholders
+	name   string // name of a named placeholder such as :name or @name
+	column string // column the placeholder is bound to, if known
+}
+
+// queryPlaceholders returns the placeholders of a SQL statement in order, with the columns they are bound to.
+func queryPlaceholders(query string) []queryPlaceholder {
+	tokens := sqlTokens(query)
+	var placeholders []queryPlaceholder
+	next := 0
+
+	// Columns of an INSERT column list, and the nesting depth and column position in the VALUES rows
+	var insertColumns []string
+	inValues := false
+	depth, position := 0, 0
+
+	for i, tok := range tokens {
+		if inValues {
+			switch {
+			case tok == "(":
+				depth++
+				if depth == 1 {
+					position = 0
+				}
+			case tok == ")":
+				depth--
+			case tok == "," && depth == 1:
+				position++
+			case depth == 0 && isIdentifierToken(tok):
+				// A keyword after the rows ends the VALUES clause
+				inValues = false
+			}
+		}
+
+		switch {
+		case strings.EqualFold(tok, "VALUES") && !inValues:
+			insertColumns = precedingColumnList(tokens[:i])
+			inValues = insertColumns != nil
+			depth = 0
+		case isPlaceholderToken(tok):
+			p := queryPlaceholder{index: next}
+			switch tok[0] {
+			case '?':
+				next++
+			case '$':
+				n, _ := strconv.Atoi(tok[1:])
+				p.index = n - 1
+			default:
+				p.name = tok[1:]
+			}
+			if inValues && depth > 0 && position < len(insertColumns) {
+				p.column = insertColumns[position]
+			} else if i >= 2 && isComparisonToken(tokens[i-1]) && isIdentifierToken(tokens[i-2]) {
+				p.column = tokens[i-2]
+			}
+			placeholders = append(placeholders, p)
+		}
+	}
+	return placeholders
+}
+
+// precedingColumnList returns the identifiers of the parenthesized list at the end of tokens,
+// or nil if tokens do not end with one.
+func precedingColumnList(tokens []string) []string {
+	if len(tokens) == 0 || tokens[len(tokens)-1] != ")" {
+		return nil
+	}
+	var columns []string
+	for i := len(tokens) - 2; i >= 0; i-- {
+		switch tok := tokens[i]; {
+		case tok == "(":
+			// Reverse into statement order
+			for l, r := 0, len(columns)-1; l < r; l, r = l+1, r-1 {
+				columns[l], columns[r] = columns[r], columns[l]
+			}
+			return columns
+		case tok == ",":
+		case isIdentifierToken(tok):
+			columns = append(columns, tok)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// sqlTokens splits a SQL statement into identifiers, placeholders and operators,
+// dropping whitespace, comments and literals. Quoted identifiers are unquoted.
+func sqlTokens(query string) []string {
+	var tokens []string
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+		case ch == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+		case ch == '\'':
+			// String literal, where a doubled quote is an escaped quote
+			i++
+			for i < len(query) {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, "'")
+		case ch == '"' || ch == '`':
+			end := strings.IndexByte(query[i+1:], ch)
+			if end < 0 {
+				end = len(query) - i - 1
+			}
+			tokens = append(tokens, query[i+1:i+1+end])
+			i += end + 1
+		case ch == '?':
+			tokens = append(tokens, "?")
+		case (ch == '$' || ch == ':' || ch == '@') && i+1 < len(query) && isIdentifierByte(query[i+1]) &&
+			!(ch == ':' && i > 0 && query[i-1] == ':'):
+			start := i
+			for i+1 < len(query) && isIdentifierByte(query[i+1]) {
+				i++
+			}
+			tokens = append(tokens, query[start:i+1])
+		case isIdentifierByte(ch):
+			start := i
+			for i+1 < len(query) && isIdentifierByte(query[i+1]) {
+				i++
+			}
+			tokens = append(tokens, query[start:i+1])
+		case strings.IndexByte("<>!=", ch) >= 0:
+			start := i
+			for i+1 < len(query) && strings.IndexByte("<>!=", query[i+1]) >= 0 {
+				i++
+			}
+			tokens = append(tokens, query[start:i+1])
+		default:
+			tokens = append(tokens, string(ch))
+		}
+	}
+	return tokens
+}
+
+func isPlaceholderToken(tok string) bool {
+	return tok == "?" || (len(tok) > 1 && (tok[0] == '$' || tok[0] == ':' || tok[0] == '@'))
+}
+
+func isIdentifierToken(tok string) bool {
+	return tok != "" && !isPlaceholderToken(tok) && strings.IndexByte("()',.;*+-/<>!=", tok[0]) < 0
+}
+
+func isComparisonToken(tok string) bool {
+	switch strings.ToUpper(tok) {
+	case "=", "<>", "!=", "<", ">", "<=", ">=", "LIKE", "ILIKE":
+		return true
+	}
+	return false
+}
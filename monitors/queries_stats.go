@@ -0,0 +1,91 @@
+package monitors
+
+import (
+	"sort"
+	"sync"
+)
+
+// QueryStats represents the aggregated statistics of the queries that share a fingerprint.
+type QueryStats struct {
+	Database      string `json:"database,omitempty"`
+	Fingerprint   string `json:"fingerprint"`
+	Count         int    `json:"count"`
+	ErrorCount    int    `json:"errorCount"`
+	TotalDuration int64  `json:"totalDuration"` // in milliseconds
+	AvgDuration   int64  `json:"avgDuration"`   // in milliseconds
+	MaxDuration   int64  `json:"maxDuration"`   // in milliseconds
+}
+
+// queryStats is a sub-store of the queries monitor that maintains per-fingerprint aggregates.
+type queryStats struct {
+	mu           sync.Mutex
+	fingerprints map[queryStatsKey]*QueryStats
+}
+
+// queryStatsKey identifies a fingerprint of a database.
+type queryStatsKey struct {
+	database    string
+	fingerprint string
+}
+
+func newQueryStats() *queryStats {
+	return &queryStats{
+		fingerprints: make(map[queryStatsKey]*QueryStats),
+	}
+}
+
+// record adds a query to the aggregates of its fingerprint.
+func (s *queryStats) record(payload *QueryPayload) {
+	key := queryStatsKey{database: payload.Database, fingerprint: normalizeQuery(payload.Query)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.fingerprints[key]
+	if !ok {
+		stats = &QueryStats{Database: key.database, Fingerprint: key.fingerprint}
+		s.fingerprints[key] = stats
+	}
+	stats.Count++
+	if payload.Error != "" {
+		stats.ErrorCount++
+	}
+	stats.TotalDuration += payload.Duration
+	if payload.Duration > stats.MaxDuration {
+		stats.MaxDuration = payload.Duration
+	}
+}
+
+// snapshot returns the statistics of all fingerprints, sorted by the total duration in descending order.
+func (s *queryStats) snapshot() []*QueryStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]*QueryStats, 0, len(s.fingerprints))
+	for _, stats := range s.fingerprints {
+		copied := *stats
+		copied.AvgDuration = stats.TotalDuration / int64(stats.Count)
+		result = append(result, &copied)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalDuration != result[j].TotalDuration {
+			return result[i].TotalDuration > result[j].TotalDuration
+		}
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		if result[i].Fingerprint != result[j].Fingerprint {
+			return result[i].Fingerprint < result[j].Fingerprint
+		}
+		return result[i].Database < result[j].Database
+	})
+	return result
+}
+
+// reset removes all aggregates.
+func (s *queryStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fingerprints = make(map[queryStatsKey]*QueryStats)
+}
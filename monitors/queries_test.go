@@ -0,0 +1,974 @@
+package monitors
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// fakeDriver is a minimal database driver for tests. Queries containing "sleep" take 10ms,
+// and queries containing "fail" return an error.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return &fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := fakeExecute(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := fakeExecute(query); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(query, "EXPLAIN") {
+		return &fakeRows{columns: []string{"detail"}, values: [][]driver.Value{{"SCAN " + query}}}, nil
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := fakeExecute(s.query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := fakeExecute(s.query); err != nil {
+		return nil, err
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeTx struct{}
+
+func (t *fakeTx) Commit() error   { return nil }
+func (t *fakeTx) Rollback() error { return nil }
+
+// fakeRows returns the values, which are empty by default, in an "id" column unless columns are given.
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if r.columns == nil {
+		return []string{"id"}
+	}
+	return r.columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+type fakeError string
+
+func (e fakeError) Error() string { return string(e) }
+
+func fakeExecute(query string) error {
+	if strings.Contains(query, "sleep") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strings.Contains(query, "fail") {
+		return fakeError("query failed")
+	}
+	return nil
+}
+
+// fakeTxConn is a fakeConn that supports the optional connection interfaces.
+type fakeTxConn struct {
+	fakeConn
+	txOptions driver.TxOptions
+	pinged    bool
+	reset     bool
+	valid     bool
+}
+
+func (c *fakeTxConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.txOptions = opts
+	return &fakeTx{}, nil
+}
+
+func (c *fakeTxConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return &fakeCtxStmt{fakeStmt: fakeStmt{query: query}}, nil
+}
+
+// fakeCtxStmt is a fakeStmt that supports the context-aware statement interfaces.
+type fakeCtxStmt struct {
+	fakeStmt
+	args []driver.NamedValue
+}
+
+func (s *fakeCtxStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	s.args = args
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Exec(nil)
+}
+
+func (s *fakeCtxStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	s.args = args
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Query(nil)
+}
+
+func (c *fakeTxConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(fakeError); ok {
+		// Accept a custom type that the default converter rejects
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+func (c *fakeTxConn) Ping(ctx context.Context) error {
+	c.pinged = true
+	return nil
+}
+
+func (c *fakeTxConn) ResetSession(ctx context.Context) error {
+	c.reset = true
+	return nil
+}
+
+func (c *fakeTxConn) IsValid() bool {
+	return c.valid
+}
+
+// fakeTxDriver opens a single fakeTxConn.
+type fakeTxDriver struct {
+	conn *fakeTxConn
+}
+
+func (d fakeTxDriver) Open(name string) (driver.Conn, error) {
+	return d.conn, nil
+}
+
+func TestQueriesMonitor_DriverPassthrough(t *testing.T) {
+	ctx := context.Background()
+
+	conn := &fakeTxConn{valid: true}
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeTxDriver{conn: conn}})
+	debugmonitor.New().AddMonitor(monitor)
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = tx.Rollback()
+	if conn.txOptions.Isolation != driver.IsolationLevel(sql.LevelSerializable) || !conn.txOptions.ReadOnly {
+		t.Errorf("Expected the transaction options to be passed through, got %+v", conn.txOptions)
+	}
+
+	if err := db.PingContext(ctx); err != nil || !conn.pinged {
+		t.Errorf("Expected the ping to be passed through, got %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "insert", fakeError("custom")); err != nil {
+		t.Errorf("Expected the named value checker to be passed through, got %v", err)
+	}
+
+	// The connection is reset when it is reused from the pool
+	if _, err := db.ExecContext(ctx, "insert"); err != nil || !conn.reset {
+		t.Errorf("Expected the session reset to be passed through, got %v", err)
+	}
+
+	// Drivers without BeginTx reject non-default options as database/sql does
+	_, db2 := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeDriver{}})
+	defer db2.Close()
+	if _, err := db2.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}); err == nil {
+		t.Error("Expected an error for a non-default isolation level")
+	}
+	tx, err = db2.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = tx.Commit()
+}
+
+func TestQueriesMonitor_SlowThreshold(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{
+		Driver:        fakeDriver{},
+		SlowThreshold: 5 * time.Millisecond,
+	})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "select 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "select sleep"); err != nil {
+		t.Fatal(err)
+	}
+
+	fast := (<-sub.C).Entry.Payload.(*QueryPayload)
+	slow := (<-sub.C).Entry.Payload.(*QueryPayload)
+	if fast.Slow {
+		t.Error("Expected the fast query not to be flagged")
+	}
+	if !slow.Slow {
+		t.Error("Expected the slow query to be flagged")
+	}
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=data&slow=1", nil))
+	var entries []struct {
+		Payload QueryPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Payload.Query != "select sleep" {
+		t.Errorf("Expected only the slow query, got %+v", entries)
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE id = 1", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT *\n  FROM users\tWHERE name = 'O''Brien'", "SELECT * FROM users WHERE name = ?"},
+		{"select * from posts where user_id = $1 and id in ($2, $3, $4)", "select * from posts where user_id = ? and id in (?+)"},
+		{"select * from t where a = :name and b = @p1", "select * from t where a = ? and b = ?"},
+		{"select id::text from t2 -- comment\nwhere x = 1.5 /* block */", "select id::text from t2 where x = ?"},
+		{"insert into t (a, b) values (?, ?)", "insert into t (a, b) values (?+)"},
+	}
+	for _, tt := range tests {
+		if got := normalizeQuery(tt.query); got != tt.want {
+			t.Errorf("normalizeQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueriesMonitor_NPlusOneDetection(t *testing.T) {
+	m := debugmonitor.New()
+	requestsMonitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(requestsMonitor)
+	queriesMonitor, db := NewQueriesMonitor(QueriesMonitorConfig{
+		Driver:            fakeDriver{},
+		NPlusOneThreshold: 3,
+	})
+	m.AddMonitor(queriesMonitor)
+	defer db.Close()
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		for i := 0; i < 4; i++ {
+			if _, err := db.QueryContext(ctx, "select * from posts where user_id = ?", i); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := db.ExecContext(ctx, "update counters set n = n + 1"); err != nil {
+				return err
+			}
+		}
+		// Below the threshold
+		_, _ = db.ExecContext(ctx, "select 1")
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var suspects []*QueryPayload
+	for len(sub.C) > 0 {
+		if payload, ok := (<-sub.C).Entry.Payload.(*QueryPayload); ok && payload.Count > 0 {
+			suspects = append(suspects, payload)
+		}
+	}
+	if len(suspects) != 2 {
+		t.Fatalf("Expected 2 suspect entries, got %+v", suspects)
+	}
+	if suspects[0].Operation != "N+1" || suspects[0].Count != 4 || suspects[0].Query != "select * from posts where user_id = ?" || suspects[0].RequestID == "" {
+		t.Errorf("Unexpected N+1 entry: %+v", suspects[0])
+	}
+	if suspects[1].Operation != "Duplicate" || suspects[1].Count != 3 {
+		t.Errorf("Unexpected duplicate entry: %+v", suspects[1])
+	}
+}
+
+func TestQueriesMonitor_NPlusOneDetectionProductionSafe(t *testing.T) {
+	m := debugmonitor.NewProductionSafe()
+	requestsMonitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(requestsMonitor)
+	queriesMonitor, db := NewQueriesMonitor(QueriesMonitorConfig{
+		Driver:            fakeDriver{},
+		NPlusOneThreshold: 3,
+	})
+	m.AddMonitor(queriesMonitor)
+	defer db.Close()
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/", func(c echo.Context) error {
+		for i := 0; i < 3; i++ {
+			if _, err := db.QueryContext(c.Request().Context(), "select * from posts where user_id = ?", i); err != nil {
+				return err
+			}
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// The arguments are not recorded, but the queries with different arguments are still N+1 and not duplicates
+	var suspects []*QueryPayload
+	for len(sub.C) > 0 {
+		if payload, ok := (<-sub.C).Entry.Payload.(*QueryPayload); ok && payload.Count > 0 {
+			suspects = append(suspects, payload)
+		}
+	}
+	if len(suspects) != 1 || suspects[0].Operation != "N+1" || suspects[0].Count != 3 {
+		t.Errorf("Expected 1 N+1 entry, got %+v", suspects)
+	}
+}
+
+func TestQueriesMonitor_StatsAction(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeDriver{}})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("select * from users where id = %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, "select sleep"); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = db.ExecContext(ctx, "select fail")
+
+	e := echo.New()
+	e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=stats&format=json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var fingerprints []*QueryStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &fingerprints); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if len(fingerprints) != 3 {
+		t.Fatalf("Expected 3 fingerprints, got %d", len(fingerprints))
+	}
+	// Ranked by the total duration
+	if fingerprints[0].Fingerprint != "select sleep" || fingerprints[0].MaxDuration < 10 {
+		t.Errorf("Expected the slow query first, got %+v", fingerprints[0])
+	}
+	for _, stats := range fingerprints {
+		switch stats.Fingerprint {
+		case "select * from users where id = ?":
+			if stats.Count != 3 || stats.ErrorCount != 0 {
+				t.Errorf("Unexpected stats: %+v", stats)
+			}
+		case "select fail":
+			if stats.Count != 1 || stats.ErrorCount != 1 {
+				t.Errorf("Unexpected stats: %+v", stats)
+			}
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=stats", nil))
+	if !strings.Contains(rec.Body.String(), "select * from users where id = ?") {
+		t.Errorf("Expected HTML stats, got %s", rec.Body.String())
+	}
+
+	// Reset the stats
+	req := httptest.NewRequest(http.MethodPost, "/monitor?monitor=queries&action=stats", nil)
+	req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+	req.Header.Set("X-CSRF-Token", "test-token")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=stats&format=json", nil))
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("Expected stats to be reset, got %s", rec.Body.String())
+	}
+}
+
+func TestQueriesMonitor_Explain(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{
+		Driver:        fakeDriver{},
+		EnableExplain: true,
+		Dialect:       DialectSQLite,
+	})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	ctx := context.Background()
+	rows, err := db.QueryContext(ctx, "select * from users where id = ?", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+	if _, err := db.ExecContext(ctx, "delete from users"); err != nil {
+		t.Fatal(err)
+	}
+	selectID := (<-sub.C).Entry.Id
+	deleteID := (<-sub.C).Entry.Id
+
+	e := echo.New()
+	e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor", m.Handler())
+	explain := func(id int64) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/monitor?monitor=queries&action=explain&id=%d&format=json", id), nil)
+		req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+		req.Header.Set("X-CSRF-Token", "test-token")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := explain(selectID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result ExplainResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Explain != "EXPLAIN QUERY PLAN select * from users where id = ?" {
+		t.Errorf("Unexpected explain statement: %s", result.Explain)
+	}
+	if len(result.Columns) != 1 || result.Columns[0] != "detail" || len(result.Rows) != 1 || result.Rows[0][0] != "SCAN "+result.Explain {
+		t.Errorf("Unexpected plan: %+v", result)
+	}
+
+	// The EXPLAIN statement itself is not recorded
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=data", nil))
+	var entries []json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 entries, got %d", len(entries))
+	}
+
+	// Only SELECT queries can be explained
+	if rec := explain(deleteID); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", rec.Code)
+	}
+
+	// Explain is disabled by default
+	m2 := debugmonitor.New()
+	monitor2, db2 := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeDriver{}})
+	m2.AddMonitor(monitor2)
+	defer db2.Close()
+	e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor2", m2.Handler())
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/monitor2?monitor=queries&action=explain&id=%d", selectID), nil)
+	req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+	req.Header.Set("X-CSRF-Token", "test-token")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rec.Code)
+	}
+}
+
+func TestMaskSensitiveArgs(t *testing.T) {
+	tests := []struct {
+		query string
+		args  []any
+		want  []any
+	}{
+		{"select * from users where email = ? and password = ?", []any{"a@example.com", "pw"}, []any{"a@example.com", redacted}},
+		{"update users set api_token = $2 where id = $1", []any{int64(1), "tok"}, []any{int64(1), redacted}},
+		{"insert into users (name, password_hash, created_at) values (?, ?, now()), (?, ?, ?)", []any{"a", "h1", "b", "h2", "t"}, []any{"a", redacted, "b", redacted, "t"}},
+		{"select * from users u where u.`Secret` = ?", []any{"s"}, []any{redacted}},
+		{"select * from users where name = :name and secret = :secret", []any{"name=a", "secret=s"}, []any{"name=a", "secret=" + redacted}},
+		{"select * from users where id = ? and note = 'password = ?'", []any{int64(1)}, []any{int64(1)}},
+	}
+	for _, tt := range tests {
+		got := MaskSensitiveArgs(tt.query, tt.args)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("MaskSensitiveArgs(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueriesMonitor_ArgSanitizer(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{
+		Driver: fakeDriver{},
+		ArgSanitizer: func(query string, args []any) []any {
+			return []any{len(args)}
+		},
+	})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	if _, err := db.ExecContext(context.Background(), "update users set name = ?", "a"); err != nil {
+		t.Fatal(err)
+	}
+	payload := (<-sub.C).Entry.Payload.(*QueryPayload)
+	if fmt.Sprint(payload.Args) != "[1]" {
+		t.Errorf("Expected the sanitized arguments, got %v", payload.Args)
+	}
+}
+
+func TestMultiQueriesMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, dbs := NewMultiQueriesMonitor(QueriesMonitorConfig{},
+		QueriesDatabase{Name: "primary", Driver: fakeDriver{}},
+		QueriesDatabase{Name: "replica", Driver: fakeDriver{}},
+	)
+	m.AddMonitor(monitor)
+	for _, db := range dbs {
+		defer db.Close()
+	}
+
+	ctx := context.Background()
+	if _, err := dbs["primary"].ExecContext(ctx, "update users set name = ?", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs["replica"].QueryContext(ctx, "select * from users"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs["replica"].QueryContext(ctx, "select * from users"); err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=data&database=replica", nil))
+	var entries []struct {
+		Payload QueryPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Payload.Database != "replica" {
+			t.Errorf("Expected the replica queries only, got %+v", entry.Payload)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=stats&format=json", nil))
+	var fingerprints []*QueryStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &fingerprints); err != nil {
+		t.Fatal(err)
+	}
+	if len(fingerprints) != 2 || fingerprints[0].Database != "replica" || fingerprints[0].Count != 2 {
+		t.Errorf("Expected stats per database, got %+v", fingerprints)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=render", nil))
+	if !strings.Contains(rec.Body.String(), `<option value="replica">replica</option>`) {
+		t.Error("Expected the database filter to be rendered")
+	}
+}
+
+// fakeConnector is a connector-only driver that tracks whether it is closed.
+type fakeConnector struct {
+	connects int
+	closed   bool
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.connects++
+	return &fakeConn{}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+func (c *fakeConnector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWrapConnector(t *testing.T) {
+	m := debugmonitor.New()
+	connector := &fakeConnector{}
+	monitor, db := WrapConnector(connector)
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	if _, err := db.ExecContext(context.Background(), "update users set name = ?", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if connector.connects != 1 {
+		t.Errorf("Expected the connector to be used, got %d connects", connector.connects)
+	}
+	if payload := (<-sub.C).Entry.Payload.(*QueryPayload); payload.Query != "update users set name = ?" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !connector.closed {
+		t.Error("Expected the connector to be closed with the database")
+	}
+}
+
+func TestQueriesMonitor_StmtContext(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeTxDriver{conn: &fakeTxConn{valid: true}}})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	ctx := debugmonitor.ContextWithRequestID(context.Background(), "req-1")
+	stmt, err := db.PrepareContext(ctx, "select * from users where id = :id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	<-sub.C // Prepare
+
+	rows, err := stmt.QueryContext(ctx, sql.Named("id", 1))
+	if err != nil {
+		t.Fatalf("Expected named arguments to be passed through, got %v", err)
+	}
+	_ = rows.Close()
+	payload := (<-sub.C).Entry.Payload.(*QueryPayload)
+	if payload.Operation != "Query" || payload.RequestID != "req-1" || fmt.Sprint(payload.Args) != "[id=1]" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+
+	// Cancellation reaches the statement
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := stmt.ExecContext(canceled, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	// Statements without the context-aware interfaces reject named arguments
+	_, db2 := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeDriver{}})
+	defer db2.Close()
+	stmt2, err := db2.Prepare("select * from users where id = :id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt2.Close()
+	if _, err := stmt2.Exec(sql.Named("id", 1)); err == nil {
+		t.Error("Expected an error for named arguments")
+	}
+	if _, err := stmt2.Exec(1); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueriesMonitor_WithoutRecording(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeTxDriver{conn: &fakeTxConn{valid: true}}})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(context.Background(), "select * from users where id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	ctx := contextWithoutRecording(context.Background())
+	if _, err := db.ExecContext(ctx, "update users set name = ?", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.QueryContext(ctx, "select * from users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	// A statement prepared with a recording context
+	if _, err := stmt.ExecContext(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = stmt.QueryContext(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+
+	select {
+	case ev := <-sub.C:
+		t.Errorf("Expected no entries to be recorded, got %+v", ev.Entry.Payload)
+	default:
+	}
+}
+
+func TestPrimaryTable(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE id = ?", "users"},
+		{"select count(*) from (select id from posts) p", ""},
+		{"insert into public.users (name) values (?)", "public.users"},
+		{"UPDATE `users` SET name = ? WHERE id = (SELECT user_id FROM posts WHERE id = ?)", "users"},
+		{"delete from sessions where expires_at < ?", "sessions"},
+		{"with recent as (select * from posts) select * from recent", "recent"},
+		{"BEGIN", ""},
+	}
+	for _, tt := range tests {
+		if got := primaryTable(tt.query); got != tt.want {
+			t.Errorf("primaryTable(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueriesMonitor_OperationAndTableFilter(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeDriver{}})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, query := range []string{
+		"select * from users",
+		"update users set name = ?",
+		"update public.posts set title = ?",
+		"insert into posts (title) values (?)",
+	} {
+		if _, err := db.ExecContext(ctx, query, "a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	tests := []struct {
+		params string
+		want   []string
+	}{
+		{"operation=Exec&table=users", []string{"select * from users", "update users set name = ?"}},
+		{"table=posts", []string{"update public.posts set title = ?", "insert into posts (title) values (?)"}},
+		{"table=public.posts", []string{"update public.posts set title = ?"}},
+		{"operation=query", nil},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=data&"+tt.params, nil))
+		var entries []struct {
+			Payload QueryPayload `json:"payload"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Payload.Query)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestQueriesMonitor_CaptureCaller(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeDriver{}, CaptureCaller: true})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "update users set name = ?", "a"); err != nil {
+		t.Fatal(err)
+	}
+	payload := (<-sub.C).Entry.Payload.(*QueryPayload)
+	if !strings.Contains(payload.Caller, "queries_test.go:") {
+		t.Errorf("Expected the caller to be the test, got %q", payload.Caller)
+	}
+
+	// Queries through prepared statements and transactions are attributed to the application as well
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "delete from users"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		payload := (<-sub.C).Entry.Payload.(*QueryPayload)
+		if !strings.Contains(payload.Caller, "queries_test.go:") {
+			t.Errorf("Expected the caller of %s to be the test, got %q", payload.Operation, payload.Caller)
+		}
+	}
+}
+
+func TestQueriesMonitor_StatementID(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeTxDriver{conn: &fakeTxConn{valid: true}}})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	ctx := context.Background()
+	prepare := func(query string) *sql.Stmt {
+		stmt, err := db.PrepareContext(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stmt
+	}
+	stmt1 := prepare("update users set name = ?")
+	defer stmt1.Close()
+	stmt2 := prepare("delete from users where id = ?")
+	defer stmt2.Close()
+	for i := 0; i < 2; i++ {
+		if _, err := stmt1.ExecContext(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := stmt2.ExecContext(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=data&statement=1", nil))
+	var entries []struct {
+		Payload QueryPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Payload.Operation+": "+entry.Payload.Query)
+	}
+	want := []string{"Prepare: update users set name = ?", "Exec: update users set name = ?", "Exec: update users set name = ?"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected the preparation and runs of the statement, got %v", got)
+	}
+}
+
+func TestQueriesMonitor_IgnoreStatements(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := NewQueriesMonitor(QueriesMonitorConfig{
+		Driver:                  fakeDriver{},
+		IgnoreStatements:        []string{"select 1"},
+		IgnoreSessionStatements: true,
+	})
+	m.AddMonitor(monitor)
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, query := range []string{
+		"  SELECT 1",
+		"set names utf8mb4",
+		"SELECT @@version",
+		"update users set name = ?",
+	} {
+		if _, err := db.ExecContext(ctx, query, "a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=queries&action=data", nil))
+	var entries []struct {
+		Payload QueryPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Payload.Query != "update users set name = ?" {
+		t.Errorf("Expected only the application statement, got %+v", entries)
+	}
+}
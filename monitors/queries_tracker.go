@@ -0,0 +1,120 @@
+package monitors
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// queryTracker accumulates the queries executed during a request.
+// The requests monitor puts it in the request context, and the queries monitor adds to it.
+type queryTracker struct {
+	mu         sync.Mutex
+	count      int
+	duration   time.Duration
+	statements map[statementKey]*trackedStatement
+	order      []statementKey // statements in the order they first ran
+}
+
+// statementKey identifies a statement run through a queries monitor.
+type statementKey struct {
+	recorder    *queryRecorder
+	fingerprint string
+}
+
+// trackedStatement is a statement run during a request.
+type trackedStatement struct {
+	query     string
+	count     int
+	duration  time.Duration
+	firstRun  time.Time
+	arguments map[string]struct{} // distinct argument lists
+}
+
+func newQueryTracker() *queryTracker {
+	return &queryTracker{
+		statements: make(map[statementKey]*trackedStatement),
+	}
+}
+
+// add adds a query recorded by the recorder that took d.
+// It must be called before the payload is recorded, so that the arguments are compared as they were passed
+// rather than as sanitized or dropped in production-safe mode.
+func (t *queryTracker) add(recorder *queryRecorder, payload *QueryPayload, d time.Duration) {
+	if recorder.ignores(payload.Query) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.count++
+	t.duration += d
+
+	key := statementKey{recorder: recorder, fingerprint: normalizeQuery(payload.Query)}
+	stmt, ok := t.statements[key]
+	if !ok {
+		stmt = &trackedStatement{
+			query:     payload.Query,
+			firstRun:  payload.Timestamp,
+			arguments: make(map[string]struct{}),
+		}
+		t.statements[key] = stmt
+		t.order = append(t.order, key)
+	}
+	stmt.count++
+	stmt.duration += d
+	stmt.arguments[fmt.Sprint(payload.Args...)] = struct{}{}
+}
+
+// totals returns the number and total duration of the queries.
+func (t *queryTracker) totals() (int, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.count, t.duration
+}
+
+// finish records an N+1 or Duplicate entry for each statement that ran at least as many times
+// as the threshold of its queries monitor. It is called when the request is done.
+func (t *queryTracker) finish(requestID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := append([]statementKey(nil), t.order...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return t.statements[keys[i]].firstRun.Before(t.statements[keys[j]].firstRun)
+	})
+	for _, key := range keys {
+		stmt := t.statements[key]
+		threshold := key.recorder.config.NPlusOneThreshold
+		if threshold <= 0 || stmt.count < threshold {
+			continue
+		}
+		operation := "N+1"
+		if len(stmt.arguments) == 1 {
+			operation = "Duplicate"
+		}
+		key.recorder.record(&QueryPayload{
+			Query:     key.fingerprint,
+			Duration:  stmt.duration.Milliseconds(),
+			Timestamp: stmt.firstRun,
+			Operation: operation,
+			RequestID: requestID,
+			Count:     stmt.count,
+		})
+	}
+}
+
+// queryTrackerKey is the context key for the queryTracker of a request.
+type queryTrackerKey struct{}
+
+func contextWithQueryTracker(ctx context.Context, t *queryTracker) context.Context {
+	return context.WithValue(ctx, queryTrackerKey{}, t)
+}
+
+func queryTrackerFromContext(ctx context.Context) *queryTracker {
+	t, _ := ctx.Value(queryTrackerKey{}).(*queryTracker)
+	return t
+}
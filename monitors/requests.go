@@ -1,10 +1,20 @@
 package monitors
 
 import (
+	cryptorand "crypto/rand"
 	_ "embed"
+	"encoding/hex"
 	"fmt"
 	"html/template"
+	"math/rand/v2"
+	"mime"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
@@ -14,8 +24,11 @@ import (
 
 // RequestPayload represents the data structure for HTTP request monitoring
 type RequestPayload struct {
+	RequestID  string            `json:"requestId,omitempty"`
 	Method     string            `json:"method"`
 	URI        string            `json:"uri"`
+	Route      string            `json:"route,omitempty"`   // registered route pattern such as /users/:id
+	Handler    string            `json:"handler,omitempty"` // name of the handler that served the request
 	Status     int               `json:"status"`
 	Latency    int64             `json:"latency"` // in milliseconds
 	RemoteAddr string            `json:"remoteAddr"`
@@ -23,6 +36,73 @@ type RequestPayload struct {
 	Error      string            `json:"error,omitempty"`
 	Headers    map[string]string `json:"headers,omitempty"`
 	Timestamp  time.Time         `json:"timestamp"`
+	// Type is the type of the request. It is "websocket" for requests that upgrade to WebSocket,
+	// and empty for ordinary HTTP requests.
+	Type string `json:"type,omitempty"`
+	// WebSocket is the information of the WebSocket connection. It is set when the connection was upgraded.
+	WebSocket *WebSocketInfo `json:"webSocket,omitempty"`
+	// Accept is the Accept header of the request.
+	Accept string `json:"accept,omitempty"`
+	// AcceptLanguage is the Accept-Language header of the request.
+	AcceptLanguage string `json:"acceptLanguage,omitempty"`
+	// ContentType is the Content-Type header of the response.
+	ContentType string `json:"contentType,omitempty"`
+	// User is the ID of the user who sent the request, resolved by UserResolver.
+	User string `json:"user,omitempty"`
+	// Extra is the derived data attached by Enricher, such as a GeoIP country or parsed user agent details.
+	Extra map[string]string `json:"extra,omitempty"`
+	// PathParams are the path parameters matched by the route, such as {"id": "42"} for /users/:id.
+	PathParams map[string]string `json:"pathParams,omitempty"`
+	// QueryParams are the parsed query parameters of the request.
+	QueryParams map[string][]string `json:"queryParams,omitempty"`
+	// FormValues are the parsed form values of the request body. They are set when the handler
+	// parsed the form or the request body was captured as application/x-www-form-urlencoded.
+	FormValues map[string][]string `json:"formValues,omitempty"`
+	// ReplayOf is the ID of the entry this request is a replay of.
+	ReplayOf int64 `json:"replayOf,omitempty"`
+	// Timings are the times spent in the middleware and the handler wrapped with TimeMiddleware and TimeHandler.
+	Timings []MiddlewareTiming `json:"timings,omitempty"`
+	// RequestSize is the number of bytes the handler read from the request body.
+	RequestSize int64 `json:"requestSize"`
+	// ResponseSize is the number of bytes written to the response body.
+	ResponseSize int64 `json:"responseSize"`
+	// Files are the metadata of the files uploaded in a multipart/form-data request body.
+	// They are set when the handler parsed the multipart form. File contents are never captured.
+	Files []*MultipartFile `json:"files,omitempty"`
+	// RequestBody is the captured request body. It is only set when CaptureRequestBody is enabled.
+	RequestBody string `json:"requestBody,omitempty"`
+	// RequestBodyTruncated reports whether the captured request body was cut at MaxBodySize.
+	RequestBodyTruncated bool `json:"requestBodyTruncated,omitempty"`
+	// ResponseBody is the captured response body. It is only set when CaptureResponseBody is enabled.
+	ResponseBody string `json:"responseBody,omitempty"`
+	// ResponseBodyTruncated reports whether the captured response body was cut at MaxBodySize.
+	ResponseBodyTruncated bool `json:"responseBodyTruncated,omitempty"`
+}
+
+// TimelineRequestID implements debugmonitor.TimelinePayload.
+func (p *RequestPayload) TimelineRequestID() string { return p.RequestID }
+
+// TimelineTime implements debugmonitor.TimelinePayload.
+func (p *RequestPayload) TimelineTime() time.Time { return p.Timestamp }
+
+// TimelineSummary implements debugmonitor.TimelinePayload.
+func (p *RequestPayload) TimelineSummary() string {
+	return fmt.Sprintf("%s %s %d (%dms)", p.Method, p.URI, p.Status, p.Latency)
+}
+
+// MultipartFile is the metadata of a file uploaded in a multipart/form-data request body.
+type MultipartFile struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size"`
+}
+
+// DefaultIgnoreHeaders are the request headers that are not captured by default.
+var DefaultIgnoreHeaders = []string{
+	echo.HeaderAuthorization,
+	"Cookie",
+	"Proxy-Authorization",
 }
 
 // RequestsMonitorConfig defines the config for Requests monitor.
@@ -30,15 +110,86 @@ type RequestsMonitorConfig struct {
 	// Skipper defines a function to skip middleware.
 	// Optional. Default: DefaultSkipper
 	Skipper middleware.Skipper
+	// CaptureHeaders are the names of the request headers to capture.
+	// If it is not empty, only these headers are captured.
+	CaptureHeaders []string
+	// IgnoreHeaders are the names of the request headers not to capture. It takes precedence over CaptureHeaders.
+	// Optional. Default: DefaultIgnoreHeaders
+	IgnoreHeaders []string
+	// IncludePaths are regular expressions of request paths to record.
+	// If it is not empty, only requests whose path matches one of them are recorded.
+	IncludePaths []string
+	// ExcludePaths are regular expressions of request paths not to record,
+	// such as "^/healthz$" or "^/static/". It takes precedence over IncludePaths.
+	ExcludePaths []string
+	// SampleRate is the fraction of requests to record, between 0 and 1.
+	// Requests that fail with an error or a 5xx status are always recorded.
+	// Optional. Default: 1 (record all requests)
+	SampleRate float64
 	// UsePolling enables polling mode instead of SSE for real-time updates.
 	UsePolling bool
+	// CaptureRequestBody enables capturing the request body as the handler reads it.
+	// Bodies are never captured in production-safe mode.
+	CaptureRequestBody bool
+	// CaptureResponseBody enables capturing the response body written by the handler.
+	// Bodies compressed with gzip or deflate are decoded, and bodies in other encodings such as br are not captured.
+	// Bodies are never captured in production-safe mode.
+	CaptureResponseBody bool
+	// MaxBodySize is the maximum number of bytes of a body to capture.
+	// Optional. Default: DefaultMaxBodySize
+	MaxBodySize int
+	// BodyContentTypes are the content types of bodies to capture.
+	// An entry ending with "/" matches any subtype, such as "text/".
+	// Optional. Default: DefaultBodyContentTypes
+	BodyContentTypes []string
+	// UserResolver returns the ID of the logged-in user or account of the request, if any.
+	// It is called after the handler, so it can read values set by the authentication middleware.
+	// The ID is redacted in production-safe mode, as are the values of the path parameters.
+	UserResolver func(c echo.Context) string
+	// ServerTiming enables the Server-Timing response header, which reports the time spent until the response
+	// was written ("app") and the number and time of the queries executed during the request ("db"),
+	// so browser devtools show the same numbers the monitor records.
+	ServerTiming bool
+	// QueryHeaders enables the X-DB-Query-Count and X-DB-Query-Time response headers, which report the number
+	// and the time in milliseconds of the queries executed during the request until the response was written.
+	QueryHeaders bool
+	// Enricher attaches derived data to the payload, typically to its Extra map, just before it is recorded.
+	// Each Extra key is shown as an extra field in the dashboard.
+	Enricher func(payload *RequestPayload, c echo.Context)
+	// EnableReplay enables the replay action, which re-issues a recorded request against the server
+	// serving the dashboard. Replay is never available in production-safe mode.
+	// Requests with redacted body values are not replayed, and the headers not captured are not sent.
+	EnableReplay bool
+	// RedactBodyFields are the names of JSON and form fields whose values are redacted in captured request and response bodies,
+	// query parameters and form values.
+	RedactBodyFields []string
 }
 
 //go:embed requests.html
 var requestsView string
 
 // requestsViewTemplate is the parsed template for the requests view
-var requestsViewTemplate = template.Must(template.New("requestsView").Parse(requestsView))
+var requestsViewTemplate = template.Must(template.New("requestsView").Funcs(debugmonitor.TemplateFuncs).Parse(requestsView))
+
+//go:embed requests_detail.html
+var requestDetailView string
+
+//go:embed requests_timeline.html
+var requestTimelineView string
+
+// requestDetailViewTemplate is the parsed template for the request detail view
+var requestDetailViewTemplate = template.Must(template.Must(template.New("requestDetailView").Funcs(debugmonitor.TemplateFuncs).Parse(requestDetailView)).Parse(requestTimelineView))
+
+// requestTimelineViewTemplate is the parsed template for the request timeline view
+var requestTimelineViewTemplate = template.Must(template.Must(template.New("requestTimelineView").Funcs(debugmonitor.TemplateFuncs).Parse(`{{ template "requestTimeline" . }}`)).Parse(requestTimelineView))
+
+//go:embed requests_stats.html
+var requestStatsView string
+
+// requestStatsViewTemplate is the parsed template for the per-route stats view
+var requestStatsViewTemplate = template.Must(template.New("requestStatsView").Funcs(debugmonitor.TemplateFuncs).Funcs(template.FuncMap{
+	"percent": func(rate float64) string { return strconv.FormatFloat(rate*100, 'f', 1, 64) + "%" },
+}).Parse(requestStatsView))
 
 // NewRequestsMonitor creates a new monitor for HTTP requests and returns
 // the monitor along with an Echo middleware function that captures request information
@@ -50,24 +201,112 @@ func NewRequestsMonitor(config *RequestsMonitorConfig) (*debugmonitor.Monitor, e
 	if config.Skipper == nil {
 		config.Skipper = middleware.DefaultSkipper
 	}
+	// Compile the path filters. Invalid patterns are programming errors, so they panic like regexp.MustCompile.
+	includePaths := compilePatterns(config.IncludePaths)
+	excludePaths := compilePatterns(config.ExcludePaths)
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		config.SampleRate = 1
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultMaxBodySize
+	}
+	if config.BodyContentTypes == nil {
+		config.BodyContentTypes = DefaultBodyContentTypes
+	}
+	if config.IgnoreHeaders == nil {
+		config.IgnoreHeaders = DefaultIgnoreHeaders
+	}
+
+	// stats holds the per-route aggregates. It is fed by every recorded request, including unsampled ones.
+	stats := newRequestStats()
+	// handlers looks up the handler names of the routes
+	handlers := &routeHandlers{}
 
-	m := &debugmonitor.Monitor{
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
 		Name:        "requests",
 		DisplayName: "Requests",
 		MaxRecords:  1000,
 		Icon:        debugmonitor.IconGlobeAlt,
+		Group:       "HTTP",
 		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
 			switch action {
 			case "render":
 				return debugmonitor.RenderTemplate(c, requestsViewTemplate, map[string]any{
-					"UsePolling": config.UsePolling,
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+					"EnableReplay":    config.EnableReplay && !m.IsProductionSafe(),
 				})
 			case "stream":
 				// SSE endpoint for real-time updates
-				return debugmonitor.HandleSSEStream(c, store)
+				filter, err := requestsFilter(c)
+				if err != nil {
+					return err
+				}
+				return debugmonitor.HandleSSEStreamWithFilter(c, store, filter)
 			case "data":
 				// JSON endpoint for polling mode
-				return debugmonitor.HandleDataJSON(c, store)
+				filter, err := requestsFilter(c)
+				if err != nil {
+					return err
+				}
+				return debugmonitor.HandleDataJSONWithFilter(c, store, filter)
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				filter, err := requestsFilter(c)
+				if err != nil {
+					return err
+				}
+				return debugmonitor.HandleLongPollWithFilter(c, store, filter)
+			case "detail":
+				// Single request by ID, as JSON with format=json or as an HTML fragment
+				entry, err := debugmonitor.GetEntryFromQuery(c, store)
+				if err != nil {
+					return err
+				}
+				if c.QueryParam("format") == "json" {
+					return c.JSON(http.StatusOK, entry)
+				}
+				return debugmonitor.RenderTemplate(c, requestDetailViewTemplate, map[string]any{
+					"Id":       entry.Id,
+					"Payload":  entry.Payload,
+					"Timeline": requestTimeline(m, entry.Payload.(*RequestPayload).RequestID),
+				})
+			case "curl":
+				// curl command that reproduces a request against the same host
+				entry, err := debugmonitor.GetEntryFromQuery(c, store)
+				if err != nil {
+					return err
+				}
+				return c.String(http.StatusOK, curlCommand(entry.Payload.(*RequestPayload), c.Scheme()+"://"+c.Request().Host))
+			case "replay":
+				return handleReplay(c, m, store, config)
+			case "timeline":
+				// Entries of all monitors recorded during a request, as JSON with format=json or as an HTML fragment
+				requestID := c.QueryParam("request_id")
+				if requestID == "" {
+					return echo.NewHTTPError(http.StatusBadRequest, "request_id is required")
+				}
+				timeline := requestTimeline(m, requestID)
+				if c.QueryParam("format") == "json" {
+					return c.JSON(http.StatusOK, timeline)
+				}
+				return debugmonitor.RenderTemplate(c, requestTimelineViewTemplate, timeline)
+			case "stats":
+				// Per-route aggregates, as JSON with format=json or as an HTML fragment.
+				// POST resets the aggregates.
+				if c.Request().Method == http.MethodPost {
+					stats.reset()
+					return c.NoContent(http.StatusNoContent)
+				}
+				routes := stats.snapshot()
+				if c.QueryParam("format") == "json" {
+					return c.JSON(http.StatusOK, routes)
+				}
+				return debugmonitor.RenderTemplate(c, requestStatsViewTemplate, map[string]any{
+					"Routes": routes,
+				})
 			default:
 				return echo.NewHTTPError(http.StatusBadRequest)
 			}
@@ -82,8 +321,97 @@ func NewRequestsMonitor(config *RequestsMonitorConfig) (*debugmonitor.Monitor, e
 				return next(c)
 			}
 
+			// Check if the request path should be recorded
+			path := c.Request().URL.Path
+			if (len(includePaths) > 0 && !matchAny(includePaths, path)) || matchAny(excludePaths, path) {
+				return next(c)
+			}
+
+			// Decide whether to sample this request. Requests that are not sampled are
+			// still recorded if they fail, but without their bodies.
+			sampleRate := config.SampleRate
+			if m.IsProductionSafe() && sampleRate > debugmonitor.ProductionSafeSampleRate {
+				sampleRate = debugmonitor.ProductionSafeSampleRate
+			}
+			sampled := sampleRate >= 1 || rand.Float64() < sampleRate
+
+			// Count the bytes the handler reads from the request body
+			var requestCounter *bodyCountingReader
+			req := c.Request()
+			if req.Body != nil && req.Body != http.NoBody {
+				requestCounter = &bodyCountingReader{ReadCloser: req.Body}
+				req.Body = requestCounter
+			}
+
+			// Capture the request body as the handler reads it
+			var requestBody *bodyBuffer
+			if sampled && config.CaptureRequestBody && !m.IsProductionSafe() && req.Body != nil && req.Body != http.NoBody &&
+				matchContentType(req.Header.Get(echo.HeaderContentType), config.BodyContentTypes) {
+				requestBody = &bodyBuffer{limit: config.MaxBodySize}
+				req.Body = newBodyCaptureReader(req.Body, requestBody)
+			}
+
+			// Capture the response body as the handler writes it
+			var responseBody *bodyBuffer
+			if sampled && config.CaptureResponseBody && !m.IsProductionSafe() {
+				responseBody = &bodyBuffer{limit: config.MaxBodySize}
+				res := c.Response()
+				originalWriter := res.Writer
+				res.Writer = newResponseCaptureWriter(originalWriter, responseBody, config.BodyContentTypes)
+				defer func() {
+					res.Writer = originalWriter
+				}()
+			}
+
+			// Reuse the request ID sent by the client or set by an upstream middleware, or generate a new one.
+			// The ID is echoed in the response and carried by the request context for correlation.
+			requestID := req.Header.Get(echo.HeaderXRequestID)
+			if requestID == "" {
+				requestID = c.Response().Header().Get(echo.HeaderXRequestID)
+			}
+			if requestID == "" {
+				requestID = newRequestID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+			ctx := debugmonitor.ContextWithRequestID(req.Context(), requestID)
+
+			// Track the queries executed during the request for the Server-Timing header and N+1 detection
+			queries := newQueryTracker()
+			ctx = contextWithQueryTracker(ctx, queries)
+			c.SetRequest(req.WithContext(ctx))
+			defer queries.finish(requestID)
+
+			// Track the connection of WebSocket upgrades, which outlives the handshake
+			var hijackTracker *hijackTrackingWriter
+			if isWebSocketUpgrade(req) {
+				res := c.Response()
+				originalWriter := res.Writer
+				hijackTracker = &hijackTrackingWriter{ResponseWriter: originalWriter}
+				res.Writer = hijackTracker
+				defer func() {
+					res.Writer = originalWriter
+				}()
+			}
+
+			// Collect the timings of the middleware chain
+			timings := &chainTimings{}
+			c.Set(chainTimingsKey, timings)
+
 			start := time.Now()
 
+			if config.ServerTiming {
+				c.Response().Before(func() {
+					c.Response().Header().Add("Server-Timing", serverTiming(time.Since(start), queries))
+				})
+			}
+			if config.QueryHeaders {
+				c.Response().Before(func() {
+					count, db := queries.totals()
+					c.Response().Header().Set(headerDBQueryCount, strconv.Itoa(count))
+					c.Response().Header().Set(headerDBQueryTime, fmt.Sprintf("%.3f", float64(db)/float64(time.Millisecond)))
+				})
+			}
+
 			// Process the request
 			err := next(c)
 
@@ -98,23 +426,109 @@ func NewRequestsMonitor(config *RequestsMonitorConfig) (*debugmonitor.Monitor, e
 
 			// Create payload
 			payload := &RequestPayload{
+				RequestID:  requestID,
 				Method:     c.Request().Method,
 				URI:        c.Request().RequestURI,
+				Route:      c.Path(),
+				Handler:    handlers.name(c),
 				Status:     status,
 				Latency:    latency.Milliseconds(),
 				RemoteAddr: c.RealIP(),
 				UserAgent:  c.Request().UserAgent(),
 				Timestamp:  start,
 			}
+			if requestCounter != nil {
+				payload.RequestSize = requestCounter.n
+			}
+			payload.ResponseSize = c.Response().Size
+			payload.Timings = timings.timings
 
-			// Include headers if configured
+			// For WebSocket connections, the latency is the time until the handshake, and the handler
+			// returns when the connection is closed
+			if hijackTracker != nil {
+				payload.Type = "websocket"
+				if info := hijackTracker.info(); info != nil {
+					payload.WebSocket = info
+					payload.Status = http.StatusSwitchingProtocols
+					payload.Latency = hijackTracker.hijackedAt.Sub(start).Milliseconds()
+				}
+			}
+
+			// Include the headers that are configured to be captured
 			payload.Headers = make(map[string]string)
 			for key, values := range c.Request().Header {
+				if key == replayOfHeader {
+					payload.ReplayOf, _ = strconv.ParseInt(values[0], 10, 64)
+					continue
+				}
+				if (len(config.CaptureHeaders) > 0 && !containsFold(config.CaptureHeaders, key)) || containsFold(config.IgnoreHeaders, key) {
+					continue
+				}
 				if len(values) > 0 {
 					payload.Headers[key] = values[0]
 				}
 			}
 
+			// Include the negotiated representation side by side with what the client accepts
+			payload.Accept = c.Request().Header.Get(echo.HeaderAccept)
+			payload.AcceptLanguage = c.Request().Header.Get("Accept-Language")
+			payload.ContentType = c.Response().Header().Get(echo.HeaderContentType)
+
+			// Include the user who sent the request
+			if config.UserResolver != nil {
+				payload.User = config.UserResolver(c)
+			}
+
+			// Include the matched path parameters
+			if names := c.ParamNames(); len(names) > 0 {
+				values := c.ParamValues()
+				payload.PathParams = make(map[string]string, len(names))
+				for i, name := range names {
+					if i < len(values) {
+						payload.PathParams[name] = values[i]
+					}
+				}
+			}
+
+			// Include the parsed query parameters and form values
+			payload.QueryParams = redactValues(c.QueryParams(), config.RedactBodyFields, m.IsProductionSafe())
+			payload.FormValues = redactValues(formValues(c.Request(), requestBody), config.RedactBodyFields, m.IsProductionSafe())
+
+			// Include the metadata of the uploaded files
+			payload.Files = multipartFiles(c.Request())
+
+			// Include the captured request body
+			if requestBody != nil {
+				payload.RequestBody = redactBody(requestBody.buf.String(), req.Header.Get(echo.HeaderContentType), config.RedactBodyFields)
+				payload.RequestBodyTruncated = requestBody.truncated
+			}
+
+			// Include the captured response body
+			// Compressed bodies are decoded so that they are readable. Bodies in unsupported encodings are dropped.
+			if responseBody != nil {
+				body, truncated, ok := decodeBody(responseBody.buf.Bytes(), c.Response().Header().Get(echo.HeaderContentEncoding), config.MaxBodySize)
+				if ok {
+					payload.ResponseBody = redactBody(body, c.Response().Header().Get(echo.HeaderContentType), config.RedactBodyFields)
+					payload.ResponseBodyTruncated = truncated || responseBody.truncated
+				}
+			}
+
+			// Redact potentially sensitive data in production-safe mode
+			if m.IsProductionSafe() {
+				payload.URI = redactURI(payload.URI)
+				for key := range payload.Headers {
+					if !productionSafeHeaders[key] {
+						payload.Headers[key] = redacted
+					}
+				}
+				for name := range payload.PathParams {
+					payload.PathParams[name] = redacted
+				}
+				if payload.User != "" {
+					payload.User = redacted
+				}
+			}
+
 			// Include error if any
 			if err != nil {
 				if he, ok := err.(*echo.HTTPError); ok {
@@ -125,8 +539,25 @@ func NewRequestsMonitor(config *RequestsMonitorConfig) (*debugmonitor.Monitor, e
 				}
 			}
 
-			// Add to monitor
-			m.Add(payload)
+			// Stats cover all requests so that sampling does not skew them
+			stats.record(payload)
+
+			// Errors are always recorded regardless of sampling
+			if !sampled && err == nil && payload.Status < http.StatusInternalServerError {
+				return nil
+			}
+
+			// Attach application-derived data
+			if config.Enricher != nil {
+				config.Enricher(payload, c)
+			}
+
+			// Add to monitor. Errors bypass the sample rate of the settings as well
+			if err != nil || payload.Status >= http.StatusInternalServerError {
+				m.AddUnsampled(payload)
+			} else {
+				m.Add(payload)
+			}
 
 			return err
 		}
@@ -134,3 +565,292 @@ func NewRequestsMonitor(config *RequestsMonitorConfig) (*debugmonitor.Monitor, e
 
 	return m, mw
 }
+
+// redacted is the placeholder for values hidden by redaction.
+const redacted = "[REDACTED]"
+
+// productionSafeHeaders are the request headers whose values are kept in production-safe mode.
+// The values of all other headers are redacted.
+var productionSafeHeaders = map[string]bool{
+	echo.HeaderAccept:         true,
+	echo.HeaderAcceptEncoding: true,
+	echo.HeaderContentLength:  true,
+	echo.HeaderContentType:    true,
+	echo.HeaderOrigin:         true,
+	"Accept-Language":         true,
+	"User-Agent":              true,
+}
+
+// redactURI replaces all query parameter values in the URI with the redacted placeholder.
+func redactURI(uri string) string {
+	u, err := url.ParseRequestURI(uri)
+	if err != nil || u.RawQuery == "" {
+		return uri
+	}
+	query := u.Query()
+	for key := range query {
+		query[key] = []string{redacted}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// formValues returns the form values of the request without consuming its body.
+// It uses the form parsed by the handler, or parses the captured request body if the handler did not.
+func formValues(req *http.Request, requestBody *bodyBuffer) url.Values {
+	values := url.Values{}
+	for key, v := range req.PostForm {
+		values[key] = v
+	}
+	if req.MultipartForm != nil {
+		for key, v := range req.MultipartForm.Value {
+			values[key] = v
+		}
+	}
+	if len(values) > 0 || requestBody == nil || requestBody.truncated {
+		return values
+	}
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType)); mediaType == echo.MIMEApplicationForm {
+		if parsed, err := url.ParseQuery(requestBody.buf.String()); err == nil {
+			return parsed
+		}
+	}
+	return values
+}
+
+// multipartFiles returns the metadata of the files in the multipart form parsed by the handler.
+func multipartFiles(req *http.Request) []*MultipartFile {
+	if req.MultipartForm == nil {
+		return nil
+	}
+	fields := make([]string, 0, len(req.MultipartForm.File))
+	for field := range req.MultipartForm.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var files []*MultipartFile
+	for _, field := range fields {
+		for _, header := range req.MultipartForm.File[field] {
+			files = append(files, &MultipartFile{
+				Field:       field,
+				Filename:    header.Filename,
+				ContentType: header.Header.Get(echo.HeaderContentType),
+				Size:        header.Size,
+			})
+		}
+	}
+	return files
+}
+
+// redactValues returns a copy of values with the values of the given fields redacted.
+// If all is true, every value is redacted. It returns nil if there are no values.
+func redactValues(values url.Values, fields []string, all bool) map[string][]string {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make(map[string][]string, len(values))
+	for key, v := range values {
+		if all || containsFold(fields, key) {
+			result[key] = []string{redacted}
+		} else {
+			result[key] = append([]string(nil), v...)
+		}
+	}
+	return result
+}
+
+// Response headers that report the queries executed during a request.
+const (
+	headerDBQueryCount = "X-DB-Query-Count"
+	headerDBQueryTime  = "X-DB-Query-Time"
+)
+
+// serverTiming returns the value of the Server-Timing header for a request.
+func serverTiming(app time.Duration, queries *queryTracker) string {
+	count, db := queries.totals()
+	return fmt.Sprintf(`app;dur=%.3f, db;dur=%.3f;desc="%d queries"`,
+		float64(app)/float64(time.Millisecond), float64(db)/float64(time.Millisecond), count)
+}
+
+// curlCommand returns a curl command that reproduces the request against baseURL.
+// Content-Length is left for curl to compute from the body.
+func curlCommand(payload *RequestPayload, baseURL string) string {
+	var b strings.Builder
+	b.WriteString("curl -X " + payload.Method)
+
+	keys := make([]string, 0, len(payload.Headers))
+	for key := range payload.Headers {
+		if key != echo.HeaderContentLength {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.WriteString(" \\\n  -H " + shellQuote(key+": "+payload.Headers[key]))
+	}
+
+	if payload.RequestBody != "" {
+		b.WriteString(" \\\n  --data-raw " + shellQuote(payload.RequestBody))
+	}
+	b.WriteString(" \\\n  " + shellQuote(baseURL+payload.URI))
+	return b.String()
+}
+
+// shellQuote quotes s as a single-quoted POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// requestTimeline returns the entries of the other monitors recorded during the request with the given ID.
+func requestTimeline(m *debugmonitor.Monitor, requestID string) []*debugmonitor.TimelineEntry {
+	timeline := []*debugmonitor.TimelineEntry{}
+	if m.Manager() == nil {
+		return timeline
+	}
+	for _, item := range m.Manager().Timeline(requestID) {
+		if item.Monitor != m.Name {
+			timeline = append(timeline, item)
+		}
+	}
+	return timeline
+}
+
+// newRequestID generates a random request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = cryptorand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// compilePatterns compiles the regular expressions. It panics if any of them is invalid.
+func compilePatterns(patterns []string) []*regexp.Regexp {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		regexps = append(regexps, regexp.MustCompile(pattern))
+	}
+	return regexps
+}
+
+// matchAny reports whether s matches any of the regular expressions.
+func matchAny(regexps []*regexp.Regexp, s string) bool {
+	for _, re := range regexps {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeHandlers looks up the names of the handlers registered for the routes by method and path.
+// The lookup is built from the routes of the Echo instance on the first request, so that the routes are not
+// scanned on every request. Routes registered after that have no handler name.
+type routeHandlers struct {
+	once  sync.Once
+	names map[string]string // keyed by method and path
+}
+
+// name returns the name of the handler registered for the route that matched the request.
+// It returns an empty string if no route matched.
+func (h *routeHandlers) name(c echo.Context) string {
+	path := c.Path()
+	if path == "" {
+		return ""
+	}
+	h.once.Do(func() {
+		h.names = make(map[string]string)
+		for _, route := range c.Echo().Routes() {
+			h.names[route.Method+" "+route.Path] = route.Name
+		}
+	})
+	if name, ok := h.names[c.Request().Method+" "+path]; ok {
+		return name
+	}
+	return h.names[echo.RouteNotFound+" "+path]
+}
+
+// requestsFilter returns the entry filter for the query parameters of the data and stream actions.
+// The "status" parameter is a comma-separated list of status codes or classes, such as "404" or "4xx,5xx".
+// The "param" parameter is a path parameter and its value, such as "id=42".
+// The "user" parameter is a user ID resolved by UserResolver.
+// It returns nil if no filter is requested.
+func requestsFilter(c echo.Context) (debugmonitor.EntryFilter, error) {
+	var filters []debugmonitor.EntryFilter
+
+	if status := c.QueryParam("status"); status != "" {
+		match, err := parseStatusFilter(status)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		filters = append(filters, func(entry *debugmonitor.DataEntry) bool {
+			payload, ok := entry.Payload.(*RequestPayload)
+			return ok && match(payload.Status)
+		})
+	}
+
+	if param := c.QueryParam("param"); param != "" {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok || name == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid param filter: "+param)
+		}
+		filters = append(filters, func(entry *debugmonitor.DataEntry) bool {
+			payload, ok := entry.Payload.(*RequestPayload)
+			if !ok {
+				return false
+			}
+			v, ok := payload.PathParams[name]
+			return ok && v == value
+		})
+	}
+
+	if user := c.QueryParam("user"); user != "" {
+		filters = append(filters, func(entry *debugmonitor.DataEntry) bool {
+			payload, ok := entry.Payload.(*RequestPayload)
+			return ok && payload.User == user
+		})
+	}
+
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	return func(entry *debugmonitor.DataEntry) bool {
+		for _, filter := range filters {
+			if !filter(entry) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// parseStatusFilter parses a comma-separated list of status codes ("404") and classes ("5xx")
+// and returns a function that reports whether a status matches any of them.
+func parseStatusFilter(s string) (func(status int) bool, error) {
+	var codes []int
+	var classes []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if len(part) == 3 && part[1:] == "xx" && part[0] >= '1' && part[0] <= '5' {
+			classes = append(classes, int(part[0]-'0'))
+			continue
+		}
+		code, err := strconv.Atoi(part)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid status filter: %q", part)
+		}
+		codes = append(codes, code)
+	}
+	return func(status int) bool {
+		for _, code := range codes {
+			if status == code {
+				return true
+			}
+		}
+		for _, class := range classes {
+			if status/100 == class {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
@@ -0,0 +1,126 @@
+package monitors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// replayOfHeader is the request header that links a replayed request to the entry it replays.
+const replayOfHeader = "X-Debugmonitor-Replay-Of"
+
+// ReplayResult is the response of a replayed request.
+type ReplayResult struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// Warnings describe how the replayed request may differ from the recorded one,
+	// such as the headers that were not captured and so were not sent.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// replayRequest is the body of a replay action request.
+type replayRequest struct {
+	// Headers override the headers of the recorded request. An empty value removes the header.
+	Headers map[string]string `json:"headers"`
+}
+
+// handleReplay re-issues a recorded request through the Echo instance that serves the dashboard.
+// The replayed request is recorded by the requests monitor with ReplayOf set to the original entry.
+//
+// Only what was captured can be replayed. Requests whose body has values redacted by RedactBodyFields are refused,
+// because the placeholder would be sent in place of the values. Headers that were not captured, such as
+// Authorization and Cookie by default, are not sent unless they are given as overrides, and the result has
+// a warning listing them.
+func handleReplay(c echo.Context, m *debugmonitor.Monitor, store *debugmonitor.Store, config *RequestsMonitorConfig) error {
+	if c.Request().Method != http.MethodPost {
+		return echo.NewHTTPError(http.StatusMethodNotAllowed)
+	}
+	if !config.EnableReplay || m.IsProductionSafe() {
+		return echo.NewHTTPError(http.StatusForbidden, "replay is disabled")
+	}
+
+	entry, err := debugmonitor.GetEntryFromQuery(c, store)
+	if err != nil {
+		return err
+	}
+	payload := entry.Payload.(*RequestPayload)
+	if payload.RequestBodyTruncated || (payload.RequestSize > 0 && payload.RequestBody == "") {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "the request body was not fully captured")
+	}
+	if strings.Contains(payload.RequestBody, redacted) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "the request body has redacted values")
+	}
+
+	var body replayRequest
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(c.Request().Context(), payload.Method, payload.URI, strings.NewReader(payload.RequestBody))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	req.RequestURI = payload.URI
+	req.Host = c.Request().Host
+	req.RemoteAddr = c.Request().RemoteAddr
+	for key, value := range payload.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range body.Headers {
+		if value == "" {
+			req.Header.Del(key)
+		} else {
+			req.Header.Set(key, value)
+		}
+	}
+	req.Header.Del(echo.HeaderContentLength)
+	// A replay is a new request, so it gets a new request ID
+	req.Header.Del(echo.HeaderXRequestID)
+	req.Header.Set(replayOfHeader, strconv.FormatInt(entry.Id, 10))
+
+	rec := httptest.NewRecorder()
+	c.Echo().ServeHTTP(rec, req)
+
+	result := &ReplayResult{
+		Status:  rec.Code,
+		Headers: make(map[string]string),
+		Body:    rec.Body.String(),
+	}
+	for key, values := range rec.Header() {
+		if len(values) > 0 {
+			result.Headers[key] = values[0]
+		}
+	}
+	if len(config.CaptureHeaders) > 0 {
+		result.Warnings = append(result.Warnings, "only the headers in CaptureHeaders were captured and sent")
+	}
+	if missing := uncapturedHeaders(config.IgnoreHeaders, body.Headers); len(missing) > 0 {
+		result.Warnings = append(result.Warnings, "the headers not captured were not sent: "+strings.Join(missing, ", "))
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// uncapturedHeaders returns the ignored headers that are not given as overrides, in canonical form.
+func uncapturedHeaders(ignoreHeaders []string, overrides map[string]string) []string {
+	var missing []string
+	for _, key := range ignoreHeaders {
+		overridden := false
+		for name := range overrides {
+			if strings.EqualFold(name, key) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			missing = append(missing, http.CanonicalHeaderKey(key))
+		}
+	}
+	return missing
+}
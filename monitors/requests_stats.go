@@ -0,0 +1,148 @@
+package monitors
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// routeStatsWindow is the number of most recent requests per route used to compute the rolling aggregates.
+const routeStatsWindow = 1000
+
+// RouteStats represents the aggregated statistics of a route.
+// ErrorRate and the latency percentiles are computed over the most recent requests of the route.
+type RouteStats struct {
+	Method     string  `json:"method"`
+	Route      string  `json:"route"`
+	Count      int     `json:"count"`      // total number of requests
+	ErrorCount int     `json:"errorCount"` // total number of requests that failed with a 5xx status
+	ErrorRate  float64 `json:"errorRate"`  // fraction of recent requests that failed with a 5xx status
+	P50        int64   `json:"p50"`        // in milliseconds
+	P95        int64   `json:"p95"`        // in milliseconds
+	P99        int64   `json:"p99"`        // in milliseconds
+	// RequestBytes is the total number of bytes read from the request bodies.
+	RequestBytes int64 `json:"requestBytes"`
+	// ResponseBytes is the total number of bytes written to the response bodies.
+	ResponseBytes int64 `json:"responseBytes"`
+}
+
+// routeSample is a single request in the rolling window of a route.
+type routeSample struct {
+	latency int64
+	failed  bool
+}
+
+// routeAggregate holds the running aggregates of a route.
+type routeAggregate struct {
+	method        string
+	route         string
+	count         int
+	errorCount    int
+	requestBytes  int64
+	responseBytes int64
+	samples       []routeSample // ring buffer of the most recent requests
+	next          int           // position of the next sample in the ring buffer
+}
+
+// requestStats is a sub-store of the requests monitor that maintains per-route aggregates.
+type requestStats struct {
+	mu     sync.Mutex
+	routes map[string]*routeAggregate
+}
+
+func newRequestStats() *requestStats {
+	return &requestStats{
+		routes: make(map[string]*routeAggregate),
+	}
+}
+
+// record adds a request to the aggregates of its route.
+func (s *requestStats) record(payload *RequestPayload) {
+	route := payload.Route
+	if route == "" {
+		route = "(unmatched)"
+	}
+	key := payload.Method + " " + route
+	failed := payload.Status >= http.StatusInternalServerError
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agg, ok := s.routes[key]
+	if !ok {
+		agg = &routeAggregate{method: payload.Method, route: route}
+		s.routes[key] = agg
+	}
+	agg.count++
+	if failed {
+		agg.errorCount++
+	}
+	agg.requestBytes += payload.RequestSize
+	agg.responseBytes += payload.ResponseSize
+	sample := routeSample{latency: payload.Latency, failed: failed}
+	if len(agg.samples) < routeStatsWindow {
+		agg.samples = append(agg.samples, sample)
+	} else {
+		agg.samples[agg.next] = sample
+	}
+	agg.next = (agg.next + 1) % routeStatsWindow
+}
+
+// snapshot returns the statistics of all routes, sorted by the number of requests in descending order.
+func (s *requestStats) snapshot() []*RouteStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]*RouteStats, 0, len(s.routes))
+	for _, agg := range s.routes {
+		latencies := make([]int64, len(agg.samples))
+		failed := 0
+		for i, sample := range agg.samples {
+			latencies[i] = sample.latency
+			if sample.failed {
+				failed++
+			}
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		result = append(result, &RouteStats{
+			Method:        agg.method,
+			Route:         agg.route,
+			Count:         agg.count,
+			ErrorCount:    agg.errorCount,
+			ErrorRate:     float64(failed) / float64(len(agg.samples)),
+			P50:           percentile(latencies, 50),
+			P95:           percentile(latencies, 95),
+			P99:           percentile(latencies, 99),
+			RequestBytes:  agg.requestBytes,
+			ResponseBytes: agg.responseBytes,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Method+" "+result[i].Route < result[j].Method+" "+result[j].Route
+	})
+	return result
+}
+
+// reset removes all aggregates.
+func (s *requestStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = make(map[string]*routeAggregate)
+}
+
+// percentile returns the p-th percentile of sorted values using the nearest-rank method.
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
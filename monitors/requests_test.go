@@ -0,0 +1,1225 @@
+package monitors
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// serveRequest sends the request through an Echo instance with the requests monitor and returns the recorded payload.
+func serveRequest(t *testing.T, config *RequestsMonitorConfig, handler echo.HandlerFunc, req *http.Request) *RequestPayload {
+	t.Helper()
+
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(config)
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.Any("/*", handler)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case event := <-sub.C:
+		return event.Entry.Payload.(*RequestPayload)
+	default:
+		t.Fatal("Expected request to be recorded")
+		return nil
+	}
+}
+
+func TestRequestsMonitor_CaptureRequestBody(t *testing.T) {
+	readBody := func(c echo.Context) error {
+		_, err := io.ReadAll(c.Request().Body)
+		return err
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"alice","password":"secret"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	payload := serveRequest(t, &RequestsMonitorConfig{
+		CaptureRequestBody: true,
+		RedactBodyFields:   []string{"Password"},
+	}, readBody, req)
+	if payload.RequestBody != `{"name":"alice","password":"[REDACTED]"}` {
+		t.Errorf("Unexpected request body: %s", payload.RequestBody)
+	}
+	if payload.RequestBodyTruncated {
+		t.Errorf("Expected request body not to be truncated")
+	}
+
+	// Bodies are truncated at MaxBodySize
+	req = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("0123456789"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+	payload = serveRequest(t, &RequestsMonitorConfig{
+		CaptureRequestBody: true,
+		MaxBodySize:        4,
+	}, readBody, req)
+	if payload.RequestBody != "0123" || !payload.RequestBodyTruncated {
+		t.Errorf("Expected truncated request body, got %q (truncated=%v)", payload.RequestBody, payload.RequestBodyTruncated)
+	}
+
+	// Content types not in the allowlist are not captured
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("binary"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEOctetStream)
+	payload = serveRequest(t, &RequestsMonitorConfig{CaptureRequestBody: true}, readBody, req)
+	if payload.RequestBody != "" {
+		t.Errorf("Expected request body not to be captured, got %q", payload.RequestBody)
+	}
+
+	// Bodies are not captured unless enabled
+	req = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"alice"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	payload = serveRequest(t, nil, readBody, req)
+	if payload.RequestBody != "" {
+		t.Errorf("Expected request body not to be captured, got %q", payload.RequestBody)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	fields := []string{"token"}
+
+	got := redactBody("user=alice&token=abc", echo.MIMEApplicationForm, fields)
+	if got != "token=%5BREDACTED%5D&user=alice" {
+		t.Errorf("Unexpected redacted form body: %s", got)
+	}
+
+	got = redactBody(`{"items":[{"token":"abc"}]}`, echo.MIMEApplicationJSONCharsetUTF8, fields)
+	if got != `{"items":[{"token":"[REDACTED]"}]}` {
+		t.Errorf("Unexpected redacted JSON body: %s", got)
+	}
+
+	// A JSON body cut at the maximum body size cannot be parsed
+	got = redactBody(`{"token": "abc", "user": {"Token":"secr`, echo.MIMEApplicationJSON, fields)
+	if got != `{"token": "[REDACTED]", "user": {"Token":"[REDACTED]"` {
+		t.Errorf("Unexpected redacted truncated JSON body: %s", got)
+	}
+
+	got = redactBody("user=alice&token=a%zz", echo.MIMEApplicationForm, fields)
+	if got != "user=alice&token=%5BREDACTED%5D" {
+		t.Errorf("Unexpected redacted invalid form body: %s", got)
+	}
+}
+
+func TestRequestsMonitor_RedactTruncatedBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"user":"alice","password":"secret-password"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	payload := serveRequest(t, &RequestsMonitorConfig{
+		CaptureRequestBody: true,
+		MaxBodySize:        36,
+		RedactBodyFields:   []string{"password"},
+	}, func(c echo.Context) error {
+		_, err := io.ReadAll(c.Request().Body)
+		return err
+	}, req)
+
+	if !payload.RequestBodyTruncated {
+		t.Fatalf("Expected the request body to be truncated, got %q", payload.RequestBody)
+	}
+	if strings.Contains(payload.RequestBody, "secret") {
+		t.Errorf("Expected the password of the truncated body to be redacted, got %q", payload.RequestBody)
+	}
+}
+
+func TestRequestsMonitor_CaptureResponseBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	payload := serveRequest(t, &RequestsMonitorConfig{
+		CaptureResponseBody: true,
+		RedactBodyFields:    []string{"email"},
+	}, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]any{"name": "alice", "email": "alice@example.com"})
+	}, req)
+	if payload.ResponseBody != `{"email":"[REDACTED]","name":"alice"}` {
+		t.Errorf("Unexpected response body: %s", payload.ResponseBody)
+	}
+
+	// Content types not in the allowlist are not captured
+	req = httptest.NewRequest(http.MethodGet, "/image", nil)
+	payload = serveRequest(t, &RequestsMonitorConfig{CaptureResponseBody: true}, func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "image/png", []byte("png"))
+	}, req)
+	if payload.ResponseBody != "" {
+		t.Errorf("Expected response body not to be captured, got %q", payload.ResponseBody)
+	}
+}
+
+func getUser(c echo.Context) error {
+	return c.NoContent(http.StatusOK)
+}
+
+func updateUser(c echo.Context) error {
+	return c.NoContent(http.StatusOK)
+}
+
+func TestRequestsMonitor_RouteAndHandler(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/users/:id", getUser)
+	e.PUT("/users/:id", updateUser)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	payload := (<-sub.C).Entry.Payload.(*RequestPayload)
+	if payload.Route != "/users/:id" {
+		t.Errorf("Expected route /users/:id, got %s", payload.Route)
+	}
+	if !strings.HasSuffix(payload.Handler, ".getUser") {
+		t.Errorf("Expected handler getUser, got %s", payload.Handler)
+	}
+	if payload.URI != "/users/1" {
+		t.Errorf("Expected URI /users/1, got %s", payload.URI)
+	}
+
+	// The handler is looked up by method as well as path
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/users/1", nil))
+	payload = (<-sub.C).Entry.Payload.(*RequestPayload)
+	if !strings.HasSuffix(payload.Handler, ".updateUser") {
+		t.Errorf("Expected handler updateUser, got %s", payload.Handler)
+	}
+}
+
+func TestRequestsMonitor_DetailAction(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/users/:id", getUser)
+	e.GET("/monitor", m.Handler())
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	entry := (<-sub.C).Entry
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/monitor?monitor=requests&action=detail&id=%d&format=json", entry.Id), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"route":"/users/:id"`) {
+		t.Errorf("Expected JSON detail, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/monitor?monitor=requests&action=detail&id=%d", entry.Id), nil))
+	if !strings.Contains(rec.Body.String(), "/users/:id") {
+		t.Errorf("Expected HTML detail, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=detail&id=1", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestParseStatusFilter(t *testing.T) {
+	match, err := parseStatusFilter("404, 5xx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for status, expected := range map[int]bool{200: false, 404: true, 400: false, 500: true, 503: true} {
+		if match(status) != expected {
+			t.Errorf("Expected match(%d) to be %v", status, expected)
+		}
+	}
+
+	for _, invalid := range []string{"abc", "6xx", "99", ""} {
+		if _, err := parseStatusFilter(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestRequestsMonitor_StatusFilter(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/ok", getUser)
+	e.GET("/fail", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	})
+	e.GET("/monitor", m.Handler())
+	for _, path := range []string{"/ok", "/fail", "/ok"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=data&status=5xx", nil))
+	var entries []struct {
+		Payload RequestPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Payload.Status != http.StatusInternalServerError {
+		t.Errorf("Expected only the failed request, got %+v", entries)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=data&status=bad", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestRequestsMonitor_SampleRate(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(&RequestsMonitorConfig{
+		SampleRate: 0.0001,
+	})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/ok", getUser)
+	e.GET("/fail", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	})
+	for i := 0; i < 50; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	}
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	// Almost no successful requests are sampled, but the error is always recorded
+	var recorded, failed int
+	for len(sub.C) > 0 {
+		payload := (<-sub.C).Entry.Payload.(*RequestPayload)
+		recorded++
+		if payload.URI == "/fail" {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Expected the failed request to be recorded, got %d", failed)
+	}
+	if recorded > 5 {
+		t.Errorf("Expected only a few sampled requests, got %d", recorded)
+	}
+}
+
+func TestRequestsMonitor_ProductionSafeRedaction(t *testing.T) {
+	m := debugmonitor.NewProductionSafe()
+	monitor, mw := NewRequestsMonitor(&RequestsMonitorConfig{
+		UserResolver: func(c echo.Context) string { return "alice@example.com" },
+	})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	// Errors are recorded regardless of sampling
+	e.GET("/users/:email", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/bob@example.com", nil))
+
+	select {
+	case event := <-sub.C:
+		payload := event.Entry.Payload.(*RequestPayload)
+		if payload.PathParams["email"] != redacted || payload.User != redacted {
+			t.Errorf("Expected the path parameters and the user to be redacted, got %v and %q", payload.PathParams, payload.User)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the request to be recorded")
+	}
+}
+
+func TestRequestsMonitor_RuntimeSampleRate(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(monitor)
+
+	// A zero sample rate set in the settings panel drops the successful requests but not the errors
+	settings, _ := m.MonitorSettings(monitor.Name)
+	settings.SampleRate = 0
+	if err := m.UpdateMonitorSettings(monitor.Name, settings); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/ok", getUser)
+	e.GET("/fail", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	})
+	e.GET("/bad-gateway", func(c echo.Context) error {
+		return c.NoContent(http.StatusBadGateway)
+	})
+	for _, path := range []string{"/ok", "/fail", "/bad-gateway"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var uris []string
+	for len(sub.C) > 0 {
+		uris = append(uris, (<-sub.C).Entry.Payload.(*RequestPayload).URI)
+	}
+	if len(uris) != 2 || uris[0] != "/fail" || uris[1] != "/bad-gateway" {
+		t.Errorf("Expected only the errors to be recorded, got %v", uris)
+	}
+}
+
+func TestRequestsMonitor_PathFilters(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(&RequestsMonitorConfig{
+		IncludePaths: []string{`^/api/`, `^/healthz$`},
+		ExcludePaths: []string{`^/healthz$`, `\.png$`},
+	})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/*", getUser)
+	for _, path := range []string{"/api/users", "/healthz", "/api/logo.png", "/about"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var uris []string
+	for len(sub.C) > 0 {
+		uris = append(uris, (<-sub.C).Entry.Payload.(*RequestPayload).URI)
+	}
+	if len(uris) != 1 || uris[0] != "/api/users" {
+		t.Errorf("Expected only /api/users to be recorded, got %v", uris)
+	}
+}
+
+func TestRequestsMonitor_StatsAction(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(&RequestsMonitorConfig{
+		// Stats cover requests that are not sampled
+		SampleRate: 0.0001,
+	})
+	m.AddMonitor(monitor)
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/users/:id", getUser)
+	e.GET("/fail", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	})
+	e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor", m.Handler())
+	for i := 0; i < 3; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d", i), nil))
+	}
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=stats&format=json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var routes []*RouteStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	// The monitor's own stats request is recorded as well
+	if len(routes) < 2 {
+		t.Fatalf("Expected at least 2 routes, got %d", len(routes))
+	}
+	if routes[0].Route != "/users/:id" || routes[0].Count != 3 || routes[0].ErrorRate != 0 {
+		t.Errorf("Unexpected stats for /users/:id: %+v", routes[0])
+	}
+	for _, route := range routes {
+		if route.Route == "/fail" && (route.Count != 1 || route.ErrorCount != 1 || route.ErrorRate != 1) {
+			t.Errorf("Unexpected stats for /fail: %+v", route)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=stats", nil))
+	if !strings.Contains(rec.Body.String(), "/users/:id") || !strings.Contains(rec.Body.String(), "100.0%") {
+		t.Errorf("Expected HTML stats, got %s", rec.Body.String())
+	}
+
+	// Reset the stats
+	req := httptest.NewRequest(http.MethodPost, "/monitor?monitor=requests&action=stats", nil)
+	req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+	req.Header.Set("X-CSRF-Token", "test-token")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=stats&format=json", nil))
+	if strings.Contains(rec.Body.String(), "/users/:id") {
+		t.Errorf("Expected stats to be reset, got %s", rec.Body.String())
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p    int
+		want int64
+	}{
+		{50, 5},
+		{95, 10},
+		{99, 10},
+		{1, 1},
+	}
+	for _, tt := range tests {
+		if got := percentile(values, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("Expected 0 for no values, got %d", got)
+	}
+}
+
+func TestRequestsMonitor_RequestID(t *testing.T) {
+	var contextID string
+	handler := func(c echo.Context) error {
+		contextID = debugmonitor.RequestIDFromContext(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	}
+
+	// A new ID is generated
+	payload := serveRequest(t, nil, handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(payload.RequestID) != 32 {
+		t.Errorf("Expected a generated request ID, got %q", payload.RequestID)
+	}
+	if contextID != payload.RequestID {
+		t.Errorf("Expected the request context to carry %q, got %q", payload.RequestID, contextID)
+	}
+
+	// The ID sent by the client is reused
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRequestID, "abc123")
+	payload = serveRequest(t, nil, handler, req)
+	if payload.RequestID != "abc123" || contextID != "abc123" {
+		t.Errorf("Expected request ID abc123, got %q (context %q)", payload.RequestID, contextID)
+	}
+
+	// The ID is echoed in the response
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(monitor)
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/", handler)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get(echo.HeaderXRequestID); got == "" || got != contextID {
+		t.Errorf("Expected response header %q, got %q", contextID, got)
+	}
+}
+
+func TestRequestsMonitor_Timeline(t *testing.T) {
+	m := debugmonitor.New()
+	requestsMonitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(requestsMonitor)
+	errorsMonitor, recorder := NewErrorsMonitor(ErrorsMonitorConfig{})
+	m.AddMonitor(errorsMonitor)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandlerWrapper(recorder, e.DefaultHTTPErrorHandler)
+	e.Use(mw)
+	e.GET("/fail", func(c echo.Context) error {
+		return fmt.Errorf("something went wrong")
+	})
+	e.GET("/monitor", m.Handler())
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=timeline&request_id=req-1&format=json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var timeline []*debugmonitor.TimelineEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &timeline); err != nil {
+		t.Fatalf("Failed to decode timeline: %v", err)
+	}
+	if len(timeline) != 1 || timeline[0].Monitor != "errors" || !strings.Contains(timeline[0].Summary, "something went wrong") {
+		t.Errorf("Expected the error in the timeline, got %+v", timeline)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=timeline&request_id=req-1", nil))
+	if !strings.Contains(rec.Body.String(), "something went wrong") {
+		t.Errorf("Expected HTML timeline, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=timeline", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestRequestsMonitor_QueryParamsAndFormValues(t *testing.T) {
+	config := &RequestsMonitorConfig{
+		CaptureRequestBody: true,
+		RedactBodyFields:   []string{"token", "password"},
+	}
+
+	// Query parameters
+	payload := serveRequest(t, config, getUser, httptest.NewRequest(http.MethodGet, "/search?q=go&tag=a&tag=b&token=secret", nil))
+	if got := payload.QueryParams["tag"]; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Expected tag=[a b], got %v", got)
+	}
+	if got := payload.QueryParams["token"]; len(got) != 1 || got[0] != "[REDACTED]" {
+		t.Errorf("Expected token to be redacted, got %v", got)
+	}
+	if payload.FormValues != nil {
+		t.Errorf("Expected no form values, got %v", payload.FormValues)
+	}
+
+	// Form values parsed by the handler
+	handler := func(c echo.Context) error {
+		_, _ = c.FormParams()
+		return c.NoContent(http.StatusOK)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("name=alice&password=secret"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	payload = serveRequest(t, config, handler, req)
+	if got := payload.FormValues["name"]; len(got) != 1 || got[0] != "alice" {
+		t.Errorf("Expected name=alice, got %v", got)
+	}
+	if got := payload.FormValues["password"]; len(got) != 1 || got[0] != "[REDACTED]" {
+		t.Errorf("Expected password to be redacted, got %v", got)
+	}
+
+	// Form values parsed from the captured body when the handler does not parse the form
+	req = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("name=bob"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	payload = serveRequest(t, config, func(c echo.Context) error {
+		_, _ = io.ReadAll(c.Request().Body)
+		return c.NoContent(http.StatusOK)
+	}, req)
+	if got := payload.FormValues["name"]; len(got) != 1 || got[0] != "bob" {
+		t.Errorf("Expected name=bob, got %v", got)
+	}
+}
+
+func TestRequestsMonitor_Sizes(t *testing.T) {
+	handler := func(c echo.Context) error {
+		_, _ = io.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, "hello")
+	}
+	payload := serveRequest(t, nil, handler, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")))
+	if payload.RequestSize != 10 {
+		t.Errorf("Expected request size 10, got %d", payload.RequestSize)
+	}
+	if payload.ResponseSize != 5 {
+		t.Errorf("Expected response size 5, got %d", payload.ResponseSize)
+	}
+
+	stats := newRequestStats()
+	stats.record(payload)
+	stats.record(payload)
+	routes := stats.snapshot()
+	if len(routes) != 1 || routes[0].RequestBytes != 20 || routes[0].ResponseBytes != 10 {
+		t.Errorf("Expected byte totals 20/10, got %+v", routes[0])
+	}
+}
+
+func TestCurlCommand(t *testing.T) {
+	payload := &RequestPayload{
+		Method: http.MethodPost,
+		URI:    "/users?debug=1",
+		Headers: map[string]string{
+			"Content-Type":   "application/json",
+			"Content-Length": "20",
+			"X-Note":         "it's",
+		},
+		RequestBody: `{"name":"alice"}`,
+	}
+	want := "curl -X POST \\\n" +
+		"  -H 'Content-Type: application/json' \\\n" +
+		"  -H 'X-Note: it'\\''s' \\\n" +
+		"  --data-raw '{\"name\":\"alice\"}' \\\n" +
+		"  'http://localhost:8080/users?debug=1'"
+	if got := curlCommand(payload, "http://localhost:8080"); got != want {
+		t.Errorf("Unexpected curl command:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRequestsMonitor_CurlAction(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/users/:id", getUser)
+	e.GET("/monitor", m.Handler())
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	entry := (<-sub.C).Entry
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/monitor?monitor=requests&action=curl&id=%d", entry.Id), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "curl -X GET") || !strings.Contains(rec.Body.String(), "'http://example.com/users/1'") {
+		t.Errorf("Unexpected curl command: %s", rec.Body.String())
+	}
+}
+
+func TestRequestsMonitor_Replay(t *testing.T) {
+	newServer := func(manager *debugmonitor.Manager, config *RequestsMonitorConfig) (*echo.Echo, *debugmonitor.Subscription) {
+		monitor, mw := NewRequestsMonitor(config)
+		manager.AddMonitor(monitor)
+		e := echo.New()
+		e.Use(mw)
+		e.POST("/echo", func(c echo.Context) error {
+			b, _ := io.ReadAll(c.Request().Body)
+			return c.String(http.StatusOK, c.Request().Header.Get("X-Mode")+":"+string(b))
+		})
+		e.Match([]string{http.MethodGet, http.MethodPost}, "/monitor", manager.Handler())
+		return e, manager.Subscribe()
+	}
+	replay := func(e *echo.Echo, id int64, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/monitor?monitor=requests&action=replay&id=%d", id), strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "_debugmonitor_csrf", Value: "test-token"})
+		req.Header.Set("X-CSRF-Token", "test-token")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	e, sub := newServer(debugmonitor.New(), &RequestsMonitorConfig{
+		CaptureRequestBody: true,
+		EnableReplay:       true,
+		ExcludePaths:       []string{"^/monitor$"},
+	})
+	defer sub.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+	req.Header.Set("X-Mode", "original")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	original := (<-sub.C).Entry
+
+	rec := replay(e, original.Id, `{"headers":{"X-Mode":"override"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result ReplayResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode replay result: %v", err)
+	}
+	if result.Status != http.StatusOK || result.Body != "override:hello" {
+		t.Errorf("Unexpected replay result: %+v", result)
+	}
+	// The default ignored headers were not captured, so they were not sent
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "Authorization, Cookie, Proxy-Authorization") {
+		t.Errorf("Expected a warning about the headers not sent, got %v", result.Warnings)
+	}
+
+	replayed := (<-sub.C).Entry.Payload.(*RequestPayload)
+	if replayed.ReplayOf != original.Id {
+		t.Errorf("Expected the replay to refer to entry %d, got %d", original.Id, replayed.ReplayOf)
+	}
+	if _, ok := replayed.Headers[replayOfHeader]; ok {
+		t.Error("Expected the replay header not to be recorded")
+	}
+
+	// Headers given as overrides are sent
+	rec = replay(e, original.Id, `{"headers":{"authorization":"Bearer token","Cookie":"","Proxy-Authorization":"Basic"}}`)
+	result = ReplayResult{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode replay result: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", result.Warnings)
+	}
+
+	// Requests with redacted body values are refused
+	e, sub = newServer(debugmonitor.New(), &RequestsMonitorConfig{
+		CaptureRequestBody: true,
+		EnableReplay:       true,
+		ExcludePaths:       []string{"^/monitor$"},
+		RedactBodyFields:   []string{"password"},
+	})
+	defer sub.Close()
+	req = httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"name":"alice","password":"secret"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	if rec := replay(e, (<-sub.C).Entry.Id, ""); rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "redacted") {
+		t.Errorf("Expected status 422 for a redacted body, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Replay is disabled by default
+	e, sub = newServer(debugmonitor.New(), &RequestsMonitorConfig{CaptureRequestBody: true})
+	defer sub.Close()
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/echo", nil))
+	if rec := replay(e, (<-sub.C).Entry.Id, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rec.Code)
+	}
+}
+
+func TestRequestsMonitor_Timings(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	sleep := func(d time.Duration) echo.MiddlewareFunc {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				time.Sleep(d)
+				return next(c)
+			}
+		}
+	}
+
+	e := echo.New()
+	e.Use(mw)
+	e.Use(TimeMiddleware("auth", sleep(20*time.Millisecond)))
+	e.Use(TimeMiddleware("session", sleep(0)))
+	e.Use(TimeHandler())
+	e.GET("/", func(c echo.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	payload := (<-sub.C).Entry.Payload.(*RequestPayload)
+	if len(payload.Timings) != 3 {
+		t.Fatalf("Expected 3 timings, got %+v", payload.Timings)
+	}
+	auth, session, handler := payload.Timings[0], payload.Timings[1], payload.Timings[2]
+	if auth.Name != "auth" || session.Name != "session" || handler.Name != "handler" {
+		t.Errorf("Unexpected timing names: %+v", payload.Timings)
+	}
+	// The time spent in the rest of the chain is excluded
+	if auth.Duration < 20000 || auth.Duration >= 50000 {
+		t.Errorf("Expected auth to take about 20ms, got %dµs", auth.Duration)
+	}
+	if session.Duration >= 50000 {
+		t.Errorf("Expected session to exclude the handler, got %dµs", session.Duration)
+	}
+	if handler.Duration < 50000 {
+		t.Errorf("Expected handler to take at least 50ms, got %dµs", handler.Duration)
+	}
+
+	// Without the requests monitor, the wrappers just run the middleware
+	e = echo.New()
+	e.Use(TimeMiddleware("auth", sleep(0)))
+	e.Use(TimeHandler())
+	e.GET("/", getUser)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+}
+
+func TestRequestsMonitor_CaptureAndIgnoreHeaders(t *testing.T) {
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Cookie", "session=secret")
+		req.Header.Set("Accept", "text/html")
+		req.Header.Set("X-Tenant", "acme")
+		return req
+	}
+
+	// Authorization and Cookie are ignored by default
+	payload := serveRequest(t, nil, getUser, newRequest())
+	if _, ok := payload.Headers["Authorization"]; ok {
+		t.Error("Expected Authorization not to be captured by default")
+	}
+	if _, ok := payload.Headers["Cookie"]; ok {
+		t.Error("Expected Cookie not to be captured by default")
+	}
+	if payload.Headers["X-Tenant"] != "acme" {
+		t.Errorf("Expected X-Tenant to be captured, got %v", payload.Headers)
+	}
+
+	// Only the allowed headers are captured
+	payload = serveRequest(t, &RequestsMonitorConfig{CaptureHeaders: []string{"x-tenant", "Authorization"}}, getUser, newRequest())
+	if len(payload.Headers) != 1 || payload.Headers["X-Tenant"] != "acme" {
+		t.Errorf("Expected only X-Tenant to be captured, got %v", payload.Headers)
+	}
+
+	// IgnoreHeaders replaces the default
+	payload = serveRequest(t, &RequestsMonitorConfig{IgnoreHeaders: []string{"Accept"}}, getUser, newRequest())
+	if _, ok := payload.Headers["Accept"]; ok {
+		t.Error("Expected Accept not to be captured")
+	}
+	if payload.Headers["Authorization"] != "Bearer secret" {
+		t.Errorf("Expected Authorization to be captured, got %v", payload.Headers)
+	}
+}
+
+func TestRequestsMonitor_PathParams(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/users/:id/posts/:post", getUser)
+	e.GET("/monitor", m.Handler())
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42/posts/7", nil))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/43/posts/7", nil))
+
+	payload := (<-sub.C).Entry.Payload.(*RequestPayload)
+	if len(payload.PathParams) != 2 || payload.PathParams["id"] != "42" || payload.PathParams["post"] != "7" {
+		t.Errorf("Unexpected path params: %v", payload.PathParams)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=data&param=id%3D43", nil))
+	var entries []struct {
+		Payload RequestPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Payload.PathParams["id"] != "43" {
+		t.Errorf("Expected only the request for id 43, got %+v", entries)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=requests&action=data&param=id", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestRequestsMonitor_UserResolver(t *testing.T) {
+	config := &RequestsMonitorConfig{
+		UserResolver: func(c echo.Context) string {
+			user, _ := c.Get("user").(string)
+			return user
+		},
+	}
+	handler := func(c echo.Context) error {
+		// Set by the authentication layer while serving the request
+		c.Set("user", "alice")
+		return c.NoContent(http.StatusOK)
+	}
+	payload := serveRequest(t, config, handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	if payload.User != "alice" {
+		t.Errorf("Expected user alice, got %q", payload.User)
+	}
+
+	payload = serveRequest(t, nil, handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	if payload.User != "" {
+		t.Errorf("Expected no user without a resolver, got %q", payload.User)
+	}
+}
+
+func TestRequestsMonitor_ServerTiming(t *testing.T) {
+	m := debugmonitor.New()
+	requestsMonitor, mw := NewRequestsMonitor(&RequestsMonitorConfig{ServerTiming: true})
+	m.AddMonitor(requestsMonitor)
+	queriesMonitor, db := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeDriver{}})
+	m.AddMonitor(queriesMonitor)
+	defer db.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/", func(c echo.Context) error {
+		for i := 0; i < 2; i++ {
+			if _, err := db.ExecContext(c.Request().Context(), "select sleep"); err != nil {
+				return err
+			}
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	header := rec.Header().Get("Server-Timing")
+	if !strings.HasPrefix(header, "app;dur=") || !strings.Contains(header, "db;dur=") || !strings.Contains(header, `desc="2 queries"`) {
+		t.Errorf("Unexpected Server-Timing header: %q", header)
+	}
+
+	// The header is not emitted by default
+	e = echo.New()
+	_, mw = NewRequestsMonitor(nil)
+	e.Use(mw)
+	e.GET("/", getUser)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if header := rec.Header().Get("Server-Timing"); header != "" {
+		t.Errorf("Expected no Server-Timing header, got %q", header)
+	}
+}
+
+func TestRequestsMonitor_QueryHeaders(t *testing.T) {
+	m := debugmonitor.New()
+	requestsMonitor, mw := NewRequestsMonitor(&RequestsMonitorConfig{QueryHeaders: true})
+	m.AddMonitor(requestsMonitor)
+	queriesMonitor, db := NewQueriesMonitor(QueriesMonitorConfig{Driver: fakeDriver{}})
+	m.AddMonitor(queriesMonitor)
+	defer db.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/", func(c echo.Context) error {
+		for i := 0; i < 3; i++ {
+			if _, err := db.ExecContext(c.Request().Context(), "select sleep"); err != nil {
+				return err
+			}
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if count := rec.Header().Get("X-DB-Query-Count"); count != "3" {
+		t.Errorf("Expected X-DB-Query-Count: 3, got %q", count)
+	}
+	if ms, err := strconv.ParseFloat(rec.Header().Get("X-DB-Query-Time"), 64); err != nil || ms < 30 {
+		t.Errorf("Expected X-DB-Query-Time of at least 30ms, got %q", rec.Header().Get("X-DB-Query-Time"))
+	}
+}
+
+func TestRequestsMonitor_DecodeCompressedResponseBody(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(&RequestsMonitorConfig{CaptureResponseBody: true})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	e.Use(middleware.GzipWithConfig(middleware.GzipConfig{MinLength: 1}))
+	e.GET("/", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]any{"name": "alice"})
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Header().Get(echo.HeaderContentEncoding) != "gzip" {
+		t.Fatal("Expected the response to be compressed")
+	}
+
+	payload := (<-sub.C).Entry.Payload.(*RequestPayload)
+	if payload.ResponseBody != "{\"name\":\"alice\"}\n" {
+		t.Errorf("Expected the decoded body, got %q", payload.ResponseBody)
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, _ = gw.Write([]byte("0123456789"))
+	_ = gw.Close()
+
+	body, truncated, ok := decodeBody(compressed.Bytes(), "gzip", 64)
+	if !ok || truncated || body != "0123456789" {
+		t.Errorf("Unexpected result: %q, %v, %v", body, truncated, ok)
+	}
+
+	body, truncated, ok = decodeBody(compressed.Bytes(), "gzip", 4)
+	if !ok || !truncated || body != "0123" {
+		t.Errorf("Expected the body to be cut at the limit, got %q, %v, %v", body, truncated, ok)
+	}
+
+	if _, _, ok := decodeBody([]byte("binary"), "br", 64); ok {
+		t.Error("Expected br not to be supported")
+	}
+
+	body, _, ok = decodeBody([]byte("plain"), "", 64)
+	if !ok || body != "plain" {
+		t.Errorf("Expected an unencoded body as is, got %q", body)
+	}
+}
+
+func TestDecodeBody_Oversized(t *testing.T) {
+	// 1024 gzip members of 1 MiB of zeros each, which decompress to 1 GiB
+	var member bytes.Buffer
+	gw := gzip.NewWriter(&member)
+	_, _ = gw.Write(make([]byte, 1<<20))
+	_ = gw.Close()
+	compressed := bytes.Repeat(member.Bytes(), 1024)
+
+	start := time.Now()
+	body, truncated, ok := decodeBody(compressed, "gzip", 16)
+	if !ok || !truncated || len(body) != 16 {
+		t.Errorf("Expected the body to be cut at the limit, got %d bytes, %v, %v", len(body), truncated, ok)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected the body not to be decompressed beyond the limit, took %v", elapsed)
+	}
+}
+
+func TestRequestsMonitor_MultipartFiles(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("title", "report")
+	part, _ := w.CreateFormFile("attachment", "report.csv")
+	_, _ = part.Write([]byte("a,b,c\n1,2,3\n"))
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	payload := serveRequest(t, nil, func(c echo.Context) error {
+		if _, err := c.MultipartForm(); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusOK)
+	}, req)
+
+	if len(payload.Files) != 1 {
+		t.Fatalf("Expected 1 file, got %+v", payload.Files)
+	}
+	file := payload.Files[0]
+	if file.Field != "attachment" || file.Filename != "report.csv" || file.ContentType != "application/octet-stream" || file.Size != 12 {
+		t.Errorf("Unexpected file metadata: %+v", file)
+	}
+	if got := payload.FormValues["title"]; len(got) != 1 || got[0] != "report" {
+		t.Errorf("Expected title=report, got %v", got)
+	}
+	if payload.RequestBody != "" {
+		t.Error("Expected the body not to be captured")
+	}
+}
+
+func TestRequestsMonitor_Enricher(t *testing.T) {
+	config := &RequestsMonitorConfig{
+		Enricher: func(payload *RequestPayload, c echo.Context) {
+			payload.Extra = map[string]string{
+				"Country": c.Request().Header.Get("X-Country"),
+			}
+		},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Country", "JP")
+	payload := serveRequest(t, config, getUser, req)
+	if payload.Extra["Country"] != "JP" {
+		t.Errorf("Expected the enriched country, got %v", payload.Extra)
+	}
+}
+
+func TestRequestsMonitor_ContentNegotiation(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAccept, "application/json")
+	req.Header.Set("Accept-Language", "ja")
+	payload := serveRequest(t, nil, func(c echo.Context) error {
+		return c.HTML(http.StatusOK, "<p>hello</p>")
+	}, req)
+	if payload.Accept != "application/json" || payload.AcceptLanguage != "ja" {
+		t.Errorf("Unexpected accepted representation: %q, %q", payload.Accept, payload.AcceptLanguage)
+	}
+	if payload.ContentType != echo.MIMETextHTMLCharsetUTF8 {
+		t.Errorf("Unexpected response content type: %q", payload.ContentType)
+	}
+}
+
+func TestRequestsMonitor_WebSocket(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, mw := NewRequestsMonitor(nil)
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	e := echo.New()
+	e.Use(mw)
+	handshake := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"
+	e.GET("/ws", func(c echo.Context) error {
+		// A minimal upgrade that echoes a message, in place of a WebSocket library
+		conn, rw, err := c.Response().Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString(handshake)
+		_ = rw.Flush()
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(rw, buf); err != nil {
+			return nil
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = conn.Write(buf)
+		return nil
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: example.com\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"))
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status 101, got %d", res.StatusCode)
+	}
+	_, _ = conn.Write([]byte("ping"))
+
+	select {
+	case event := <-sub.C:
+		payload := event.Entry.Payload.(*RequestPayload)
+		if payload.Type != "websocket" || payload.Status != http.StatusSwitchingProtocols {
+			t.Errorf("Expected a WebSocket entry, got type %q and status %d", payload.Type, payload.Status)
+		}
+		if payload.WebSocket == nil {
+			t.Fatal("Expected the WebSocket connection to be recorded")
+		}
+		if payload.WebSocket.Duration < 20 {
+			t.Errorf("Expected the connection to be open for at least 20ms, got %dms", payload.WebSocket.Duration)
+		}
+		if want := int64(len(handshake) + 4); payload.WebSocket.BytesWritten != want {
+			t.Errorf("Expected %d bytes written, got %d", want, payload.WebSocket.BytesWritten)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the WebSocket request to be recorded")
+	}
+}
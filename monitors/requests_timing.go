@@ -0,0 +1,98 @@
+package monitors
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MiddlewareTiming is the time spent in a middleware or the handler while serving a request.
+type MiddlewareTiming struct {
+	Name     string `json:"name"`
+	Duration int64  `json:"duration"` // in microseconds, excluding the time spent in the rest of the chain
+}
+
+// chainTimingsKey is the key of the echo.Context value that collects the timings of a request.
+const chainTimingsKey = "debugmonitor.chainTimings"
+
+// chainTimings collects the timings of the middleware chain of a request.
+type chainTimings struct {
+	timings []MiddlewareTiming
+	inner   []time.Duration // time spent in the rest of the chain, for each timing
+	stack   []int           // indexes of the timings that are running
+}
+
+func chainTimingsFrom(c echo.Context) *chainTimings {
+	t, _ := c.Get(chainTimingsKey).(*chainTimings)
+	return t
+}
+
+// begin starts a timing and returns its index.
+func (t *chainTimings) begin(name string) int {
+	t.timings = append(t.timings, MiddlewareTiming{Name: name})
+	t.inner = append(t.inner, 0)
+	t.stack = append(t.stack, len(t.timings)-1)
+	return len(t.timings) - 1
+}
+
+// excludeInner subtracts the time spent in the rest of the chain from the running timing.
+func (t *chainTimings) excludeInner(d time.Duration) {
+	if len(t.stack) > 0 {
+		t.inner[t.stack[len(t.stack)-1]] += d
+	}
+}
+
+// end finishes the timing at index i that took d in total.
+func (t *chainTimings) end(i int, d time.Duration) {
+	t.timings[i].Duration = (d - t.inner[i]).Microseconds()
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// TimeMiddleware wraps mw so that the requests monitor records the time spent in it under name.
+// The time spent in the rest of the chain is excluded. The requests monitor middleware must be
+// registered before the wrapped middleware; otherwise nothing is recorded.
+//
+//	e.Use(requestsMiddleware)
+//	e.Use(monitors.TimeMiddleware("auth", authMiddleware))
+//	e.Use(monitors.TimeHandler())
+func TimeMiddleware(name string, mw echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		h := mw(func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if t := chainTimingsFrom(c); t != nil {
+				t.excludeInner(time.Since(start))
+			}
+			return err
+		})
+		return func(c echo.Context) error {
+			t := chainTimingsFrom(c)
+			if t == nil {
+				return h(c)
+			}
+			i := t.begin(name)
+			start := time.Now()
+			err := h(c)
+			t.end(i, time.Since(start))
+			return err
+		}
+	}
+}
+
+// TimeHandler returns a middleware that records the time spent in the route handler under "handler".
+// Register it after all other middleware.
+func TimeHandler() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			t := chainTimingsFrom(c)
+			if t == nil {
+				return next(c)
+			}
+			i := t.begin("handler")
+			start := time.Now()
+			err := next(c)
+			t.end(i, time.Since(start))
+			return err
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package monitors
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WebSocketInfo is the information of a WebSocket connection served by a request.
+type WebSocketInfo struct {
+	// Duration is how long the connection was open after the handshake, in milliseconds.
+	Duration int64 `json:"duration"`
+	// BytesRead is the number of bytes read from the connection after the handshake, including frame headers.
+	BytesRead int64 `json:"bytesRead"`
+	// BytesWritten is the number of bytes written to the connection, including the handshake response and frame headers.
+	BytesWritten int64 `json:"bytesWritten"`
+}
+
+// isWebSocketUpgrade reports whether the request asks to upgrade the connection to WebSocket.
+func isWebSocketUpgrade(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get(echo.HeaderUpgrade), "websocket") &&
+		containsToken(req.Header.Get(echo.HeaderConnection), "upgrade")
+}
+
+// containsToken reports whether the comma-separated header value contains the token.
+func containsToken(value, token string) bool {
+	for _, v := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// hijackTrackingWriter wraps a response writer and tracks the connection hijacked by a WebSocket handler.
+type hijackTrackingWriter struct {
+	http.ResponseWriter
+	hijackedAt time.Time
+	conn       *countingConn
+}
+
+func (w *hijackTrackingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return conn, rw, err
+	}
+	w.hijackedAt = time.Now()
+	w.conn = &countingConn{Conn: conn}
+	// The buffered reader may already hold data read from the connection, so it is kept as is,
+	// and writes go through the counting connection.
+	rw.Writer.Reset(w.conn)
+	return w.conn, rw, nil
+}
+
+// Unwrap returns the original response writer for http.ResponseController.
+func (w *hijackTrackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// info returns the information of the hijacked connection, or nil if the connection was not hijacked.
+func (w *hijackTrackingWriter) info() *WebSocketInfo {
+	if w.conn == nil {
+		return nil
+	}
+	return &WebSocketInfo{
+		Duration:     time.Since(w.hijackedAt).Milliseconds(),
+		BytesRead:    w.conn.read.Load(),
+		BytesWritten: w.conn.written.Load(),
+	}
+}
+
+// countingConn wraps a connection and counts the bytes read and written.
+type countingConn struct {
+	net.Conn
+	read    atomic.Int64
+	written atomic.Int64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,20 @@
+// Package sqlxmonitor records the queries run through sqlx in the queries monitor of echo-debugmonitor.
+// It is a separate module so that the monitors package does not depend on sqlx.
+package sqlxmonitor
+
+import (
+	"github.com/jmoiron/sqlx"
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+)
+
+// WrapSqlxDB creates a queries monitor for the database of config, as monitors.NewQueriesMonitor does,
+// and returns the monitor along with a *sqlx.DB on the monitored connection. The driver name selects
+// the bind variable style of sqlx, such as "postgres" or "mysql".
+//
+//	queriesMonitor, db := sqlxmonitor.WrapSqlxDB(monitors.QueriesMonitorConfig{Driver: &pq.Driver{}, DSN: dsn}, "postgres")
+//	m.AddMonitor(queriesMonitor)
+func WrapSqlxDB(config monitors.QueriesMonitorConfig, driverName string) (*debugmonitor.Monitor, *sqlx.DB) {
+	monitor, db := monitors.NewQueriesMonitor(config)
+	return monitor, sqlx.NewDb(db, driverName)
+}
@@ -0,0 +1,39 @@
+package sqlxmonitor
+
+import (
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+	"modernc.org/sqlite"
+)
+
+func TestWrapSqlxDB(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, db := WrapSqlxDB(monitors.QueriesMonitorConfig{Driver: &sqlite.Driver{}, DSN: ":memory:"}, "sqlite")
+	defer db.Close()
+	m.AddMonitor(monitor)
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	var name string
+	if err := db.Get(&name, db.Rebind("SELECT name FROM users WHERE id = ?"), 1); err == nil {
+		t.Fatal("Expected no rows")
+	}
+	select {
+	case event := <-sub.C:
+		payload := event.Entry.Payload.(*monitors.QueryPayload)
+		if payload.Query != "SELECT name FROM users WHERE id = ?" || payload.Operation != "Query" {
+			t.Errorf("Unexpected payload: %+v", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the query to be recorded")
+	}
+}
@@ -0,0 +1,126 @@
+package monitors
+
+import (
+	"io"
+	"os"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+// StdioMonitorConfig is the configuration for the stdio monitor.
+type StdioMonitorConfig struct {
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+}
+
+// StdioCapture is the capture of the stdout and stderr of the process by a stdio monitor.
+// Close it to restore the original stdout and stderr.
+type StdioCapture struct {
+	streams []*stdioStream
+}
+
+// stdioStream is a redirected stdout or stderr.
+type stdioStream struct {
+	file     **os.File
+	original *os.File
+	restore  func() error
+	done     chan struct{}
+}
+
+// NewStdioMonitor creates a writer monitor that captures the output written to the stdout and stderr of the process,
+// including the output of third-party libraries that write to them directly instead of through echo.Logger.
+// On Unix the file descriptors 1 and 2 are redirected to pipes, which also captures the output of the packages
+// that keep a reference to os.Stdout or os.Stderr, such as the standard log package. On other platforms
+// os.Stdout and os.Stderr are replaced instead. The output is still written to the original stdout and stderr,
+// and recorded line by line with "stdout" or "stderr" as the source, grouping the lines of stack traces. Output written before the monitor
+// is added to a manager is not recorded.
+func NewStdioMonitor(config StdioMonitorConfig) (*debugmonitor.Monitor, *StdioCapture, error) {
+	capture := &StdioCapture{}
+	readers := make(map[string]*os.File, 2)
+	sources := make([]WriterSource, 0, 2)
+	for _, s := range []struct {
+		name string
+		file **os.File
+	}{
+		{"stdout", &os.Stdout},
+		{"stderr", &os.Stderr},
+	} {
+		r, w, err := os.Pipe()
+		if err != nil {
+			capture.abort(readers)
+			return nil, nil, err
+		}
+		original, restore, err := redirectStdio(s.file, w)
+		if err != nil {
+			_ = r.Close()
+			_ = w.Close()
+			capture.abort(readers)
+			return nil, nil, err
+		}
+		capture.streams = append(capture.streams, &stdioStream{
+			file:     s.file,
+			original: original,
+			restore:  restore,
+			done:     make(chan struct{}),
+		})
+		readers[s.name] = r
+		sources = append(sources, WriterSource{Name: s.name, Writer: original})
+	}
+
+	m, writers := NewMultiWriterMonitor(WriterMonitorConfig{
+		UsePolling:       config.UsePolling,
+		SplitLines:       true,
+		GroupStackTraces: true,
+	}, sources...)
+	m.Name = "stdio"
+	m.DisplayName = "Stdout/Stderr"
+
+	for i, source := range sources {
+		stream := capture.streams[i]
+		r := readers[source.Name]
+		w := writers[source.Name].(*TeeWriter)
+		go func() {
+			defer close(stream.done)
+			defer r.Close()
+			_, _ = io.Copy(w, r)
+			w.Flush()
+		}()
+	}
+	return m, capture, nil
+}
+
+// abort restores the streams redirected before the copy of their output has started.
+func (c *StdioCapture) abort(readers map[string]*os.File) {
+	for _, stream := range c.streams {
+		_ = stream.restore()
+		if stream.original != *stream.file {
+			_ = stream.original.Close()
+		}
+	}
+	for _, r := range readers {
+		_ = r.Close()
+	}
+	c.streams = nil
+}
+
+// Close restores the original stdout and stderr, and waits for the output written before
+// to be recorded.
+func (c *StdioCapture) Close() error {
+	var firstErr error
+	for _, stream := range c.streams {
+		if err := stream.restore(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, stream := range c.streams {
+		<-stream.done
+		// The original is a duplicate of the file descriptor, unless the os variable was replaced
+		if stream.original != *stream.file {
+			if err := stream.original.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	c.streams = nil
+	return firstErr
+}
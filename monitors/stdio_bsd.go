@@ -0,0 +1,28 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package monitors
+
+import (
+	"os"
+	"syscall"
+)
+
+// redirectStdio redirects the file descriptor of *f to w, and returns a duplicate of the original
+// file descriptor and a function that restores it. It takes the ownership of w.
+func redirectStdio(f **os.File, w *os.File) (*os.File, func() error, error) {
+	fd := int((*f).Fd())
+	saved, err := syscall.Dup(fd)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := syscall.Dup2(int(w.Fd()), fd); err != nil {
+		_ = syscall.Close(saved)
+		return nil, nil, err
+	}
+	// The file descriptor now refers to the pipe, so w is no longer needed
+	_ = w.Close()
+	restore := func() error {
+		return syscall.Dup2(saved, fd)
+	}
+	return os.NewFile(uintptr(saved), (*f).Name()), restore, nil
+}
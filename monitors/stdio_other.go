@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package monitors
+
+import (
+	"os"
+)
+
+// redirectStdio replaces *f with w, and returns the original file and a function that restores it.
+// It takes the ownership of w.
+func redirectStdio(f **os.File, w *os.File) (*os.File, func() error, error) {
+	original := *f
+	*f = w
+	restore := func() error {
+		*f = original
+		return w.Close()
+	}
+	return original, restore, nil
+}
@@ -0,0 +1,41 @@
+package monitors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+func TestStdioMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, capture, err := NewStdioMonitor(StdioMonitorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.AddMonitor(monitor)
+
+	fmt.Fprintln(os.Stderr, "written to stderr by the stdio monitor test")
+	if err := capture.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=stdio&action=data&source=stderr", nil))
+	var entries []struct {
+		Payload WriterPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Payload.Data != "written to stderr by the stdio monitor test\n" {
+		t.Errorf("Expected the line written to stderr, got %+v", entries)
+	}
+}
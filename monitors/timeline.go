@@ -0,0 +1,171 @@
+package monitors
+
+import (
+	"context"
+	_ "embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+)
+
+// TimelineItemPayload represents an entry of another monitor in the unified timeline
+type TimelineItemPayload struct {
+	Monitor     string    `json:"monitor"`     // name of the monitor the entry was added to
+	DisplayName string    `json:"displayName"` // display name of the monitor
+	EntryID     int64     `json:"entryId"`     // ID of the entry in the monitor
+	RequestID   string    `json:"requestId,omitempty"`
+	Summary     string    `json:"summary"`
+	Time        time.Time `json:"time"`
+}
+
+// TimelineMonitorConfig defines the config for Timeline monitor.
+type TimelineMonitorConfig struct {
+	// UsePolling enables polling mode instead of SSE for real-time updates.
+	UsePolling bool
+	// Monitors are the names of the monitors to include. If it is empty, all monitors are included.
+	Monitors []string
+}
+
+//go:embed timeline.html
+var timelineView string
+
+// timelineViewTemplate is the parsed template for the timeline view
+var timelineViewTemplate = template.Must(template.New("timelineView").Funcs(debugmonitor.TemplateFuncs).Parse(timelineView))
+
+// TimelineMonitor is a plugin that merges the entries of all other monitors into a single chronological feed,
+// so that requests, queries, logs and errors can be followed interleaved. Only the entries whose payloads
+// implement debugmonitor.TimelinePayload are included. Add it with debugmonitor.Manager.AddPlugin.
+type TimelineMonitor struct {
+	monitor *debugmonitor.Monitor
+	config  TimelineMonitorConfig
+}
+
+// NewTimelineMonitor creates a new monitor for the unified timeline.
+func NewTimelineMonitor(config TimelineMonitorConfig) *TimelineMonitor {
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
+		Name:        "timeline",
+		DisplayName: "Timeline",
+		MaxRecords:  2000,
+		Icon:        debugmonitor.IconDocumentText,
+		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
+			switch action {
+			case "render":
+				return debugmonitor.RenderTemplate(c, timelineViewTemplate, map[string]any{
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+				})
+			case "stream":
+				// SSE endpoint for real-time updates
+				return debugmonitor.HandleSSEStreamWithFilter(c, store, timelineFilter(c))
+			case "data":
+				// JSON endpoint for polling mode
+				return debugmonitor.HandleDataJSONWithFilter(c, store, timelineFilter(c))
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPollWithFilter(c, store, timelineFilter(c))
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		},
+	}
+
+	return &TimelineMonitor{
+		monitor: m,
+		config:  config,
+	}
+}
+
+// Monitor implements debugmonitor.MonitorPlugin.
+func (t *TimelineMonitor) Monitor() *debugmonitor.Monitor {
+	return t.monitor
+}
+
+// Assets implements debugmonitor.MonitorPlugin.
+func (t *TimelineMonitor) Assets() fs.FS {
+	return nil
+}
+
+// Run implements debugmonitor.MonitorPluginRunner. It records the entries of the other monitors
+// until ctx is canceled.
+func (t *TimelineMonitor) Run(ctx context.Context) {
+	manager := t.monitor.Manager()
+	if manager == nil {
+		return
+	}
+	sub := manager.Subscribe()
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			t.record(manager, event)
+		}
+	}
+}
+
+// record adds the event of another monitor to the timeline if it is included.
+func (t *TimelineMonitor) record(manager *debugmonitor.Manager, event *debugmonitor.MonitorEvent) {
+	if event.MonitorName == t.monitor.Name {
+		return
+	}
+	if len(t.config.Monitors) > 0 && !containsFold(t.config.Monitors, event.MonitorName) {
+		return
+	}
+	payload, ok := event.Entry.Payload.(debugmonitor.TimelinePayload)
+	if !ok {
+		return
+	}
+
+	displayName := event.MonitorName
+	for _, monitor := range manager.Monitors() {
+		if monitor.Name == event.MonitorName {
+			displayName = monitor.DisplayName
+			break
+		}
+	}
+
+	t.monitor.Add(&TimelineItemPayload{
+		Monitor:     event.MonitorName,
+		DisplayName: displayName,
+		EntryID:     event.Entry.Id,
+		RequestID:   payload.TimelineRequestID(),
+		Summary:     payload.TimelineSummary(),
+		Time:        payload.TimelineTime(),
+	})
+}
+
+// timelineFilter returns the filter of the items given by the query parameters: "monitors" keeps the items
+// of the comma-separated monitors, and "requestId" keeps the items of a request. It returns nil if no parameter
+// is given.
+func timelineFilter(c echo.Context) debugmonitor.EntryFilter {
+	var monitors []string
+	if v := c.QueryParam("monitors"); v != "" {
+		monitors = strings.Split(v, ",")
+	}
+	requestID := c.QueryParam("requestId")
+	if len(monitors) == 0 && requestID == "" {
+		return nil
+	}
+	return func(entry *debugmonitor.DataEntry) bool {
+		payload, ok := entry.Payload.(*TimelineItemPayload)
+		if !ok {
+			return false
+		}
+		if len(monitors) > 0 && !containsFold(monitors, payload.Monitor) {
+			return false
+		}
+		return requestID == "" || payload.RequestID == requestID
+	}
+}
@@ -0,0 +1,47 @@
+package monitors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+)
+
+func TestTimelineMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	defer m.Close()
+
+	eventsMonitor, events := NewEventsMonitor(EventsMonitorConfig{})
+	m.AddMonitor(eventsMonitor)
+	m.AddPlugin(NewMemoryMonitor(MemoryMonitorConfig{Interval: time.Hour}))
+	timeline := NewTimelineMonitor(TimelineMonitorConfig{})
+	m.AddPlugin(timeline)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	// The timeline subscribes to the manager in the background, so the event is fired until it is recorded
+	ctx := debugmonitor.ContextWithRequestID(context.Background(), "req-1")
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case <-ticker.C:
+			events.RecordWithContext(ctx, "order.placed", nil)
+		case event := <-sub.C:
+			if event.MonitorName != "timeline" {
+				continue
+			}
+			item := event.Entry.Payload.(*TimelineItemPayload)
+			// Memory samples do not implement debugmonitor.TimelinePayload, so the event is the only item
+			if item.Monitor != "events" || item.DisplayName != "Events" || item.RequestID != "req-1" || item.Summary != "event order.placed" {
+				t.Errorf("Unexpected item: %+v", item)
+			}
+			return
+		case <-timeout:
+			t.Fatal("Expected the event in the timeline")
+		}
+	}
+}
@@ -1,22 +1,40 @@
 package monitors
 
 import (
+	"bytes"
 	_ "embed"
+	"encoding/json"
 	"html/template"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
 	"github.com/labstack/echo/v4"
 )
 
 type WriterPayload struct {
-	Data string `json:"data"`
+	Data   string      `json:"data"`
+	Source string      `json:"source,omitempty"` // name of the writer the data was written to
+	Log    *LogPayload `json:"log,omitempty"`    // structured log entry parsed from Data in JSON mode
+	// StackTrace reports whether Data is a multi-line stack trace grouped into one entry
+	StackTrace bool `json:"stackTrace,omitempty"`
 }
 
 type TeeWriter struct {
-	original io.Writer
-	monitor  *debugmonitor.Monitor
+	original   io.Writer
+	monitor    *debugmonitor.Monitor
+	source     string
+	splitLines bool
+	jsonKeys   *JSONLogKeys // keys of the JSON log lines parsed in JSON mode
+	mu         sync.Mutex
+	buf        []byte // incomplete line of the previous writes in line mode
+
+	groupStackTraces bool
+	trace            []byte      // lines of the stack trace being grouped
+	traceTimer       *time.Timer // records the stack trace after a quiet period
+	traceGeneration  int         // identifies the stack trace the timer was started for
 }
 
 func (t *TeeWriter) Write(p []byte) (n int, err error) {
@@ -26,28 +44,145 @@ func (t *TeeWriter) Write(p []byte) (n int, err error) {
 		return n, err
 	}
 
+	if t.splitLines {
+		t.addLines(p[:n])
+		return n, nil
+	}
+
 	// Also send the payload to the monitor
 	t.monitor.Add(&WriterPayload{
-		Data: string(p),
+		Data:   string(p),
+		Source: t.source,
 	})
 
 	return n, nil
 }
 
+// addLines records each complete line of p, including its newline. An incomplete last line is kept
+// until a later write completes it or Flush is called.
+func (t *TeeWriter) addLines(p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	for {
+		i := bytes.IndexByte(t.buf, '\n')
+		if i < 0 {
+			break
+		}
+		t.addLine(t.buf[:i+1])
+		t.buf = t.buf[i+1:]
+	}
+	if len(t.buf) == 0 {
+		t.buf = nil
+	}
+}
+
+// newPayload creates the payload of a line. In JSON mode, a line that is a JSON object is also parsed
+// into a structured log entry.
+func (t *TeeWriter) newPayload(line []byte) *WriterPayload {
+	payload := &WriterPayload{Data: string(line), Source: t.source}
+	if t.jsonKeys == nil {
+		return payload
+	}
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 || trimmed[0] != '{' || !json.Valid(trimmed) {
+		return payload
+	}
+	payload.Log = parseJSONLogLine(trimmed, *t.jsonKeys)
+	// gommon's header has the file and the line of the caller in separate fields
+	if file, ok := payload.Log.Fields["file"].(string); ok && payload.Log.Caller == "" {
+		if lineNo, ok := payload.Log.Fields["line"].(string); ok {
+			payload.Log.Caller = file + ":" + lineNo
+			delete(payload.Log.Fields, "file")
+			delete(payload.Log.Fields, "line")
+			if len(payload.Log.Fields) == 0 {
+				payload.Log.Fields = nil
+			}
+		}
+	}
+	return payload
+}
+
+// addLine records a complete line. When stack traces are grouped, the lines of a stack trace are kept
+// until the trace ends, and recorded as one entry. The caller must hold the lock.
+func (t *TeeWriter) addLine(line []byte) {
+	if t.groupStackTraces {
+		if t.trace != nil {
+			if isStackTraceContinuation(line) {
+				t.trace = append(t.trace, line...)
+				t.traceTimer.Reset(stackTraceQuietPeriod)
+				return
+			}
+			t.flushTrace()
+		}
+		if isStackTraceStart(line) {
+			t.trace = append([]byte{}, line...)
+			t.traceGeneration++
+			generation := t.traceGeneration
+			t.traceTimer = time.AfterFunc(stackTraceQuietPeriod, func() {
+				t.mu.Lock()
+				defer t.mu.Unlock()
+				if t.trace != nil && t.traceGeneration == generation {
+					t.flushTrace()
+				}
+			})
+			return
+		}
+	}
+	t.monitor.Add(t.newPayload(line))
+}
+
+// flushTrace records the stack trace being grouped. The caller must hold the lock.
+func (t *TeeWriter) flushTrace() {
+	t.traceTimer.Stop()
+	t.monitor.Add(&WriterPayload{
+		Data:       string(t.trace),
+		Source:     t.source,
+		StackTrace: true,
+	})
+	t.trace = nil
+}
+
+// Flush records the stack trace being grouped and the incomplete last line kept in line mode, if any.
+func (t *TeeWriter) Flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.trace != nil {
+		t.flushTrace()
+	}
+	if len(t.buf) > 0 {
+		t.monitor.Add(t.newPayload(t.buf))
+		t.buf = nil
+	}
+}
+
 // LoggerWriterMonitorConfig is the configuration for the logger writer monitor.
 type LoggerWriterMonitorConfig struct {
 	// Logger is the echo.Logger to wrap with monitoring.
 	Logger echo.Logger
 	// UsePolling enables polling mode instead of SSE for real-time updates.
 	UsePolling bool
+	// JSONKeys are the keys of the JSON lines written by the logger, which are parsed into structured entries
+	// with a level, a time and a message. Lines that are not JSON are recorded as they are.
+	// Optional. Default: GommonJSONKeys
+	JSONKeys *JSONLogKeys
 }
 
 // NewLoggerWriterMonitor creates a logger writer monitor with the given configuration.
 func NewLoggerWriterMonitor(config LoggerWriterMonitorConfig) *debugmonitor.Monitor {
+	// Defaults
+	if config.JSONKeys == nil {
+		config.JSONKeys = &GommonJSONKeys
+	}
+
 	o := config.Logger.Output()
 	m, w := NewWriterMonitor(WriterMonitorConfig{
-		UsePolling: config.UsePolling,
-		Writer:     o,
+		UsePolling:       config.UsePolling,
+		Writer:           o,
+		JSONKeys:         config.JSONKeys,
+		GroupStackTraces: true,
 	})
 	m.Name = "logger_writer"
 	m.DisplayName = "Logger Writer"
@@ -59,7 +194,7 @@ func NewLoggerWriterMonitor(config LoggerWriterMonitorConfig) *debugmonitor.Moni
 var writerView string
 
 // writerViewTemplate is the parsed template for the writer view
-var writerViewTemplate = template.Must(template.New("writerView").Parse(writerView))
+var writerViewTemplate = template.Must(template.New("writerView").Funcs(debugmonitor.TemplateFuncs).Parse(writerView))
 
 // WriterMonitorConfig is the configuration for the writer monitor.
 type WriterMonitorConfig struct {
@@ -67,33 +202,124 @@ type WriterMonitorConfig struct {
 	Writer io.Writer
 	// UsePolling enables polling mode instead of SSE for real-time updates.
 	UsePolling bool
+	// SplitLines records one entry per newline-terminated line instead of one entry per Write call.
+	// An incomplete line is kept until a later write completes it, or until TeeWriter.Flush is called.
+	SplitLines bool
+	// JSONKeys enables the JSON mode, which implies SplitLines. Each line that is a JSON object is parsed
+	// with the keys into a structured log entry, recorded in WriterPayload.Log along with the raw line.
+	// Optional. Default: nil (JSON mode is disabled)
+	JSONKeys *JSONLogKeys
+	// GroupStackTraces groups the lines of Go panics and stack traces, such as the output of debug.PrintStack,
+	// into one entry instead of one entry per line. It implies SplitLines.
+	GroupStackTraces bool
+}
+
+// WriterSource is an output stream teed by a multi-writer monitor.
+type WriterSource struct {
+	// Name is the name of the stream, such as "stdout" or "file", recorded with its output.
+	// It must be unique among the sources of the monitor.
+	Name string
+	// Writer is the original io.Writer to write to.
+	Writer io.Writer
 }
 
 // NewWriterMonitor creates a new writer monitor with the given configuration.
 // It returns the monitor and a new io.Writer that writes to both the original writer
 // and the monitor's store.
 func NewWriterMonitor(config WriterMonitorConfig) (*debugmonitor.Monitor, io.Writer) {
-	m := &debugmonitor.Monitor{
+	m, writers := NewMultiWriterMonitor(config, WriterSource{Writer: config.Writer})
+	return m, writers[""]
+}
+
+// NewMultiWriterMonitor creates a writer monitor that tees several output streams of the application,
+// such as stdout, a log file and a syslog connection, and records their output with the name of the source.
+// It returns a new io.Writer for each source by name. The Writer field of the config is ignored in favor of the sources.
+func NewMultiWriterMonitor(config WriterMonitorConfig, sources ...WriterSource) (*debugmonitor.Monitor, map[string]io.Writer) {
+	names := make([]string, 0, len(sources))
+	for _, source := range sources {
+		if source.Name != "" {
+			names = append(names, source.Name)
+		}
+	}
+
+	// m is declared first so that the action handler can refer to the monitor
+	var m *debugmonitor.Monitor
+	m = &debugmonitor.Monitor{
 		Name:        "writer",
 		DisplayName: "Writer",
 		MaxRecords:  1000,
 		Icon:        debugmonitor.IconPencilSquare,
+		Group:       "Application",
 		ActionHandler: func(c echo.Context, store *debugmonitor.Store, action string) error {
 			switch action {
 			case "render":
 				return debugmonitor.RenderTemplate(c, writerViewTemplate, map[string]any{
-					"UsePolling": config.UsePolling,
+					"UsePolling":      config.UsePolling,
+					"PollingInterval": m.Settings().PollingInterval,
+					"Sources":         names,
 				})
 			case "stream":
 				// SSE endpoint for real-time updates
-				return debugmonitor.HandleSSEStream(c, store)
+				return debugmonitor.HandleSSEStreamWithFilter(c, store, writerFilter(c))
 			case "data":
 				// JSON endpoint for polling mode
-				return debugmonitor.HandleDataJSON(c, store)
+				return debugmonitor.HandleDataJSONWithFilter(c, store, writerFilter(c))
+			case "longpoll":
+				// JSON endpoint for long polling, which waits for new entries
+				return debugmonitor.HandleLongPollWithFilter(c, store, writerFilter(c))
+			case "download":
+				// The captured output as a plaintext or NDJSON attachment
+				return debugmonitor.HandleDownload(c, store, writerFilter(c), m.Name, writerData)
 			default:
 				return echo.NewHTTPError(http.StatusBadRequest)
 			}
 		},
 	}
-	return m, &TeeWriter{original: config.Writer, monitor: m}
+
+	writers := make(map[string]io.Writer, len(sources))
+	for _, source := range sources {
+		writers[source.Name] = &TeeWriter{
+			original:   source.Writer,
+			monitor:    m,
+			source:     source.Name,
+			splitLines: config.SplitLines || config.JSONKeys != nil || config.GroupStackTraces,
+			jsonKeys:   config.JSONKeys,
+
+			groupStackTraces: config.GroupStackTraces,
+		}
+	}
+	return m, writers
+}
+
+// writerFilter returns the entry filter for the query parameters of the data, stream and download actions.
+// The "source" parameter selects the entries of a source. The "level" parameter selects the structured log lines
+// of the level or higher, such as "warn" for warnings and errors. It returns nil if no filter is requested.
+func writerFilter(c echo.Context) debugmonitor.EntryFilter {
+	source := c.QueryParam("source")
+	level := c.QueryParam("level")
+	if source == "" && level == "" {
+		return nil
+	}
+	minRank := logLevelRanks[normalizeLogLevel(level)]
+	return func(entry *debugmonitor.DataEntry) bool {
+		payload, ok := entry.Payload.(*WriterPayload)
+		if !ok {
+			return false
+		}
+		if source != "" && payload.Source != source {
+			return false
+		}
+		if level != "" && (payload.Log == nil || logLevelRanks[payload.Log.Level] < minRank) {
+			return false
+		}
+		return true
+	}
+}
+
+// writerData returns the raw output of an entry in the downloaded plaintext output.
+func writerData(entry *debugmonitor.DataEntry) string {
+	if payload, ok := entry.Payload.(*WriterPayload); ok {
+		return payload.Data
+	}
+	return ""
 }
@@ -0,0 +1,62 @@
+package monitors
+
+import (
+	"bytes"
+	"time"
+)
+
+// stackTraceQuietPeriod is the time after the last line of a stack trace after which the trace
+// is recorded, when no other line has ended it yet.
+const stackTraceQuietPeriod = 100 * time.Millisecond
+
+// stackTraceStartPrefixes are the prefixes of the lines that start a Go panic or stack trace.
+var stackTraceStartPrefixes = [][]byte{
+	[]byte("panic: "),
+	[]byte("fatal error: "),
+	[]byte("goroutine "),
+}
+
+// stackTraceContinuationPrefixes are the prefixes of the lines, other than the frames,
+// that continue a Go panic or stack trace.
+var stackTraceContinuationPrefixes = [][]byte{
+	[]byte("\t"),
+	[]byte("panic: "),
+	[]byte("fatal error: "),
+	[]byte("goroutine "),
+	[]byte("created by "),
+	[]byte("[signal "),
+	[]byte("...additional frames elided..."),
+	[]byte("exit status "),
+}
+
+// isStackTraceStart reports whether line starts a Go panic or stack trace, such as "panic: ..."
+// or "goroutine 1 [running]:".
+func isStackTraceStart(line []byte) bool {
+	if bytes.HasPrefix(line, []byte("goroutine ")) {
+		return bytes.Contains(line, []byte(" ["))
+	}
+	for _, prefix := range stackTraceStartPrefixes {
+		if bytes.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStackTraceContinuation reports whether line continues a Go panic or stack trace: a blank line,
+// a function of a frame such as "main.(*T).m(...)", the indented file and line of a frame, or another
+// line of the trace such as "created by ...".
+func isStackTraceContinuation(line []byte) bool {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(line) == 0 {
+		return true
+	}
+	for _, prefix := range stackTraceContinuationPrefixes {
+		if bytes.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	// The function of a frame has no space before its arguments
+	i := bytes.IndexByte(line, '(')
+	return i > 0 && bytes.IndexByte(line[:i], ' ') < 0 && line[len(line)-1] == ')'
+}
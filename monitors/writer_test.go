@@ -0,0 +1,156 @@
+package monitors
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+func TestTeeWriter_SplitLines(t *testing.T) {
+	m := debugmonitor.New()
+	var out bytes.Buffer
+	monitor, w := NewWriterMonitor(WriterMonitorConfig{Writer: &out, SplitLines: true})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	for _, s := range []string{"first li", "ne\nsecond line\nthi", "rd"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.(*TeeWriter).Flush()
+
+	if out.String() != "first line\nsecond line\nthird" {
+		t.Errorf("Unexpected output of the original writer: %q", out.String())
+	}
+	for _, want := range []string{"first line\n", "second line\n", "third"} {
+		payload := (<-sub.C).Entry.Payload.(*WriterPayload)
+		if payload.Data != want {
+			t.Errorf("Expected entry %q, got %q", want, payload.Data)
+		}
+	}
+}
+
+func TestLoggerWriterMonitor_JSON(t *testing.T) {
+	m := debugmonitor.New()
+	e := echo.New()
+	e.Logger.SetOutput(io.Discard)
+	e.Logger.SetLevel(log.DEBUG)
+	monitor := NewLoggerWriterMonitor(LoggerWriterMonitorConfig{Logger: e.Logger})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+	e.Logger.Warn("disk almost full")
+	if _, err := e.Logger.Output().Write([]byte("plain text\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := (<-sub.C).Entry.Payload.(*WriterPayload)
+	if payload.Log == nil {
+		t.Fatalf("Expected a structured entry, got %q", payload.Data)
+	}
+	if payload.Log.Level != "WARN" || payload.Log.Message != "disk almost full" {
+		t.Errorf("Unexpected log entry: %+v", payload.Log)
+	}
+	if !strings.Contains(payload.Log.Caller, "writer_test.go:") {
+		t.Errorf("Expected the caller from the header, got %q", payload.Log.Caller)
+	}
+
+	payload = (<-sub.C).Entry.Payload.(*WriterPayload)
+	if payload.Log != nil || payload.Data != "plain text\n" {
+		t.Errorf("Expected a raw entry, got %+v", payload)
+	}
+}
+
+func TestMultiWriterMonitor(t *testing.T) {
+	m := debugmonitor.New()
+	var stdout, file bytes.Buffer
+	monitor, writers := NewMultiWriterMonitor(WriterMonitorConfig{SplitLines: true},
+		WriterSource{Name: "stdout", Writer: &stdout},
+		WriterSource{Name: "file", Writer: &file},
+	)
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+	fmt.Fprintln(writers["stdout"], "to stdout")
+	fmt.Fprintln(writers["file"], "to file")
+
+	if stdout.String() != "to stdout\n" || file.String() != "to file\n" {
+		t.Errorf("Unexpected output of the original writers: %q, %q", stdout.String(), file.String())
+	}
+	for _, want := range []WriterPayload{{Data: "to stdout\n", Source: "stdout"}, {Data: "to file\n", Source: "file"}} {
+		payload := (<-sub.C).Entry.Payload.(*WriterPayload)
+		if *payload != want {
+			t.Errorf("Expected %+v, got %+v", want, *payload)
+		}
+	}
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=writer&action=render", nil))
+	if !strings.Contains(rec.Body.String(), `<option value="file">file</option>`) {
+		t.Errorf("Expected a source filter in the view")
+	}
+}
+
+func TestTeeWriter_GroupStackTraces(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, w := NewWriterMonitor(WriterMonitorConfig{Writer: io.Discard, GroupStackTraces: true})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	trace := "panic: boom\n" +
+		"\n" +
+		"goroutine 1 [running]:\n" +
+		"main.(*server).handle(0xc000010000, {0x1, 0x2})\n" +
+		"\t/app/main.go:42 +0x1d\n" +
+		"main.main()\n" +
+		"\t/app/main.go:12 +0x25\n" +
+		"exit status 2\n"
+	for _, line := range strings.SplitAfter("starting (pid 42)\n"+trace+"restarted\n", "\n") {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []WriterPayload{
+		{Data: "starting (pid 42)\n"},
+		{Data: trace, StackTrace: true},
+		{Data: "restarted\n"},
+	} {
+		payload := (<-sub.C).Entry.Payload.(*WriterPayload)
+		if *payload != want {
+			t.Errorf("Expected %+v, got %+v", want, *payload)
+		}
+	}
+
+	// A trace at the end of the output is recorded after a quiet period
+	if _, err := w.Write([]byte("goroutine 7 [running]:\nmain.main()\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-sub.C:
+		payload := event.Entry.Payload.(*WriterPayload)
+		if !payload.StackTrace || payload.Data != "goroutine 7 [running]:\nmain.main()\n" {
+			t.Errorf("Unexpected trailing trace: %+v", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the trailing trace to be recorded")
+	}
+}
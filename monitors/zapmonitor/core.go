@@ -0,0 +1,76 @@
+// Package zapmonitor records the entries of a zap logger in the logs monitor of echo-debugmonitor.
+// It is a separate module so that the monitors package does not depend on zap.
+package zapmonitor
+
+import (
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core is a zapcore.Core that records the entries of a zap logger in a logs monitor as structured entries
+// with their level, message, time, caller and fields, without encoding and parsing them as JSON.
+type Core struct {
+	zapcore.LevelEnabler
+	logger *monitors.LoggerWrapper
+	fields []zapcore.Field // fields added by With
+}
+
+// NewCore creates a Core that records the entries enabled by enab into the logs monitor of the logger returned
+// by monitors.NewLogsMonitor. Tee it with the core of the application:
+//
+//	logsMonitor, wrappedLogger := monitors.NewLogsMonitor(monitors.LogsMonitorConfig{Logger: e.Logger})
+//	logger := zap.New(zapcore.NewTee(core, zapmonitor.NewCore(wrappedLogger, zap.DebugLevel)), zap.AddCaller())
+func NewCore(logger *monitors.LoggerWrapper, enab zapcore.LevelEnabler) *Core {
+	return &Core{LevelEnabler: enab, logger: logger}
+}
+
+// With implements zapcore.Core.
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+// Check implements zapcore.Core.
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	if ent.LoggerName != "" {
+		enc.Fields["logger"] = ent.LoggerName
+	}
+	if ent.Stack != "" {
+		enc.Fields["stacktrace"] = ent.Stack
+	}
+
+	payload := &monitors.LogPayload{
+		Level:     ent.Level.CapitalString(),
+		Message:   ent.Message,
+		Timestamp: ent.Time,
+	}
+	if ent.Caller.Defined {
+		payload.Caller = ent.Caller.TrimmedPath()
+	}
+	if len(enc.Fields) > 0 {
+		payload.Fields = enc.Fields
+	}
+	c.logger.Record(payload)
+	return nil
+}
+
+// Sync implements zapcore.Core. Entries are recorded as soon as they are written, so there is nothing to flush.
+func (c *Core) Sync() error {
+	return nil
+}
@@ -0,0 +1,48 @@
+package zapmonitor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	debugmonitor "github.com/kohkimakimoto/echo-debugmonitor"
+	"github.com/kohkimakimoto/echo-debugmonitor/monitors"
+	"go.uber.org/zap"
+)
+
+func TestCore(t *testing.T) {
+	m := debugmonitor.New()
+	monitor, wrappedLogger := monitors.NewLogsMonitor(monitors.LogsMonitorConfig{})
+	m.AddMonitor(monitor)
+
+	sub := m.Subscribe()
+	defer sub.Close()
+
+	logger := zap.New(NewCore(wrappedLogger, zap.InfoLevel), zap.AddCaller()).With(zap.String("request_id", "req-1"))
+	logger.Debug("not recorded")
+	logger.Info("user created", zap.Int("user_id", 7))
+
+	var payload *monitors.LogPayload
+	select {
+	case ev := <-sub.C:
+		payload = ev.Entry.Payload.(*monitors.LogPayload)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the entry to be recorded")
+	}
+	if payload.Level != "INFO" || payload.Message != "user created" || payload.RequestID != "req-1" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if !strings.Contains(payload.Caller, "core_test.go:") {
+		t.Errorf("Expected the caller to be the test, got %q", payload.Caller)
+	}
+	if fmt.Sprint(payload.Fields["user_id"]) != "7" {
+		t.Errorf("Unexpected fields: %v", payload.Fields)
+	}
+
+	select {
+	case ev := <-sub.C:
+		t.Errorf("Unexpected entry: %+v", ev.Entry.Payload)
+	default:
+	}
+}
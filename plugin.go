@@ -0,0 +1,44 @@
+package debugmonitor
+
+import (
+	"context"
+	"io/fs"
+)
+
+// MonitorPlugin is a monitor distributed with its own assets, typically by a third party.
+// The manager serves the files in Assets under a path namespaced by the monitor name:
+//
+//	?monitor=<name>&file=<path>
+//
+// so the monitor's views can load their JS and CSS instead of inlining them.
+type MonitorPlugin interface {
+	// Monitor returns the monitor provided by this plugin.
+	Monitor() *Monitor
+	// Assets returns the file system containing the plugin's static assets.
+	// It can return nil if the plugin has no assets.
+	Assets() fs.FS
+}
+
+// MonitorPluginRunner is an optional interface for a MonitorPlugin that has a background task.
+// Run is started in its own goroutine when the plugin is added to the manager,
+// and its context is canceled when the manager is closed.
+type MonitorPluginRunner interface {
+	Run(ctx context.Context)
+}
+
+// AddPlugin registers the monitor provided by the plugin and serves its assets.
+// If the plugin implements MonitorPluginRunner, its background task is started.
+func (m *Manager) AddPlugin(plugin MonitorPlugin) {
+	monitor := plugin.Monitor()
+	monitor.assets = plugin.Assets()
+	m.AddMonitor(monitor)
+
+	if runner, ok := plugin.(MonitorPluginRunner); ok {
+		go runner.Run(m.ctx)
+	}
+}
+
+// Close stops the background tasks of all plugins.
+func (m *Manager) Close() {
+	m.cancel()
+}
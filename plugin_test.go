@@ -0,0 +1,90 @@
+package debugmonitor
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type testPlugin struct {
+	monitor *Monitor
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func (p *testPlugin) Monitor() *Monitor {
+	return p.monitor
+}
+
+func (p *testPlugin) Assets() fs.FS {
+	return fstest.MapFS{
+		"plugin.js": &fstest.MapFile{Data: []byte("console.log('plugin');")},
+	}
+}
+
+func (p *testPlugin) Run(ctx context.Context) {
+	close(p.started)
+	<-ctx.Done()
+	close(p.stopped)
+}
+
+func TestManager_AddPlugin(t *testing.T) {
+	m := New()
+	plugin := &testPlugin{
+		monitor: &Monitor{Name: "plugin"},
+		started: make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	m.AddPlugin(plugin)
+
+	if len(m.Monitors()) != 1 {
+		t.Fatalf("Expected 1 monitor, got %d", len(m.Monitors()))
+	}
+
+	select {
+	case <-plugin.started:
+	case <-time.After(time.Second):
+		t.Fatal("Expected background task to be started")
+	}
+
+	e := echo.New()
+	e.GET("/monitor", m.Handler())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=plugin&file=plugin.js", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	if rec.Body.String() != "console.log('plugin');" {
+		t.Errorf("Unexpected asset body: %q", rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/javascript; charset=utf-8" {
+		t.Errorf("Unexpected content type: %q", ct)
+	}
+
+	// Files outside the plugin assets are not served
+	for _, path := range []string{
+		"/monitor?monitor=plugin&file=missing.js",
+		"/monitor?monitor=plugin&file=../manager.go",
+		"/monitor?monitor=unknown&file=plugin.js",
+	} {
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s, got %d", path, rec.Code)
+		}
+	}
+
+	m.Close()
+	select {
+	case <-plugin.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected background task to be stopped")
+	}
+}
@@ -0,0 +1,106 @@
+package debugmonitor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// preferencesCookieName is the name of the cookie that holds the UI preferences.
+const preferencesCookieName = "_debugmonitor_prefs"
+
+// Preferences represents the per-user UI preferences of the dashboard.
+// They are persisted in a cookie so that they survive page reloads across monitors.
+type Preferences struct {
+	// Theme is the color theme: "light", "dark", or empty to follow the system setting.
+	Theme string `json:"theme"`
+	// RefreshInterval is the interval in milliseconds at which the dashboard polls for new data.
+	// Zero uses the monitor's PollingInterval setting.
+	RefreshInterval int `json:"refreshInterval"`
+	// HiddenColumns are the names of the columns hidden by the user, keyed by monitor name.
+	HiddenColumns map[string][]string `json:"hiddenColumns,omitempty"`
+}
+
+// loadPreferences reads the UI preferences from the request cookie.
+// It returns the zero Preferences if the cookie is missing or malformed.
+func loadPreferences(c echo.Context) *Preferences {
+	prefs := &Preferences{}
+	cookie, err := c.Cookie(preferencesCookieName)
+	if err != nil {
+		return prefs
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return prefs
+	}
+	if err := json.Unmarshal(data, prefs); err != nil {
+		return &Preferences{}
+	}
+	return prefs
+}
+
+// savePreferences writes the UI preferences to the response cookie.
+func savePreferences(c echo.Context, prefs *Preferences) error {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     preferencesCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(data),
+		Path:     c.Request().URL.Path,
+		Expires:  time.Now().AddDate(1, 0, 0),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return nil
+}
+
+// handlePreferences handles the preferences action.
+// GET returns the current preferences, and POST merges the given preferences and returns the result.
+func handlePreferences(c echo.Context) error {
+	prefs := loadPreferences(c)
+
+	if c.Request().Method == http.MethodPost {
+		update := &struct {
+			Theme           *string             `json:"theme"`
+			RefreshInterval *int                `json:"refreshInterval"`
+			HiddenColumns   map[string][]string `json:"hiddenColumns"`
+		}{}
+		if err := c.Bind(update); err != nil {
+			return err
+		}
+		if update.Theme != nil {
+			switch *update.Theme {
+			case "", "light", "dark":
+				prefs.Theme = *update.Theme
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest, "theme must be light, dark or empty")
+			}
+		}
+		if update.RefreshInterval != nil {
+			if *update.RefreshInterval != 0 && *update.RefreshInterval < 100 {
+				return echo.NewHTTPError(http.StatusBadRequest, "refreshInterval must be 0 or at least 100")
+			}
+			prefs.RefreshInterval = *update.RefreshInterval
+		}
+		for monitorName, columns := range update.HiddenColumns {
+			if prefs.HiddenColumns == nil {
+				prefs.HiddenColumns = make(map[string][]string)
+			}
+			if len(columns) == 0 {
+				delete(prefs.HiddenColumns, monitorName)
+			} else {
+				prefs.HiddenColumns[monitorName] = columns
+			}
+		}
+		if err := savePreferences(c, prefs); err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusOK, prefs)
+}
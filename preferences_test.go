@@ -0,0 +1,71 @@
+package debugmonitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestManager_PreferencesAction(t *testing.T) {
+	m := New()
+	m.AddMonitor(&Monitor{Name: "test"})
+
+	e := echo.New()
+	e.Any("/monitor", m.Handler())
+
+	// POST stores the preferences in a cookie
+	req := withCSRFToken(httptest.NewRequest(http.MethodPost, "/monitor?action=preferences", strings.NewReader(`{"theme":"dark","refreshInterval":2000,"hiddenColumns":{"test":["userAgent"]}}`)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == preferencesCookieName {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("Expected preferences cookie to be set")
+	}
+
+	// GET reads the preferences from the cookie
+	req = httptest.NewRequest(http.MethodGet, "/monitor?action=preferences", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	var prefs Preferences
+	if err := json.Unmarshal(rec.Body.Bytes(), &prefs); err != nil {
+		t.Fatalf("Failed to decode preferences: %v", err)
+	}
+	if prefs.Theme != "dark" || prefs.RefreshInterval != 2000 {
+		t.Errorf("Unexpected preferences: %+v", prefs)
+	}
+	if cols := prefs.HiddenColumns["test"]; len(cols) != 1 || cols[0] != "userAgent" {
+		t.Errorf("Unexpected hidden columns: %v", prefs.HiddenColumns)
+	}
+
+	// The dashboard is rendered with the preferences
+	req = httptest.NewRequest(http.MethodGet, "/monitor?monitor=test", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"theme":"dark"`) {
+		t.Errorf("Expected dashboard to include the preferences")
+	}
+
+	// Invalid preferences are rejected
+	req = withCSRFToken(httptest.NewRequest(http.MethodPost, "/monitor?action=preferences", strings.NewReader(`{"theme":"blue"}`)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
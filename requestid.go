@@ -0,0 +1,22 @@
+package debugmonitor
+
+import "context"
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx that carries the request ID.
+// The requests monitor stores the ID of each request in its context so that
+// other monitors can correlate their entries with the request.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
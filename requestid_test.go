@@ -0,0 +1,16 @@
+package debugmonitor
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestIDFromContext(t *testing.T) {
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("Expected no request ID, got %q", id)
+	}
+	ctx := ContextWithRequestID(context.Background(), "abc123")
+	if id := RequestIDFromContext(ctx); id != "abc123" {
+		t.Errorf("Expected abc123, got %q", id)
+	}
+}
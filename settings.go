@@ -0,0 +1,136 @@
+package debugmonitor
+
+import (
+	"math/rand/v2"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultPollingInterval is the default interval in milliseconds at which the dashboard polls for new data.
+const DefaultPollingInterval = 1000
+
+// MonitorSettings represents the runtime-adjustable settings of a monitor.
+type MonitorSettings struct {
+	// Enabled reports whether the monitor captures data.
+	Enabled bool `json:"enabled"`
+	// MaxRecords is the maximum number of records to keep in the data storage.
+	MaxRecords int `json:"maxRecords"`
+	// SampleRate is the fraction of data the monitor captures, between 0 and 1.
+	SampleRate float64 `json:"sampleRate"`
+	// PollingInterval is the interval in milliseconds at which the dashboard polls for new data.
+	PollingInterval int `json:"pollingInterval"`
+}
+
+// monitorSettingsUpdate is the request body of the settings action.
+// Nil fields are left unchanged.
+type monitorSettingsUpdate struct {
+	Enabled         *bool    `json:"enabled" form:"enabled"`
+	MaxRecords      *int     `json:"maxRecords" form:"maxRecords"`
+	SampleRate      *float64 `json:"sampleRate" form:"sampleRate"`
+	PollingInterval *int     `json:"pollingInterval" form:"pollingInterval"`
+}
+
+// MonitorSettings returns the current settings of the named monitor.
+// The second return value is false if the monitor is not registered.
+func (m *Manager) MonitorSettings(name string) (MonitorSettings, bool) {
+	m.settingsMu.RLock()
+	defer m.settingsMu.RUnlock()
+	settings, ok := m.settings[name]
+	return settings, ok
+}
+
+// UpdateMonitorSettings replaces the settings of the named monitor.
+// MaxRecords is applied to the monitor's data store immediately, removing the oldest records if needed.
+// In production-safe mode, MaxRecords is capped at ProductionSafeMaxRecords and SampleRate at ProductionSafeSampleRate.
+func (m *Manager) UpdateMonitorSettings(name string, settings MonitorSettings) error {
+	monitor, ok := m.monitorByName(name)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "monitor "+name+" not found")
+	}
+
+	if settings.MaxRecords <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "maxRecords must be greater than 0")
+	}
+	if settings.SampleRate < 0 || settings.SampleRate > 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "sampleRate must be between 0 and 1")
+	}
+	if settings.PollingInterval < 100 {
+		return echo.NewHTTPError(http.StatusBadRequest, "pollingInterval must be at least 100")
+	}
+	if m.productionSafe && settings.MaxRecords > ProductionSafeMaxRecords {
+		settings.MaxRecords = ProductionSafeMaxRecords
+	}
+	if m.productionSafe && settings.SampleRate > ProductionSafeSampleRate {
+		settings.SampleRate = ProductionSafeSampleRate
+	}
+
+	m.settingsMu.Lock()
+	m.settings[name] = settings
+	m.settingsMu.Unlock()
+
+	monitor.store.SetMaxRecords(settings.MaxRecords)
+	return nil
+}
+
+// handleSettings handles the settings action of a monitor.
+// GET returns the current settings, and POST updates them and returns the new settings.
+func (m *Manager) handleSettings(c echo.Context, monitor *Monitor) error {
+	settings, _ := m.MonitorSettings(monitor.Name)
+
+	if c.Request().Method == http.MethodPost {
+		update := &monitorSettingsUpdate{}
+		if err := c.Bind(update); err != nil {
+			return err
+		}
+		if update.Enabled != nil {
+			settings.Enabled = *update.Enabled
+		}
+		if update.MaxRecords != nil {
+			settings.MaxRecords = *update.MaxRecords
+		}
+		if update.SampleRate != nil {
+			settings.SampleRate = *update.SampleRate
+		}
+		if update.PollingInterval != nil {
+			settings.PollingInterval = *update.PollingInterval
+		}
+		if err := m.UpdateMonitorSettings(monitor.Name, settings); err != nil {
+			return err
+		}
+		settings, _ = m.MonitorSettings(monitor.Name)
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// Settings returns the current runtime settings of this monitor.
+// It returns the default settings if the monitor is not connected to a Manager.
+func (m *Monitor) Settings() MonitorSettings {
+	if m.manager != nil {
+		if settings, ok := m.manager.MonitorSettings(m.Name); ok {
+			return settings
+		}
+	}
+	return defaultMonitorSettings(m)
+}
+
+// shouldCapture reports whether a payload should be captured according to the monitor's
+// enabled state and, unless unsampled is true, its sample rate.
+func (m *Monitor) shouldCapture(unsampled bool) bool {
+	settings := m.Settings()
+	if !settings.Enabled {
+		return false
+	}
+	return unsampled || settings.SampleRate >= 1 || rand.Float64() < settings.SampleRate
+}
+
+// defaultMonitorSettings returns the initial settings of a monitor.
+func defaultMonitorSettings(monitor *Monitor) MonitorSettings {
+	return MonitorSettings{
+		Enabled:         true,
+		MaxRecords:      monitor.MaxRecords,
+		SampleRate:      1,
+		PollingInterval: DefaultPollingInterval,
+	}
+}
@@ -0,0 +1,123 @@
+package debugmonitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestManager_SettingsAction(t *testing.T) {
+	m := New()
+	monitor := &Monitor{Name: "test", MaxRecords: 10}
+	m.AddMonitor(monitor)
+	for i := 0; i < 5; i++ {
+		monitor.Add(i)
+	}
+
+	e := echo.New()
+	e.Any("/monitor", m.Handler())
+
+	// GET returns the default settings
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/monitor?monitor=test&action=settings", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var settings MonitorSettings
+	if err := json.Unmarshal(rec.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("Failed to decode settings: %v", err)
+	}
+	expected := MonitorSettings{Enabled: true, MaxRecords: 10, SampleRate: 1, PollingInterval: DefaultPollingInterval}
+	if settings != expected {
+		t.Errorf("Expected settings %+v, got %+v", expected, settings)
+	}
+
+	// POST updates only the given settings
+	req := withCSRFToken(httptest.NewRequest(http.MethodPost, "/monitor?monitor=test&action=settings", strings.NewReader(`{"maxRecords":3,"pollingInterval":5000}`)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	expected = MonitorSettings{Enabled: true, MaxRecords: 3, SampleRate: 1, PollingInterval: 5000}
+	if s := monitor.Settings(); s != expected {
+		t.Errorf("Expected settings %+v, got %+v", expected, s)
+	}
+	// The store is trimmed to the new maximum
+	if monitor.store.Len() != 3 {
+		t.Errorf("Expected 3 records, got %d", monitor.store.Len())
+	}
+
+	// Invalid settings are rejected
+	req = withCSRFToken(httptest.NewRequest(http.MethodPost, "/monitor?monitor=test&action=settings", strings.NewReader(`{"sampleRate":2}`)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestMonitor_SettingsEnabled(t *testing.T) {
+	m := New()
+	monitor := &Monitor{Name: "test"}
+	m.AddMonitor(monitor)
+
+	settings := monitor.Settings()
+	settings.Enabled = false
+	if err := m.UpdateMonitorSettings("test", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Disabled monitors do not capture data
+	monitor.Add("ignored")
+	if monitor.store.Len() != 0 {
+		t.Errorf("Expected 0 records, got %d", monitor.store.Len())
+	}
+	if monitor.TryAdd("ignored") {
+		t.Errorf("Expected TryAdd to report that the payload was dropped")
+	}
+
+	settings.Enabled = true
+	settings.SampleRate = 0
+	if err := m.UpdateMonitorSettings("test", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// A zero sample rate captures nothing
+	monitor.Add("ignored")
+	if monitor.store.Len() != 0 {
+		t.Errorf("Expected 0 records, got %d", monitor.store.Len())
+	}
+
+	settings.SampleRate = 1
+	if err := m.UpdateMonitorSettings("test", settings); err != nil {
+		t.Fatal(err)
+	}
+	if !monitor.TryAdd("stored") || monitor.store.Len() != 1 {
+		t.Errorf("Expected TryAdd to store the payload, got %d records", monitor.store.Len())
+	}
+}
+
+func TestManager_UpdateMonitorSettingsProductionSafe(t *testing.T) {
+	m := NewProductionSafe()
+	monitor := &Monitor{Name: "test"}
+	m.AddMonitor(monitor)
+
+	// The sample rate cannot be raised above the production-safe rate from the dashboard
+	settings := monitor.Settings()
+	settings.SampleRate = 1
+	settings.MaxRecords = 1000
+	if err := m.UpdateMonitorSettings("test", settings); err != nil {
+		t.Fatal(err)
+	}
+	settings = monitor.Settings()
+	if settings.SampleRate != ProductionSafeSampleRate || settings.MaxRecords != ProductionSafeMaxRecords {
+		t.Errorf("Expected the settings to be capped, got %+v", settings)
+	}
+}
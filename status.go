@@ -0,0 +1,79 @@
+package debugmonitor
+
+import (
+	"runtime"
+	"time"
+)
+
+// Status represents the health and runtime status of the debug monitor itself.
+type Status struct {
+	StartedAt     time.Time        `json:"startedAt"`
+	Uptime        string           `json:"uptime"`
+	UptimeSeconds float64          `json:"uptimeSeconds"`
+	GoVersion     string           `json:"goVersion"`
+	NumGoroutine  int              `json:"numGoroutine"`
+	NumMonitors   int              `json:"numMonitors"`
+	TotalEntries  int              `json:"totalEntries"`
+	Subscribers   int              `json:"subscribers"`
+	Memory        MemoryStatus     `json:"memory"`
+	Monitors      []*MonitorStatus `json:"monitors"`
+}
+
+// MonitorStatus represents the status of a single monitor.
+type MonitorStatus struct {
+	Name        string `json:"name"`
+	Entries     int    `json:"entries"`
+	MaxRecords  int    `json:"maxRecords"`
+	Subscribers int    `json:"subscribers"`
+}
+
+// MemoryStatus represents the memory statistics of the process.
+type MemoryStatus struct {
+	Alloc      uint64 `json:"alloc"`
+	TotalAlloc uint64 `json:"totalAlloc"`
+	Sys        uint64 `json:"sys"`
+	HeapAlloc  uint64 `json:"heapAlloc"`
+	HeapInuse  uint64 `json:"heapInuse"`
+	NumGC      uint32 `json:"numGC"`
+}
+
+// Status returns the current health and runtime status of the manager and its monitors.
+func (m *Manager) Status() *Status {
+	monitors := m.Monitors()
+
+	uptime := time.Since(m.startedAt)
+	status := &Status{
+		StartedAt:     m.startedAt,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: uptime.Seconds(),
+		GoVersion:     runtime.Version(),
+		NumGoroutine:  runtime.NumGoroutine(),
+		NumMonitors:   len(monitors),
+		Monitors:      make([]*MonitorStatus, 0, len(monitors)),
+	}
+
+	for _, monitor := range monitors {
+		ms := &MonitorStatus{
+			Name:        monitor.Name,
+			Entries:     monitor.store.Len(),
+			MaxRecords:  monitor.store.MaxRecords(),
+			Subscribers: monitor.store.numStreams(),
+		}
+		status.TotalEntries += ms.Entries
+		status.Subscribers += ms.Subscribers
+		status.Monitors = append(status.Monitors, ms)
+	}
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	status.Memory = MemoryStatus{
+		Alloc:      memStats.Alloc,
+		TotalAlloc: memStats.TotalAlloc,
+		Sys:        memStats.Sys,
+		HeapAlloc:  memStats.HeapAlloc,
+		HeapInuse:  memStats.HeapInuse,
+		NumGC:      memStats.NumGC,
+	}
+
+	return status
+}
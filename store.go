@@ -2,24 +2,45 @@ package debugmonitor
 
 import (
 	"container/list"
+	"encoding/json"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // DataEntry represents a single data record with its ID.
 type DataEntry struct {
 	Id      int64 `json:"id"`
 	Payload any   `json:"payload"`
+
+	// text is the lowercase JSON encoding of the payload searched by the TextFilterParam filters.
+	// It is encoded once for the filters of all subscribers.
+	textOnce sync.Once
+	text     string
+}
+
+// searchText returns the lowercase JSON encoding of the payload, or an empty string if it cannot be encoded.
+func (e *DataEntry) searchText() string {
+	e.textOnce.Do(func() {
+		if data, err := json.Marshal(e.Payload); err == nil {
+			e.text = strings.ToLower(string(data))
+		}
+	})
+	return e.text
 }
 
 // AddEvent represents a subscription to Add events.
 // Use the C channel to receive notifications when new data is added.
 // Call Close() when done to clean up resources.
 type AddEvent struct {
-	C      <-chan *DataEntry // Channel to receive Add events
-	store  *Store
-	ch     chan *DataEntry
-	closed bool
-	mu     sync.Mutex
+	C       <-chan *DataEntry // Channel to receive Add events
+	store   *Store
+	ch      chan *DataEntry
+	filter  EntryFilter  // entries that do not match are not sent to C, nil for all entries
+	dropped atomic.Int64 // entries dropped because the channel was full
+	closed  bool
+	mu      sync.Mutex
 }
 
 // Close unsubscribes from the Store and closes the event channel.
@@ -71,13 +92,16 @@ func (e *ClearEvent) Close() {
 type Store struct {
 	mu            sync.RWMutex
 	maxRecords    int
-	idGen         *IDGenerator            // Snowflake-style ID generator
-	entries       map[int64]*list.Element // map for O(1) access by ID
-	order         *list.List              // doubly linked list to maintain insertion order
-	addEventsMu   sync.RWMutex            // protects addEvents slice
-	addEvents     []*AddEvent             // active Add event subscriptions
-	clearEventsMu sync.RWMutex            // protects clearEvents slice
-	clearEvents   []*ClearEvent           // active Clear event subscriptions
+	idGen         *IDGenerator             // Snowflake-style ID generator
+	entries       map[int64]*list.Element  // map for O(1) access by ID
+	order         *list.List               // doubly linked list to maintain insertion order
+	clearedAt     time.Time                // time of the last Clear, zero if the store has never been cleared
+	addEventsMu   sync.RWMutex             // protects addEvents slice
+	addEvents     []*AddEvent              // active Add event subscriptions
+	clearEventsMu sync.RWMutex             // protects clearEvents slice
+	clearEvents   []*ClearEvent            // active Clear event subscriptions
+	subscribersMu sync.Mutex               // protects subscribers
+	subscribers   map[*subscriber]struct{} // clients tracked for Subscribers
 }
 
 // NewStore creates a new Store with the specified maximum number of records.
@@ -101,6 +125,11 @@ func NewStore(maxRecords int) *Store {
 // If the store is at capacity, the oldest record is removed.
 // After adding, all registered listeners are notified with the new entry.
 func (s *Store) Add(payload any) {
+	s.AddEntry(payload)
+}
+
+// AddEntry is like Add but returns the added entry.
+func (s *Store) AddEntry(payload any) *DataEntry {
 	s.mu.Lock()
 
 	// Generate Snowflake-style ID
@@ -130,6 +159,8 @@ func (s *Store) Add(payload any) {
 
 	// Notify add event subscribers outside the lock to prevent deadlocks
 	s.notifyAddEvents(entry)
+
+	return entry
 }
 
 // GetLatest returns all data entries in reverse chronological order (newest first).
@@ -234,6 +265,62 @@ func (s *Store) Len() int {
 	return s.order.Len()
 }
 
+// ClearedAt returns the time the store was last cleared, or the zero time if it has never been cleared.
+// Polling clients compare it between responses to detect that their entries are stale.
+func (s *Store) ClearedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clearedAt
+}
+
+// MaxRecords returns the maximum number of records the store keeps.
+func (s *Store) MaxRecords() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxRecords
+}
+
+// SetMaxRecords changes the maximum number of records the store keeps.
+// If the store holds more records than the new limit, the oldest records are removed.
+// A non-positive value is ignored.
+func (s *Store) SetMaxRecords(maxRecords int) {
+	if maxRecords <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxRecords = maxRecords
+	for s.order.Len() > s.maxRecords {
+		oldest := s.order.Front()
+		oldEntry := oldest.Value.(*DataEntry)
+		delete(s.entries, oldEntry.Id)
+		s.order.Remove(oldest)
+	}
+}
+
+// NumSubscribers returns the current number of active Add and Clear event subscriptions.
+func (s *Store) NumSubscribers() int {
+	s.addEventsMu.RLock()
+	n := len(s.addEvents)
+	s.addEventsMu.RUnlock()
+
+	s.clearEventsMu.RLock()
+	n += len(s.clearEvents)
+	s.clearEventsMu.RUnlock()
+
+	return n
+}
+
+// numStreams returns the number of active Add event subscriptions. Each streaming client also subscribes
+// to Clear events, so the Clear event subscriptions are not counted to count each client once.
+func (s *Store) numStreams() int {
+	s.addEventsMu.RLock()
+	defer s.addEventsMu.RUnlock()
+	return len(s.addEvents)
+}
+
 // Clear removes all records from the store.
 // After clearing, all registered clear listeners are notified.
 func (s *Store) Clear() {
@@ -242,6 +329,7 @@ func (s *Store) Clear() {
 	s.idGen = NewIDGenerator()
 	s.entries = make(map[int64]*list.Element)
 	s.order.Init()
+	s.clearedAt = time.Now()
 
 	s.mu.Unlock()
 
@@ -254,11 +342,20 @@ func (s *Store) Clear() {
 // when new data is added to the Store.
 // Call Close() on the returned AddEvent when done to clean up resources.
 func (s *Store) NewAddEvent() *AddEvent {
+	return s.NewAddEventWithFilter(nil)
+}
+
+// NewAddEventWithFilter is like NewAddEvent but the returned AddEvent receives only the entries that match the filter.
+// The filter is applied before the entries are queued, so that the entries that do not match do not fill
+// the channel buffer and cause matching entries to be skipped. A nil filter matches all entries.
+// The filter is called by the goroutine that adds the entry, so it must be fast and safe for concurrent use.
+func (s *Store) NewAddEventWithFilter(filter EntryFilter) *AddEvent {
 	ch := make(chan *DataEntry, 10) // Buffered to prevent blocking
 	event := &AddEvent{
-		C:     ch,
-		store: s,
-		ch:    ch,
+		C:      ch,
+		store:  s,
+		ch:     ch,
+		filter: filter,
 	}
 
 	s.addEventsMu.Lock()
@@ -320,10 +417,14 @@ func (s *Store) notifyAddEvents(entry *DataEntry) {
 	defer s.addEventsMu.RUnlock()
 
 	for _, event := range s.addEvents {
+		if event.filter != nil && !event.filter(entry) {
+			continue
+		}
 		select {
 		case event.ch <- entry:
 		default:
 			// Channel is full, skip this subscriber to avoid blocking
+			event.dropped.Add(1)
 		}
 	}
 }
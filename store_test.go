@@ -364,6 +364,45 @@ func TestStore_NewAddEvent(t *testing.T) {
 	}
 }
 
+func TestStore_NewAddEventWithFilter(t *testing.T) {
+	store := NewStore(100)
+
+	event := store.NewAddEventWithFilter(func(entry *DataEntry) bool {
+		return entry.Payload == "match"
+	})
+	defer event.Close()
+
+	// The entries that do not match do not fill the channel buffer
+	for i := 0; i < 20; i++ {
+		store.Add("skip")
+	}
+	store.Add("match")
+
+	select {
+	case entry := <-event.C:
+		if entry.Payload != "match" {
+			t.Errorf("Expected the matching entry, got %v", entry.Payload)
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Timeout waiting for notification")
+	}
+}
+
+func TestStore_AddEventDropped(t *testing.T) {
+	store := NewStore(100)
+
+	event := store.NewAddEvent()
+	defer event.Close()
+
+	// The entries over the channel buffer are dropped and counted
+	for i := 0; i < 15; i++ {
+		store.Add(i)
+	}
+	if dropped := event.dropped.Load(); dropped != 5 {
+		t.Errorf("Expected 5 dropped entries, got %d", dropped)
+	}
+}
+
 func TestStore_MultipleAddSubscribers(t *testing.T) {
 	store := NewStore(10)
 
@@ -456,3 +495,54 @@ func TestStore_EventClose(t *testing.T) {
 	// Calling Close again should be safe
 	event.Close()
 }
+
+func TestStore_NumSubscribers(t *testing.T) {
+	store := NewStore(10)
+
+	if store.NumSubscribers() != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", store.NumSubscribers())
+	}
+
+	addEvent := store.NewAddEvent()
+	clearEvent := store.NewClearEvent()
+	if store.NumSubscribers() != 2 {
+		t.Errorf("Expected 2 subscribers, got %d", store.NumSubscribers())
+	}
+
+	addEvent.Close()
+	clearEvent.Close()
+	if store.NumSubscribers() != 0 {
+		t.Errorf("Expected 0 subscribers after close, got %d", store.NumSubscribers())
+	}
+}
+
+func TestStore_SetMaxRecords(t *testing.T) {
+	store := NewStore(5)
+	for i := 1; i <= 5; i++ {
+		store.Add(map[string]any{"index": i})
+	}
+
+	store.SetMaxRecords(2)
+	if store.MaxRecords() != 2 {
+		t.Errorf("Expected max records 2, got %d", store.MaxRecords())
+	}
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 records, got %d", store.Len())
+	}
+
+	// The newest records remain
+	allData := store.GetSince(0)
+	expectedIndexes := []int{4, 5}
+	for i, entry := range allData {
+		payloadMap := entry.Payload.(map[string]any)
+		if payloadMap["index"] != expectedIndexes[i] {
+			t.Errorf("Expected index %d at position %d, got %v", expectedIndexes[i], i, payloadMap["index"])
+		}
+	}
+
+	// Non-positive values are ignored
+	store.SetMaxRecords(0)
+	if store.MaxRecords() != 2 {
+		t.Errorf("Expected max records 2, got %d", store.MaxRecords())
+	}
+}
@@ -0,0 +1,242 @@
+package debugmonitor
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StreamVersion is the version of the StreamEvent envelope sent by HandleSSEStream.
+// It is incremented on incompatible changes of the envelope.
+const StreamVersion = 1
+
+// The types of the events sent by HandleSSEStream. The name of each SSE event is its type.
+const (
+	StreamEventAdd     = "add"     // an entry was added
+	StreamEventBatch   = "batch"   // entries were added, batched by WithStreamBatching
+	StreamEventClear   = "clear"   // the store was cleared
+	StreamEventSkipped = "skipped" // entries were skipped by WithStreamRateLimit
+	StreamEventStats   = "stats"   // the periodic stats of the store
+)
+
+// StreamEvent is the envelope of the events sent by HandleSSEStream, so that clients can tell the additions
+// of entries from the control messages. Only the fields of its type are set.
+type StreamEvent struct {
+	Version   int          `json:"v"`
+	Type      string       `json:"type"`
+	Monitor   string       `json:"monitor,omitempty"`
+	Entry     *DataEntry   `json:"entry,omitempty"`     // add
+	Entries   []*DataEntry `json:"entries,omitempty"`   // batch
+	ClearedAt int64        `json:"clearedAt,omitempty"` // clear, in Unix milliseconds
+	Count     int          `json:"count,omitempty"`     // skipped
+	Stats     *StreamStats `json:"stats,omitempty"`     // stats
+}
+
+// StreamStats is the stats of the store sent in the "stats" events.
+type StreamStats struct {
+	Len        int `json:"len"`
+	MaxRecords int `json:"maxRecords"`
+}
+
+// DefaultStreamBatchSize is the maximum number of entries in a batch when WithStreamBatching is given
+// a non-positive size.
+const DefaultStreamBatchSize = 100
+
+// streamConfig is the configuration of the streams served by HandleSSEStream, set by the manager options.
+type streamConfig struct {
+	batchInterval time.Duration // zero disables batching
+	batchSize     int
+	rateLimit     float64 // maximum entries per second of each connection, zero for no limit
+	compress      bool    // compress the responses with gzip for the clients that accept it
+	maxStreams    int     // maximum simultaneous streams of the manager, zero for no limit
+	streams       *atomic.Int64
+}
+
+// streamConfigKey is the key of the echo.Context value that carries the streamConfig of the manager.
+const streamConfigKey = "debugmonitor.streamConfig"
+
+// WithStreamBatching enables batching of the entries sent by HandleSSEStream. Instead of sending each entry
+// in an "add" event, the entries added within interval are sent together as one "batch" event with the entries
+// in StreamEvent.Entries, or as soon as size entries are pending. It reduces the writes and the rendering of the dashboard
+// under bursty writes, such as hundreds of queries per request. A non-positive size means DefaultStreamBatchSize.
+func WithStreamBatching(interval time.Duration, size int) Option {
+	return func(m *Manager) {
+		if size <= 0 {
+			size = DefaultStreamBatchSize
+		}
+		m.stream.batchInterval = interval
+		m.stream.batchSize = size
+	}
+}
+
+// StreamSkippedNoticeInterval is the interval of the "skipped" events sent by HandleSSEStream while
+// entries are skipped by the rate limit set with WithStreamRateLimit.
+const StreamSkippedNoticeInterval = time.Second
+
+// WithStreamRateLimit limits the entries sent by HandleSSEStream to perSecond entries per second on each connection,
+// with bursts of up to one second worth of entries. The entries over the limit are skipped and summarized by
+// a "skipped" event with the number of skipped entries in StreamEvent.Count, so that a runaway log loop does not saturate the browser
+// or the network while the dashboard still indicates the data loss. A non-positive perSecond removes the limit.
+func WithStreamRateLimit(perSecond float64) Option {
+	return func(m *Manager) {
+		if perSecond < 0 {
+			perSecond = 0
+		}
+		m.stream.rateLimit = perSecond
+	}
+}
+
+// rateLimiter is a token bucket that allows rate events per second with bursts of up to rate events,
+// or one event if rate is less than one.
+type rateLimiter struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a rateLimiter whose bucket is full.
+func newRateLimiter(rate float64) *rateLimiter {
+	return &rateLimiter{rate: rate, tokens: max(rate, 1), last: time.Now()}
+}
+
+// allow reports whether an event is allowed at now, and takes a token if it is.
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, max(l.rate, 1))
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// CompressionMinSize is the minimum size in bytes of the JSON responses of HandleDataJSON that are compressed
+// when compression is enabled with WithCompression. Smaller responses are sent as is.
+const CompressionMinSize = 1024
+
+// WithCompression enables gzip compression of the responses of HandleDataJSON and the streams of HandleSSEStream
+// for the clients that accept it, which reduces the size of large payloads such as headers, SQL and stack traces.
+// Each SSE event is flushed through the compressor, so that it is delivered immediately.
+// Do not use it together with a compression middleware on the dashboard route.
+func WithCompression() Option {
+	return func(m *Manager) {
+		m.stream.compress = true
+	}
+}
+
+// WithMaxStreams limits the streams served by HandleSSEStream to n simultaneous connections across all
+// the monitors of the manager. Beyond it, new stream requests get a 503 Service Unavailable response whose JSON body
+// is {"error": "...", "fallback": "polling"}, and the dashboard switches to polling, so that many open dashboard tabs
+// do not exhaust the connections and goroutines of the application. A non-positive n removes the limit.
+func WithMaxStreams(n int) Option {
+	return func(m *Manager) {
+		if n < 0 {
+			n = 0
+		}
+		m.stream.maxStreams = n
+		m.stream.streams = new(atomic.Int64)
+	}
+}
+
+// StreamLimitResponse is the JSON body of the 503 response to the stream requests over the limit
+// set with WithMaxStreams.
+type StreamLimitResponse struct {
+	Error    string `json:"error"`
+	Fallback string `json:"fallback"` // the transport the client should use instead, "polling"
+}
+
+// acquireStream reports whether a new stream is allowed by the limit, and counts it if it is.
+// An allowed stream must be released with releaseStream.
+func (cfg streamConfig) acquireStream() bool {
+	if cfg.maxStreams <= 0 {
+		return true
+	}
+	if cfg.streams.Add(1) > int64(cfg.maxStreams) {
+		cfg.streams.Add(-1)
+		return false
+	}
+	return true
+}
+
+// releaseStream uncounts a stream allowed by acquireStream.
+func (cfg streamConfig) releaseStream() {
+	if cfg.maxStreams > 0 {
+		cfg.streams.Add(-1)
+	}
+}
+
+// acceptsGzip reports whether the client accepts gzip-encoded responses.
+func acceptsGzip(c echo.Context) bool {
+	return strings.Contains(c.Request().Header.Get(echo.HeaderAcceptEncoding), "gzip")
+}
+
+// gzipStreamWriter compresses the stream written to the wrapped http.ResponseWriter. Flush flushes the data
+// pending in the compressor before flushing the response, so that each SSE event reaches the client.
+type gzipStreamWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipStreamWriter) Write(p []byte) (int, error) {
+	return w.gz.Write(p)
+}
+
+func (w *gzipStreamWriter) Flush() {
+	_ = w.gz.Flush()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// compressStream replaces the writer of the response with a gzipStreamWriter. It must be called before
+// the header is written. The returned function finishes the compressed stream and restores the writer.
+func compressStream(c echo.Context) func() {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentEncoding, "gzip")
+	res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+	w := &gzipStreamWriter{ResponseWriter: res.Writer, gz: gzip.NewWriter(res.Writer)}
+	res.Writer = w
+	return func() {
+		_ = w.gz.Close()
+		res.Writer = w.ResponseWriter
+	}
+}
+
+// writeJSON writes v as the JSON response, compressed with gzip if compression is enabled,
+// the client accepts it and the response is at least CompressionMinSize bytes.
+func writeJSON(c echo.Context, cfg streamConfig, code int, v any) error {
+	if !cfg.compress || !acceptsGzip(c) {
+		return c.JSON(code, v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	res := c.Response()
+	res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+	if len(data) < CompressionMinSize {
+		return c.JSONBlob(code, data)
+	}
+	res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res.Header().Set(echo.HeaderContentEncoding, "gzip")
+	res.WriteHeader(code)
+	gz := gzip.NewWriter(res)
+	if _, err := gz.Write(data); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// streamConfigFromContext returns the streamConfig set by the manager that dispatched the action,
+// or the zero config if the handler is not called through a manager.
+func streamConfigFromContext(c echo.Context) streamConfig {
+	if cfg, ok := c.Get(streamConfigKey).(*streamConfig); ok {
+		return *cfg
+	}
+	return streamConfig{}
+}
@@ -0,0 +1,32 @@
+package debugmonitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2)
+	now := l.last
+
+	// A burst of up to the rate is allowed
+	if !l.allow(now) || !l.allow(now) {
+		t.Fatal("Expected the burst to be allowed")
+	}
+	if l.allow(now) {
+		t.Error("Expected the event over the burst to be denied")
+	}
+	// A token is added every half a second
+	if !l.allow(now.Add(500 * time.Millisecond)) {
+		t.Error("Expected the event after the refill to be allowed")
+	}
+	if l.allow(now.Add(500 * time.Millisecond)) {
+		t.Error("Expected the event to be denied until the next refill")
+	}
+
+	// A rate of less than one still allows one event
+	l = newRateLimiter(0.5)
+	if !l.allow(l.last) || l.allow(l.last) {
+		t.Error("Expected one event to be allowed")
+	}
+}
@@ -0,0 +1,182 @@
+package debugmonitor
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// The transports of the clients reported by Store.Subscribers.
+const (
+	TransportSSE      = "sse"
+	TransportLongPoll = "longpoll"
+	TransportPoll     = "poll"
+)
+
+// PollSubscriberTTL is how long a polling client is reported by Store.Subscribers after its last request.
+const PollSubscriberTTL = 10 * time.Second
+
+// SubscriberInfo describes a client of the entries of a store. Streaming clients are reported while they are
+// connected, and polling clients, keyed by their ClientParam or else their address, while they keep requesting
+// the entries.
+type SubscriberInfo struct {
+	Transport   string    `json:"transport"`
+	ClientID    string    `json:"clientId,omitempty"` // the ClientParam of a polling client
+	RemoteAddr  string    `json:"remoteAddr"`
+	Since       int64     `json:"since"`   // the ID of the last entry sent to the client
+	Dropped     int64     `json:"dropped"` // entries dropped because the client did not keep up with the store
+	Skipped     int64     `json:"skipped"` // entries skipped by the rate limit set with WithStreamRateLimit
+	ConnectedAt time.Time `json:"connectedAt"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+}
+
+// MonitorSubscribers represents the clients of a single monitor.
+type MonitorSubscribers struct {
+	Name        string            `json:"name"`
+	SSE         int               `json:"sse"`
+	LongPoll    int               `json:"longpoll"`
+	Poll        int               `json:"poll"`
+	Subscribers []*SubscriberInfo `json:"subscribers"`
+}
+
+// Subscribers returns the clients of each monitor, so that a dashboard that stops updating can be diagnosed
+// as a subscription problem on the server, such as dropped entries or a stale cursor, or as a frontend issue.
+func (m *Manager) Subscribers() []*MonitorSubscribers {
+	monitors := m.Monitors()
+	result := make([]*MonitorSubscribers, 0, len(monitors))
+	for _, monitor := range monitors {
+		ms := &MonitorSubscribers{
+			Name:        monitor.Name,
+			Subscribers: monitor.store.Subscribers(),
+		}
+		for _, sub := range ms.Subscribers {
+			switch sub.Transport {
+			case TransportSSE:
+				ms.SSE++
+			case TransportLongPoll:
+				ms.LongPoll++
+			case TransportPoll:
+				ms.Poll++
+			}
+		}
+		result = append(result, ms)
+	}
+	return result
+}
+
+// subscriber tracks a client of a store for Store.Subscribers.
+type subscriber struct {
+	transport   string
+	clientID    string
+	remoteAddr  string
+	connectedAt time.Time
+	event       *AddEvent // the subscription of a streaming client, nil for polling clients
+	since       atomic.Int64
+	skipped     atomic.Int64
+	lastSeen    atomic.Int64 // Unix nanoseconds of the last request or, for a streaming client, of the last event
+	inFlight    atomic.Int32 // requests of a polling client in progress
+}
+
+// trackStream tracks a streaming client until untrack is called.
+func (s *Store) trackStream(transport, remoteAddr string, event *AddEvent, since int64) *subscriber {
+	now := time.Now()
+	sub := &subscriber{transport: transport, remoteAddr: remoteAddr, connectedAt: now, event: event}
+	sub.since.Store(since)
+	sub.lastSeen.Store(now.UnixNano())
+
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	if s.subscribers == nil {
+		s.subscribers = make(map[*subscriber]struct{})
+	}
+	s.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// sent records that an event was sent to a streaming client, with the ID of the last entry if it carried entries.
+func (sub *subscriber) sent(id int64) {
+	if id > 0 {
+		sub.since.Store(id)
+	}
+	sub.lastSeen.Store(time.Now().UnixNano())
+}
+
+// untrack stops tracking a client.
+func (s *Store) untrack(sub *subscriber) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	delete(s.subscribers, sub)
+}
+
+// trackPoll tracks a request of a polling client, identified by its transport and client ID, or by its transport
+// and address if it sends no client ID, and returns the function that records the end of the request with
+// the cursor of the client, which is the ID of the last entry sent to it. It also forgets the other polling clients
+// that have made no request within PollSubscriberTTL.
+func (s *Store) trackPoll(transport, clientID, remoteAddr string) func(since int64) {
+	now := time.Now()
+
+	expired := now.Add(-PollSubscriberTTL).UnixNano()
+
+	s.subscribersMu.Lock()
+	var sub *subscriber
+	for candidate := range s.subscribers {
+		if candidate.event != nil {
+			continue
+		}
+		if candidate.transport == transport && candidate.clientID == clientID && (clientID != "" || candidate.remoteAddr == remoteAddr) {
+			sub = candidate
+		} else if candidate.inFlight.Load() == 0 && candidate.lastSeen.Load() < expired {
+			delete(s.subscribers, candidate)
+		}
+	}
+	if sub == nil {
+		sub = &subscriber{transport: transport, clientID: clientID, remoteAddr: remoteAddr, connectedAt: now}
+		if s.subscribers == nil {
+			s.subscribers = make(map[*subscriber]struct{})
+		}
+		s.subscribers[sub] = struct{}{}
+	}
+	sub.lastSeen.Store(now.UnixNano())
+	sub.inFlight.Add(1)
+	s.subscribersMu.Unlock()
+
+	return func(since int64) {
+		sub.since.Store(since)
+		sub.lastSeen.Store(time.Now().UnixNano())
+		sub.inFlight.Add(-1)
+	}
+}
+
+// Subscribers returns the clients of the store. Polling clients that have made no request
+// within PollSubscriberTTL are forgotten.
+func (s *Store) Subscribers() []*SubscriberInfo {
+	expired := time.Now().Add(-PollSubscriberTTL).UnixNano()
+
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+
+	result := make([]*SubscriberInfo, 0, len(s.subscribers))
+	for sub := range s.subscribers {
+		if sub.event == nil && sub.inFlight.Load() == 0 && sub.lastSeen.Load() < expired {
+			delete(s.subscribers, sub)
+			continue
+		}
+		info := &SubscriberInfo{
+			Transport:   sub.transport,
+			ClientID:    sub.clientID,
+			RemoteAddr:  sub.remoteAddr,
+			Since:       sub.since.Load(),
+			Skipped:     sub.skipped.Load(),
+			ConnectedAt: sub.connectedAt,
+			LastSeenAt:  time.Unix(0, sub.lastSeen.Load()),
+		}
+		if sub.event != nil {
+			info.Dropped = sub.event.dropped.Load()
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ConnectedAt.Before(result[j].ConnectedAt)
+	})
+	return result
+}
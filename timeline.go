@@ -0,0 +1,57 @@
+package debugmonitor
+
+import (
+	"sort"
+	"time"
+)
+
+// TimelinePayload is implemented by payloads that are captured while serving an HTTP request.
+// The manager uses it to collect the entries of all monitors that belong to a request.
+type TimelinePayload interface {
+	// TimelineRequestID returns the ID of the request the payload was captured in.
+	// An empty string means the payload does not belong to any request.
+	TimelineRequestID() string
+	// TimelineTime returns the time the payload was captured at.
+	TimelineTime() time.Time
+	// TimelineSummary returns a one-line description of the payload.
+	TimelineSummary() string
+}
+
+// TimelineEntry is an entry of a monitor in the timeline of a request.
+type TimelineEntry struct {
+	Monitor     string     `json:"monitor"`
+	DisplayName string     `json:"displayName"`
+	Time        time.Time  `json:"time"`
+	Summary     string     `json:"summary"`
+	Entry       *DataEntry `json:"entry"`
+}
+
+// Timeline returns the entries of all monitors that belong to the request with the given ID,
+// in chronological order.
+func (m *Manager) Timeline(requestID string) []*TimelineEntry {
+	timeline := []*TimelineEntry{}
+	if requestID == "" {
+		return timeline
+	}
+
+	for _, monitor := range m.Monitors() {
+		for _, entry := range monitor.store.GetLatest() {
+			payload, ok := entry.Payload.(TimelinePayload)
+			if !ok || payload.TimelineRequestID() != requestID {
+				continue
+			}
+			timeline = append(timeline, &TimelineEntry{
+				Monitor:     monitor.Name,
+				DisplayName: monitor.DisplayName,
+				Time:        payload.TimelineTime(),
+				Summary:     payload.TimelineSummary(),
+				Entry:       entry,
+			})
+		}
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time.Before(timeline[j].Time)
+	})
+	return timeline
+}
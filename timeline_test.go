@@ -0,0 +1,48 @@
+package debugmonitor
+
+import (
+	"testing"
+	"time"
+)
+
+type timelineTestPayload struct {
+	requestID string
+	time      time.Time
+	summary   string
+}
+
+func (p *timelineTestPayload) TimelineRequestID() string { return p.requestID }
+func (p *timelineTestPayload) TimelineTime() time.Time   { return p.time }
+func (p *timelineTestPayload) TimelineSummary() string   { return p.summary }
+
+func TestManager_Timeline(t *testing.T) {
+	m := New()
+	a := &Monitor{Name: "a", DisplayName: "A"}
+	b := &Monitor{Name: "b", DisplayName: "B"}
+	m.AddMonitor(a)
+	m.AddMonitor(b)
+
+	now := time.Now()
+	a.Add(&timelineTestPayload{requestID: "r1", time: now.Add(2 * time.Millisecond), summary: "third"})
+	b.Add(&timelineTestPayload{requestID: "r1", time: now, summary: "first"})
+	b.Add(&timelineTestPayload{requestID: "r2", time: now, summary: "other request"})
+	a.Add(&timelineTestPayload{requestID: "r1", time: now.Add(time.Millisecond), summary: "second"})
+	a.Add("not a timeline payload")
+
+	timeline := m.Timeline("r1")
+	if len(timeline) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(timeline))
+	}
+	for i, want := range []string{"first", "second", "third"} {
+		if timeline[i].Summary != want {
+			t.Errorf("Expected entry %d to be %q, got %q", i, want, timeline[i].Summary)
+		}
+	}
+	if timeline[0].Monitor != "b" || timeline[0].DisplayName != "B" {
+		t.Errorf("Expected the first entry to come from monitor b, got %s", timeline[0].Monitor)
+	}
+
+	if timeline := m.Timeline(""); len(timeline) != 0 {
+		t.Errorf("Expected no entries for an empty request ID, got %d", len(timeline))
+	}
+}